@@ -4,7 +4,28 @@ const (
 	EMAIL  int = 1
 	MOBILE int = 2
 
-	TIME_OTP_REGISTER     int = 1
-	TIME_2FA_OTP_REGISTER int = 30 // 30 phút cho OTP 2FA
-	TIME_REFRESH_TOKEN    int = 240
+	TIME_OTP_REGISTER        int = 1
+	TIME_2FA_OTP_REGISTER    int = 30 // 30 phút cho OTP 2FA
+	TIME_2FA_LOGIN_CHALLENGE int = 5  // 5 phút để hoàn tất đăng nhập 2FA
+	TIME_REFRESH_TOKEN       int = 240
+	TIME_OTP_RESET_PASSWORD  int = 10 // 10 phút để đặt lại mật khẩu
+	TIME_OTP_CHANGE_EMAIL    int = 10 // 10 phút để xác nhận email mới
+
+	PASSWORD_MIN_LENGTH int = 8
+
+	// pre_go_acc_user_verify_9999.verify_purpose, OTP chỉ dùng đúng mục đích
+	VERIFY_PURPOSE_REGISTER       string = "REGISTER"
+	VERIFY_PURPOSE_RESET_PASSWORD string = "RESET_PASSWORD"
+	VERIFY_PURPOSE_CHANGE_EMAIL   string = "CHANGE_EMAIL"
+
+	// Authenticator app (TOTP) two-factor authentication
+	TOTP_ISSUER               string = "GN Farm"
+	TWO_FACTOR_RECOVERY_CODES int    = 10 // số recovery code cấp cho mỗi lần bật 2FA
+)
+
+// pre_go_acc_user_info_9999.user_state
+const (
+	UserStateLocked       uint8 = 0
+	UserStateActivated    uint8 = 1
+	UserStateNotActivated uint8 = 2
 )
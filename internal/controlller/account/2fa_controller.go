@@ -5,7 +5,6 @@ import (
 	"go_ecommerce/internal/service"
 	"go_ecommerce/internal/utils/context"
 	"go_ecommerce/pkg/response"
-	"log"
 
 	"github.com/gin-gonic/gin"
 )
@@ -33,19 +32,18 @@ func (c *sUser2FA) SetupTwoFactorAuth(ctx *gin.Context) {
 		return
 	}
 	// get UserId from uuid (token)
-	userId, err := context.GetUserIdFromUUID(ctx.Request.Context())
+	principal, err := context.GetPrincipal(ctx)
 	if err != nil {
 		response.ErrorResponse(ctx, response.ErrCodeTwoFactorAuthSetupFailed, "UserId is not valid")
 		return
 	}
-	log.Println("UserId: ", userId)
-	params.UserId = uint32(userId)
-	codeResult, err := service.UserLogin().SetupTwoFactorAuth(ctx, &params)
+	params.UserId = uint32(principal.UserId)
+	codeResult, dataRs, err := service.UserLogin().SetupTwoFactorAuth(ctx, &params)
 	if err != nil {
-		response.ErrorResponse(ctx, response.ErrCodeTwoFactorAuthSetupFailed, err.Error())
+		response.ErrorResponse(ctx, codeResult, err.Error())
 		return
 	}
-	response.SuccessResponse(ctx, codeResult, nil)
+	response.SuccessResponse(ctx, codeResult, dataRs)
 
 }
 
@@ -69,18 +67,53 @@ func (c *sUser2FA) VerifyTwoFactorAuth(ctx *gin.Context) {
 	}
 
 	// get UserId from uuid (token)
-	userId, err := context.GetUserIdFromUUID(ctx.Request.Context())
+	principal, err := context.GetPrincipal(ctx)
 	if err != nil {
 		response.ErrorResponse(ctx, response.ErrCodeTwoFactorAuthSetupFailed, "UserId is not valid")
 		return
 	}
-	log.Println("UserId:VerifyTwoFactorAuth:: ", userId)
-	params.UserId = uint32(userId)
+	params.UserId = uint32(principal.UserId)
+	params.ClientIp = ctx.ClientIP()
 
-	codeResult, err := service.UserLogin().VerifyTwoFactorAuth(ctx, &params)
+	codeResult, dataRs, err := service.UserLogin().VerifyTwoFactorAuth(ctx, &params)
 	if err != nil {
-		response.ErrorResponse(ctx, response.ErrCodeTwoFactorAuthSetupFailed, err.Error())
+		response.ErrorResponse(ctx, codeResult, err.Error())
 		return
 	}
-	response.SuccessResponse(ctx, codeResult, nil)
+	response.SuccessResponse(ctx, codeResult, dataRs)
+}
+
+// User Regenerate Two Factor Recovery Codes
+// @Summary      User Regenerate Two Factor Recovery Codes
+// @Description  Replace all recovery codes, requires an authenticator app code or an unused recovery code
+// @Tags         account 2fa
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        payload body model.RegenerateRecoveryCodesInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/two-factor/recovery-codes [post]
+func (c *sUser2FA) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var params model.RegenerateRecoveryCodesInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeTwoFactorRecoveryFailed, "Missing or invalid recovery codes parameter")
+		return
+	}
+
+	// get UserId from uuid (token)
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeTwoFactorRecoveryFailed, "UserId is not valid")
+		return
+	}
+	params.UserId = uint32(principal.UserId)
+	params.ClientIp = ctx.ClientIP()
+
+	codeResult, dataRs, err := service.UserLogin().RegenerateRecoveryCodes(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeResult, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeResult, dataRs)
 }
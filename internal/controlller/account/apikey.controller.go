@@ -0,0 +1,106 @@
+package account
+
+import (
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/context"
+	"go_ecommerce/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// management controller API keys of the shop
+
+var ApiKey = new(cShopApiKey)
+
+type cShopApiKey struct{}
+
+// Create API Key
+// @Summary      Create API Key
+// @Description  Issue a scoped key for a POS terminal or a partner, the key is returned only once
+// @Tags         shop api key
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        payload body model.CreateApiKeyInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/api-keys [post]
+func (c *cShopApiKey) CreateKey(ctx *gin.Context) {
+	var params model.CreateApiKeyInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeAuthFailed, "UserId is not valid")
+		return
+	}
+	params.ShopId = principal.UserId
+
+	codeRs, dataRs, err := service.ShopApiKey().CreateKey(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// List API Keys
+// @Summary      List API Keys
+// @Description  Keys of the shop with scopes and last use, secrets are never returned
+// @Tags         shop api key
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/api-keys [get]
+func (c *cShopApiKey) ListKeys(ctx *gin.Context) {
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeAuthFailed, "UserId is not valid")
+		return
+	}
+	codeRs, dataRs, err := service.ShopApiKey().ListKeys(ctx, principal.UserId)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Revoke API Key
+// @Summary      Revoke API Key
+// @Description  The key stops working immediately
+// @Tags         shop api key
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        id path int true "Key ID"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/api-keys/{id} [delete]
+func (c *cShopApiKey) RevokeKey(ctx *gin.Context) {
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeAuthFailed, "UserId is not valid")
+		return
+	}
+	keyId, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || keyId == 0 {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, "Key ID is not valid")
+		return
+	}
+	codeRs, err := service.ShopApiKey().RevokeKey(ctx, &model.ApiKeyInput{
+		ShopId: principal.UserId,
+		KeyId:  uint32(keyId),
+	})
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, nil)
+}
@@ -0,0 +1,82 @@
+package account
+
+import (
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/context"
+	"go_ecommerce/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// management controller email user
+
+var Email = new(cUserEmail)
+
+type cUserEmail struct{}
+
+// User Request Change Email
+// @Summary      User Request Change Email
+// @Description  Send an OTP to the new email, the account is unchanged until it is confirmed
+// @Tags         account email
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        payload body model.ChangeEmailInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/email/change [post]
+func (c *cUserEmail) RequestChangeEmail(ctx *gin.Context) {
+	var params model.ChangeEmailInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+	params.UserId = principal.UserId
+	params.UserAccount = principal.UserAccount
+
+	codeRs, err := service.UserLogin().RequestChangeEmail(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, nil)
+}
+
+// User Confirm Change Email
+// @Summary      User Confirm Change Email
+// @Description  Verify the OTP sent to the new email and switch the login email
+// @Tags         account email
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        payload body model.ConfirmChangeEmailInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/email/change/confirm [post]
+func (c *cUserEmail) ConfirmChangeEmail(ctx *gin.Context) {
+	var params model.ConfirmChangeEmailInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+	params.UserId = principal.UserId
+	params.ClientIp = ctx.ClientIP()
+
+	codeRs, dataRs, err := service.UserLogin().ConfirmChangeEmail(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
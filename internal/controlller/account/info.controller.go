@@ -0,0 +1,186 @@
+package account
+
+import (
+	"fmt"
+	"net/http"
+
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/context"
+	"go_ecommerce/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// management controller profile user
+
+var Info = new(cUserInfo)
+
+type cUserInfo struct{}
+
+// User Get Info
+// @Summary      User Get Info
+// @Description  Profile of the logged in user
+// @Tags         account info
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/me [get]
+func (c *cUserInfo) GetInfo(ctx *gin.Context) {
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+
+	codeRs, dataRs, err := service.UserInfo().GetInfoByUserId(ctx, principal.UserId)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// User Update Info
+// @Summary      User Update Info
+// @Description  Update nickname, avatar, gender, birthday or mobile, omitted fields are kept
+// @Tags         account info
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        payload body model.UpdateUserInfoInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/me [patch]
+func (c *cUserInfo) UpdateInfo(ctx *gin.Context) {
+	var params model.UpdateUserInfoInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+	params.UserId = principal.UserId
+
+	codeRs, dataRs, err := service.UserInfo().UpdateUserInfo(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// User Request Data Export
+// @Summary      User Request Data Export
+// @Description  Build a ZIP of the personal data in the background, an email is sent when it is ready
+// @Tags         account info
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/me/export [post]
+func (c *cUserInfo) RequestExport(ctx *gin.Context) {
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+
+	codeRs, dataRs, err := service.UserInfo().RequestExport(ctx, principal.UserId)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// User Get Data Export
+// @Summary      User Get Data Export
+// @Description  Status of the last data export
+// @Tags         account info
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/me/export [get]
+func (c *cUserInfo) GetExport(ctx *gin.Context) {
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+
+	codeRs, dataRs, err := service.UserInfo().GetExport(ctx, principal.UserId)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// User Download Data Export
+// @Summary      User Download Data Export
+// @Description  Download the ZIP of the last ready data export
+// @Tags         account info
+// @Produce      application/zip
+// @param Authorization header string true "Authorization token"
+// @Success      200  {file}    file
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/me/export/download [get]
+func (c *cUserInfo) DownloadExport(ctx *gin.Context) {
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+
+	codeRs, archive, err := service.UserInfo().DownloadExport(ctx, principal.UserId)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="gnfarm-data-%d.zip"`, principal.UserId))
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(http.StatusOK, "application/zip", archive)
+}
+
+// User Delete Account
+// @Summary      User Delete Account
+// @Description  Schedule the account deletion and sign out everywhere, signing in during the grace period cancels it
+// @Tags         account info
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        payload body model.DeleteAccountInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/me [delete]
+func (c *cUserInfo) DeleteAccount(ctx *gin.Context) {
+	var params model.DeleteAccountInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+	params.UserId = principal.UserId
+	params.UserAccount = principal.UserAccount
+	params.ClientIp = ctx.ClientIP()
+
+	codeRs, dataRs, err := service.UserInfo().DeleteAccount(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
@@ -0,0 +1,27 @@
+package account
+
+import (
+	"net/http"
+
+	"go_ecommerce/internal/utils/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// public keys used to verify our JWTs
+
+var JWKS = new(cJWKS)
+
+type cJWKS struct{}
+
+// JSON Web Key Set
+// @Summary      JSON Web Key Set
+// @Description  Public keys (RFC 7517) of the active and not yet expired retired signing keys, served without the response envelope
+// @Tags         account management
+// @Produce      json
+// @Success      200  {object}  auth.JWKSet
+// @Router       /.well-known/jwks.json [get]
+func (c *cJWKS) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, auth.JWKS())
+}
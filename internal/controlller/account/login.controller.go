@@ -5,8 +5,8 @@ import (
 	"go_ecommerce/internal/model"
 	"go_ecommerce/internal/service"
 	"go_ecommerce/internal/utils/auth"
+	"go_ecommerce/internal/utils/loginaudit"
 	"go_ecommerce/pkg/response"
-	"log"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -22,7 +22,6 @@ type cUserLogin struct {
 func (c *cUserLogin) RefreshToken(ctx *gin.Context) {
 	// Lấy Authorization header
 	refreshToken, _ := auth.ExtractBearerToken(ctx)
-	log.Println("refreshToken", refreshToken)
 
 	// Kiểm tra nếu token rỗng
 	if refreshToken == "" {
@@ -36,10 +35,8 @@ func (c *cUserLogin) RefreshToken(ctx *gin.Context) {
 
 	// Gọi service xử lý Refresh Token
 	codeRs, dataRs, err := service.UserLogin().RefreshToken(ctx, &params)
-	log.Println("errr 39 %v", err)
-	log.Println("dataRs %v", dataRs.AccessToken)
 	if err != nil {
-		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		response.ErrorResponse(ctx, codeRs, err.Error())
 		return
 	}
 
@@ -63,12 +60,39 @@ func (c *cUserLogin) Login(ctx *gin.Context) {
 		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
 		return
 	}
+	loginaudit.FillClient(ctx, &params.LoginClient)
 
 	codeRs, dataRs, err := service.UserLogin().Login(ctx, &params)
 	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// User Login Two Factor
+// @Summary      User Login Two Factor
+// @Description  Complete login with the challenge token returned by /user/login and the 2FA code
+// @Tags         account management
+// @Accept       json
+// @Produce      json
+// @Param        payload body model.LoginTwoFactorInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/login/two-factor [post]
+func (c *cUserLogin) LoginTwoFactor(ctx *gin.Context) {
+	var params model.LoginTwoFactorInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
 		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
 		return
 	}
+	loginaudit.FillClient(ctx, &params.LoginClient)
+
+	codeRs, dataRs, err := service.UserLogin().VerifyLoginTwoFactor(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
 	response.SuccessResponse(ctx, codeRs, dataRs)
 }
 
@@ -85,7 +109,6 @@ func (c *cUserLogin) Login(ctx *gin.Context) {
 
 func (c *cUserLogin) UpdatePasswordRegister(ctx *gin.Context) {
 	var params model.UpdatePasswordRegisterInput
-	log.Println("params  UpdatePasswordRegister", params.UserPassword)
 	if err := ctx.ShouldBindJSON(&params); err != nil {
 		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
 		return
@@ -116,9 +139,11 @@ func (c *cUserLogin) VerifyOTP(ctx *gin.Context) {
 		return
 	}
 
-	result, err := service.UserLogin().VerifyOTP(ctx, &params)
+	params.ClientIp = ctx.ClientIP()
+
+	codeRs, result, err := service.UserLogin().VerifyOTP(ctx, &params)
 	if err != nil {
-		response.ErrorResponse(ctx, response.ErrInvalidOtp, err.Error())
+		response.ErrorResponse(ctx, codeRs, err.Error())
 		return
 	}
 
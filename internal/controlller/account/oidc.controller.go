@@ -0,0 +1,63 @@
+package account
+
+import (
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/loginaudit"
+	"go_ecommerce/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// social login controller
+
+var OIDC = new(cOIDC)
+
+type cOIDC struct {
+}
+
+// OIDC Login Start
+// @Summary      Start social login
+// @Description  Returns the provider URL to redirect the user to and the state to send back with the callback
+// @Tags         account management
+// @Produce      json
+// @Param        provider path string true "provider name"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/oidc/{provider}/start [get]
+func (c *cOIDC) Start(ctx *gin.Context) {
+	codeRs, dataRs, err := service.UserLogin().OIDCLoginStart(ctx, ctx.Param("provider"))
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// OIDC Login Callback
+// @Summary      Complete social login
+// @Description  Exchange the code returned by the provider for our access/refresh tokens, or a 2FA challenge
+// @Tags         account management
+// @Accept       json
+// @Produce      json
+// @Param        provider path string true "provider name"
+// @Param        payload body model.OIDCCallbackInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/oidc/{provider}/callback [post]
+func (c *cOIDC) Callback(ctx *gin.Context) {
+	var params model.OIDCCallbackInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	params.Provider = ctx.Param("provider")
+	loginaudit.FillClient(ctx, &params.LoginClient)
+
+	codeRs, dataRs, err := service.UserLogin().OIDCLoginCallback(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
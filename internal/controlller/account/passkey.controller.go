@@ -0,0 +1,247 @@
+package account
+
+import (
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/context"
+	"go_ecommerce/internal/utils/loginaudit"
+	"go_ecommerce/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// management controller passkeys (WebAuthn)
+
+var Passkey = new(cUserPasskey)
+
+type cUserPasskey struct{}
+
+// Passkey Login Start
+// @Summary      Passkey Login Start
+// @Description  Options for navigator.credentials.get. Without payload the user picks a passkey, with challenge_token the passkey completes a password login
+// @Tags         account passkey
+// @Accept       json
+// @Produce      json
+// @Param        payload body model.PasskeyLoginStartInput false "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/login/passkey/start [post]
+func (c *cUserPasskey) LoginStart(ctx *gin.Context) {
+	var params model.PasskeyLoginStartInput
+	// the body is optional
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&params); err != nil {
+			response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+			return
+		}
+	}
+	codeRs, dataRs, err := service.UserLogin().BeginPasskeyLogin(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Passkey Login Finish
+// @Summary      Passkey Login Finish
+// @Description  Verify the credential returned by navigator.credentials.get and issue access/refresh tokens
+// @Tags         account passkey
+// @Accept       json
+// @Produce      json
+// @Param        payload body model.PasskeyLoginInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/login/passkey/finish [post]
+func (c *cUserPasskey) LoginFinish(ctx *gin.Context) {
+	var params model.PasskeyLoginInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	loginaudit.FillClient(ctx, &params.LoginClient)
+
+	codeRs, dataRs, err := service.UserLogin().FinishPasskeyLogin(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Passkey Register Start
+// @Summary      Passkey Register Start
+// @Description  Options for navigator.credentials.create. With 2FA enabled the session must have verified its second factor
+// @Tags         account passkey
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/passkeys/register/start [post]
+func (c *cUserPasskey) RegisterStart(ctx *gin.Context) {
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+	codeRs, dataRs, err := service.UserLogin().BeginPasskeyRegistration(ctx, &model.PasskeyRegisterInput{
+		UserId:      principal.UserId,
+		UserAccount: principal.UserAccount,
+		SubToken:    principal.SubToken,
+	})
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Passkey Register Finish
+// @Summary      Passkey Register Finish
+// @Description  Verify the credential returned by navigator.credentials.create and store it
+// @Tags         account passkey
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        payload body model.PasskeyRegisterInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/passkeys/register/finish [post]
+func (c *cUserPasskey) RegisterFinish(ctx *gin.Context) {
+	var params model.PasskeyRegisterInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+	params.UserId = principal.UserId
+	params.UserAccount = principal.UserAccount
+	params.SubToken = principal.SubToken
+	params.UserAgent = ctx.Request.UserAgent()
+
+	codeRs, dataRs, err := service.UserLogin().FinishPasskeyRegistration(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// List Passkeys
+// @Summary      List Passkeys
+// @Description  Registered passkeys of the user with their last use
+// @Tags         account passkey
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/passkeys [get]
+func (c *cUserPasskey) ListPasskeys(ctx *gin.Context) {
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+	codeRs, dataRs, err := service.UserLogin().ListPasskeys(ctx, principal.UserId)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Remove Passkey
+// @Summary      Remove Passkey
+// @Description  The passkey can no longer be used to log in, e.g. a lost phone
+// @Tags         account passkey
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        id path int true "Passkey ID"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/passkeys/{id} [delete]
+func (c *cUserPasskey) RemovePasskey(ctx *gin.Context) {
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+	passkeyId, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || passkeyId == 0 {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, "Passkey ID is not valid")
+		return
+	}
+	codeRs, err := service.UserLogin().RemovePasskey(ctx, &model.PasskeyInput{
+		UserId:    principal.UserId,
+		PasskeyId: uint32(passkeyId),
+	})
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, nil)
+}
+
+// Passkey Step-up Start
+// @Summary      Passkey Step-up Start
+// @Description  Options for navigator.credentials.get to verify the current session with a passkey
+// @Tags         account passkey
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/passkeys/verify/start [post]
+func (c *cUserPasskey) StepUpStart(ctx *gin.Context) {
+	params, ok := getSessionInput(ctx)
+	if !ok {
+		return
+	}
+	codeRs, dataRs, err := service.UserLogin().BeginPasskeyStepUp(ctx, params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Passkey Step-up Finish
+// @Summary      Passkey Step-up Finish
+// @Description  Verify the credential, the current session counts as second factor verified afterwards
+// @Tags         account passkey
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        payload body model.PasskeyStepUpInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/passkeys/verify/finish [post]
+func (c *cUserPasskey) StepUpFinish(ctx *gin.Context) {
+	var params model.PasskeyStepUpInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+	params.UserId = principal.UserId
+	params.SubToken = principal.SubToken
+
+	codeRs, err := service.UserLogin().FinishPasskeyStepUp(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, nil)
+}
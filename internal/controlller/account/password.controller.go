@@ -0,0 +1,100 @@
+package account
+
+import (
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/context"
+	"go_ecommerce/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// management controller password user
+
+var Password = new(cUserPassword)
+
+type cUserPassword struct{}
+
+// User Forgot Password
+// @Summary      User Forgot Password
+// @Description  Send a reset-password OTP to the registered email
+// @Tags         account password
+// @Accept       json
+// @Produce      json
+// @Param        payload body model.ForgotPasswordInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/password/forgot [post]
+func (c *cUserPassword) ForgotPassword(ctx *gin.Context) {
+	var params model.ForgotPasswordInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	codeRs, err := service.UserLogin().ForgotPassword(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, nil)
+}
+
+// User Reset Password
+// @Summary      User Reset Password
+// @Description  Verify the reset-password OTP, set the new password and log out every session
+// @Tags         account password
+// @Accept       json
+// @Produce      json
+// @Param        payload body model.ResetPasswordInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/password/reset [post]
+func (c *cUserPassword) ResetPassword(ctx *gin.Context) {
+	var params model.ResetPasswordInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	params.ClientIp = ctx.ClientIP()
+
+	codeRs, err := service.UserLogin().ResetPassword(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, nil)
+}
+
+// User Change Password
+// @Summary      User Change Password
+// @Description  Change the password of the logged-in user, other sessions are logged out
+// @Tags         account password
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        payload body model.ChangePasswordInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/password [put]
+func (c *cUserPassword) ChangePassword(ctx *gin.Context) {
+	var params model.ChangePasswordInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+	params.UserId = principal.UserId
+	params.UserAccount = principal.UserAccount
+	params.SubToken = principal.SubToken
+
+	codeRs, err := service.UserLogin().ChangePassword(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, nil)
+}
@@ -0,0 +1,159 @@
+package account
+
+import (
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/context"
+	"go_ecommerce/internal/utils/loginaudit"
+	"go_ecommerce/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// management controller session user
+
+var Session = new(cUserSession)
+
+type cUserSession struct{}
+
+// User Logout
+// @Summary      User Logout
+// @Description  Revoke the current session
+// @Tags         account session
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/logout [post]
+func (c *cUserSession) Logout(ctx *gin.Context) {
+	params, ok := getSessionInput(ctx)
+	if !ok {
+		return
+	}
+	codeRs, err := service.UserLogin().Logout(ctx, params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, nil)
+}
+
+// User Logout All Devices
+// @Summary      User Logout All Devices
+// @Description  Revoke every session of the user
+// @Tags         account session
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/logout-all [post]
+func (c *cUserSession) LogoutAll(ctx *gin.Context) {
+	params, ok := getSessionInput(ctx)
+	if !ok {
+		return
+	}
+	codeRs, err := service.UserLogin().LogoutAll(ctx, params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, nil)
+}
+
+// User Active Sessions
+// @Summary      User Active Sessions
+// @Description  List device, IP, user agent and last-seen time of active sessions
+// @Tags         account session
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/sessions [get]
+func (c *cUserSession) ListSessions(ctx *gin.Context) {
+	params, ok := getSessionInput(ctx)
+	if !ok {
+		return
+	}
+	codeRs, dataRs, err := service.UserLogin().ListSessions(ctx, params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// User Login History
+// @Summary      User Login History
+// @Description  Login attempts of the account (failed ones included), newest first
+// @Tags         account session
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        page query int false "page"
+// @Param        limit query int false "limit"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/login-history [get]
+func (c *cUserSession) LoginHistory(ctx *gin.Context) {
+	var params model.LoginHistoryInput
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return
+	}
+	params.UserId = principal.UserId
+	params.Fingerprint = loginaudit.Fingerprint(ctx.Request.UserAgent(), ctx.GetHeader("Accept-Language"), ctx.GetHeader("X-Device-Id"))
+
+	codeRs, dataRs, err := service.UserLogin().LoginHistory(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// User Revoke Session
+// @Summary      User Revoke Session
+// @Description  Revoke one session by id, e.g. a shared tablet
+// @Tags         account session
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        id path string true "Session ID"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /user/sessions/{id} [delete]
+func (c *cUserSession) RevokeSession(ctx *gin.Context) {
+	params, ok := getSessionInput(ctx)
+	if !ok {
+		return
+	}
+	params.SessionId = ctx.Param("id")
+	codeRs, err := service.UserLogin().RevokeSession(ctx, params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, nil)
+}
+
+// getSessionInput reads the current session from the request principal
+func getSessionInput(ctx *gin.Context) (*model.SessionInput, bool) {
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "UserId is not valid")
+		return nil, false
+	}
+	return &model.SessionInput{
+		UserId:      principal.UserId,
+		UserAccount: principal.UserAccount,
+		SubToken:    principal.SubToken,
+	}, true
+}
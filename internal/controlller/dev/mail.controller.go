@@ -0,0 +1,50 @@
+package dev
+
+import (
+	"go_ecommerce/internal/utils/sendto"
+	"go_ecommerce/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// development only: inspect mails captured by the outbox sender
+
+var Mail = new(cDevMail)
+
+type cDevMail struct{}
+
+// List Captured Mails
+// @Summary      List Captured Mails
+// @Description  Mails captured by the outbox email provider, newest first (dev mode only)
+// @Tags         dev
+// @Produce      json
+// @Param        to   query     string  false  "filter by recipient"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /dev/mails [get]
+func (c *cDevMail) ListMails(ctx *gin.Context) {
+	outbox := sendto.Outbox()
+	if outbox == nil {
+		response.ErrorResponse(ctx, response.CodeFail, "email provider is not the outbox")
+		return
+	}
+	response.SuccessResponse(ctx, response.CodeSuccess, outbox.List(ctx.Query("to")))
+}
+
+// Clear Captured Mails
+// @Summary      Clear Captured Mails
+// @Description  Drop the mails kept in memory by the outbox email provider (dev mode only)
+// @Tags         dev
+// @Produce      json
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /dev/mails [delete]
+func (c *cDevMail) ClearMails(ctx *gin.Context) {
+	outbox := sendto.Outbox()
+	if outbox == nil {
+		response.ErrorResponse(ctx, response.CodeFail, "email provider is not the outbox")
+		return
+	}
+	outbox.Clear()
+	response.SuccessResponse(ctx, response.CodeSuccess, nil)
+}
@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/loginaudit"
+	"go_ecommerce/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// management controller admin login
+
+var Admin = new(cAdminLogin)
+
+type cAdminLogin struct{}
+
+// Admin Login
+// @Summary      Admin Login
+// @Description  Admin login, always answered with a two-factor challenge
+// @Tags         admin management
+// @Accept       json
+// @Produce      json
+// @Param        payload body model.LoginInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/login [post]
+func (c *cAdminLogin) Login(ctx *gin.Context) {
+	var params model.LoginInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	loginaudit.FillClient(ctx, &params.LoginClient)
+
+	codeRs, dataRs, err := service.UserAdmin().Login(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Admin Login Two Factor
+// @Summary      Admin Login Two Factor
+// @Description  Complete the admin login with the challenge token returned by /admin/login and the 2FA code
+// @Tags         admin management
+// @Accept       json
+// @Produce      json
+// @Param        payload body model.LoginTwoFactorInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/login/two-factor [post]
+func (c *cAdminLogin) LoginTwoFactor(ctx *gin.Context) {
+	var params model.LoginTwoFactorInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	loginaudit.FillClient(ctx, &params.LoginClient)
+
+	codeRs, dataRs, err := service.UserLogin().VerifyLoginTwoFactor(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
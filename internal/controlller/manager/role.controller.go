@@ -0,0 +1,107 @@
+package manager
+
+import (
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// management controller roles of a user
+
+var Role = new(cAdminRole)
+
+type cAdminRole struct{}
+
+// List User Roles
+// @Summary      List User Roles
+// @Description  Roles and permissions of a user
+// @Tags         admin role
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        id path int true "User ID"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/user/{id}/roles [get]
+func (c *cAdminRole) ListUserRoles(ctx *gin.Context) {
+	userId, ok := getUserIdParam(ctx)
+	if !ok {
+		return
+	}
+	codeRs, dataRs, err := service.UserRole().ListUserRoles(ctx, userId)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Assign Role
+// @Summary      Assign Role
+// @Description  Give a role to a user: shop_owner, shop_staff, moderator, admin
+// @Tags         admin role
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        id path int true "User ID"
+// @Param        payload body model.UserRoleInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/user/{id}/roles [post]
+func (c *cAdminRole) AssignRole(ctx *gin.Context) {
+	var params model.UserRoleInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	userId, ok := getUserIdParam(ctx)
+	if !ok {
+		return
+	}
+	params.UserId = userId
+	codeRs, dataRs, err := service.UserRole().AssignRole(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Remove Role
+// @Summary      Remove Role
+// @Description  Take a role away from a user
+// @Tags         admin role
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        id path int true "User ID"
+// @Param        role path string true "Role"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/user/{id}/roles/{role} [delete]
+func (c *cAdminRole) RemoveRole(ctx *gin.Context) {
+	userId, ok := getUserIdParam(ctx)
+	if !ok {
+		return
+	}
+	params := model.UserRoleInput{UserId: userId, Role: ctx.Param("role")}
+	codeRs, dataRs, err := service.UserRole().RemoveRole(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// getUserIdParam reads the :id path parameter
+func getUserIdParam(ctx *gin.Context) (uint64, bool) {
+	userId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || userId == 0 {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, "User ID is not valid")
+		return 0, false
+	}
+	return userId, true
+}
@@ -0,0 +1,202 @@
+package manager
+
+import (
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/context"
+	"go_ecommerce/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// management controller users
+
+var User = new(cAdminUser)
+
+type cAdminUser struct{}
+
+// Register User
+// @Summary      Register User
+// @Description  Create an account on behalf of a user, it stays Not Activated until activated
+// @Tags         admin user
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        payload body model.AdminRegisterUserInput true "payload"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/user/register [post]
+func (c *cAdminUser) RegisterUser(ctx *gin.Context) {
+	var params model.AdminRegisterUserInput
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	codeRs, dataRs, err := service.UserAdmin().RegisterUser(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Find Users
+// @Summary      Find Users
+// @Description  Search users by account or nickname
+// @Tags         admin user
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        q query string false "Account or nickname"
+// @Param        page query int false "Page number"
+// @Param        limit query int false "Number of items per page"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/user [get]
+func (c *cAdminUser) FindUsers(ctx *gin.Context) {
+	var params model.FindUsersInput
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+	codeRs, dataRs, err := service.UserAdmin().FindUsers(ctx, &params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Find One User
+// @Summary      Find One User
+// @Description  User info by id
+// @Tags         admin user
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        id path int true "User ID"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/user/{id} [get]
+func (c *cAdminUser) FindOneUser(ctx *gin.Context) {
+	userId, ok := getUserIdParam(ctx)
+	if !ok {
+		return
+	}
+	codeRs, dataRs, err := service.UserAdmin().FindOneUser(ctx, userId)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Activate User
+// @Summary      Activate User
+// @Description  Not Activated -> Activated
+// @Tags         admin user
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        id path int true "User ID"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/user/{id}/activate [post]
+func (c *cAdminUser) ActivateUser(ctx *gin.Context) {
+	params, ok := getAdminUserInput(ctx)
+	if !ok {
+		return
+	}
+	codeRs, dataRs, err := service.UserAdmin().ActivateUser(ctx, params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Lock User
+// @Summary      Lock User
+// @Description  Lock the account and revoke every session of the user
+// @Tags         admin user
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        id path int true "User ID"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/user/{id}/lock [post]
+func (c *cAdminUser) LockUser(ctx *gin.Context) {
+	params, ok := getAdminUserInput(ctx)
+	if !ok {
+		return
+	}
+	codeRs, dataRs, err := service.UserAdmin().LockUser(ctx, params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Unlock User
+// @Summary      Unlock User
+// @Description  Locked -> Activated
+// @Tags         admin user
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        id path int true "User ID"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/user/{id}/unlock [post]
+func (c *cAdminUser) UnlockUser(ctx *gin.Context) {
+	params, ok := getAdminUserInput(ctx)
+	if !ok {
+		return
+	}
+	codeRs, dataRs, err := service.UserAdmin().UnlockUser(ctx, params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, dataRs)
+}
+
+// Remove User
+// @Summary      Remove User
+// @Description  Delete the account and revoke every session of the user
+// @Tags         admin user
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization token"
+// @Param        id path int true "User ID"
+// @Success      200  {object}  response.ResponseData
+// @Failure      500  {object}  response.ErrorResponseData
+// @Router       /admin/user/{id} [delete]
+func (c *cAdminUser) RemoveUser(ctx *gin.Context) {
+	params, ok := getAdminUserInput(ctx)
+	if !ok {
+		return
+	}
+	codeRs, err := service.UserAdmin().RemoveUser(ctx, params)
+	if err != nil {
+		response.ErrorResponse(ctx, codeRs, err.Error())
+		return
+	}
+	response.SuccessResponse(ctx, codeRs, nil)
+}
+
+// getAdminUserInput reads the target user from the path and the admin from the principal
+func getAdminUserInput(ctx *gin.Context) (*model.AdminUserInput, bool) {
+	userId, ok := getUserIdParam(ctx)
+	if !ok {
+		return nil, false
+	}
+	principal, err := context.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, "Session is not valid")
+		return nil, false
+	}
+	return &model.AdminUserInput{UserId: userId, AdminId: principal.UserId}, true
+}
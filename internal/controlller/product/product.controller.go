@@ -1,10 +1,15 @@
 package product
 
 import (
+	"errors"
 	"go_ecommerce/internal/model"
 	"go_ecommerce/internal/service"
+	usercontext "go_ecommerce/internal/utils/context"
+	"go_ecommerce/internal/utils/producttype"
+	"go_ecommerce/internal/utils/variant"
 	"go_ecommerce/pkg/response"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,7 +21,7 @@ type cProduct struct{}
 
 // CreateProduct creates a new product
 // @Summary Create a new product
-// @Description Create a new product with the provided details
+// @Description Create a new product with the provided details. options and skus describe the variants, every combination of option values becomes a SKU when skus is left out
 // @Tags product management
 // @Accept json
 // @Produce json
@@ -34,16 +39,28 @@ func (c *cProduct) CreateProduct(ctx *gin.Context) {
 
 	product, err := service.ProductManagement().CreateProduct(ctx, &input)
 	if err != nil {
-		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		productError(ctx, err)
 		return
 	}
 
 	response.SuccessResponse(ctx, response.CodeSuccess, product)
 }
 
+// ProductTypes lists the product types
+// @Summary List product types
+// @Description Product types with their attributes: kind, required, allowed values and unit
+// @Tags product
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.ResponseData
+// @Router /product/types [get]
+func (c *cProduct) ProductTypes(ctx *gin.Context) {
+	response.SuccessResponse(ctx, response.CodeSuccess, producttype.List())
+}
+
 // UpdateProduct updates an existing product
 // @Summary Update an existing product
-// @Description Update an existing product with the provided details
+// @Description Update an existing product with the provided details. skus replaces the variant matrix: SKUs are matched by sku_code, SKUs left out are deleted
 // @Tags product management
 // @Accept json
 // @Produce json
@@ -67,6 +84,33 @@ func (c *cProduct) UpdateProduct(ctx *gin.Context) {
 	}
 
 	err := service.ProductManagement().UpdateProduct(ctx, productID, &input)
+	if err != nil {
+		productError(ctx, err)
+		return
+	}
+
+	response.SuccessResponse(ctx, response.CodeSuccess, nil)
+}
+
+// DeleteProduct deletes a product
+// @Summary Delete a product
+// @Description Delete a product of the shop together with its type attributes and inventory
+// @Tags product management
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} response.ResponseData
+// @Failure 400 {object} response.ErrorResponseData
+// @Failure 500 {object} response.ErrorResponseData
+// @Router /product/delete/{id} [delete]
+func (c *cProduct) DeleteProduct(ctx *gin.Context) {
+	productID := ctx.Param("id")
+	if productID == "" {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, "Product ID is required")
+		return
+	}
+
+	err := service.ProductManagement().DeleteProduct(ctx, productID)
 	if err != nil {
 		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
 		return
@@ -93,10 +137,53 @@ func (c *cProduct) PublishProduct(ctx *gin.Context) {
 		return
 	}
 
-	userID, _ := ctx.Get("user_id")
-	shopID := userID.(string)
+	principal, err := usercontext.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, err.Error())
+		return
+	}
 
-	err := service.ProductManagement().PublishProduct(ctx, productID, shopID)
+	err = service.ProductManagement().PublishProduct(ctx, productID, principal.ShopId)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+		return
+	}
+
+	response.SuccessResponse(ctx, response.CodeSuccess, nil)
+}
+
+// UpdateStock sets the stock of a product
+// @Summary Update product stock
+// @Description Set the stock of a SKU of the shop, used by POS terminals and partner stock sync (scope inventory:write). sku_code may be left out when the product has a single SKU
+// @Tags product management
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param payload body model.StockInput true "Stock"
+// @Success 200 {object} response.ResponseData
+// @Failure 400 {object} response.ErrorResponseData
+// @Failure 500 {object} response.ErrorResponseData
+// @Router /product/stock/{id} [put]
+func (c *cProduct) UpdateStock(ctx *gin.Context) {
+	productID := ctx.Param("id")
+	if productID == "" {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, "Product ID is required")
+		return
+	}
+
+	var input model.StockInput
+	if err := ctx.ShouldBindJSON(&input); err != nil || input.Stock == nil {
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, "stock is required")
+		return
+	}
+
+	principal, err := usercontext.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, err.Error())
+		return
+	}
+
+	err = service.ProductManagement().UpdateStock(ctx, productID, principal.ShopId, input.SkuCode, *input.Stock)
 	if err != nil {
 		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
 		return
@@ -123,10 +210,13 @@ func (c *cProduct) UnPublishProduct(ctx *gin.Context) {
 		return
 	}
 
-	userID, _ := ctx.Get("user_id")
-	shopID := userID.(string)
+	principal, err := usercontext.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, err.Error())
+		return
+	}
 
-	err := service.ProductManagement().UnPublishProduct(ctx, productID, shopID)
+	err = service.ProductManagement().UnPublishProduct(ctx, productID, principal.ShopId)
 	if err != nil {
 		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
 		return
@@ -171,7 +261,7 @@ func (c *cProduct) GetProductByID(ctx *gin.Context) {
 // @Param page query int false "Page number"
 // @Param limit query int false "Number of items per page"
 // @Param product_type query string false "Product type filter"
-// @Param keyword query string false "Search keyword"
+// @Param keyword query string false "Search keyword, the results are ordered by relevance as in /product/search"
 // @Success 200 {object} response.ResponseData
 // @Failure 500 {object} response.ErrorResponseData
 // @Router /product [get]
@@ -220,9 +310,12 @@ func (c *cProduct) GetAllProducts(ctx *gin.Context) {
 // @Failure 500 {object} response.ErrorResponseData
 // @Router /product/drafts [get]
 func (c *cProduct) GetAllDraftsForShop(ctx *gin.Context) {
-	userID, _ := ctx.Get("user_id")
-	shopID := userID.(string)
-	
+	principal, err := usercontext.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, err.Error())
+		return
+	}
+
 	// Parse page parameter
 	page := ctx.DefaultQuery("page", "1")
 	pageNum, err := strconv.Atoi(page)
@@ -237,7 +330,7 @@ func (c *cProduct) GetAllDraftsForShop(ctx *gin.Context) {
 		limitNum = 10
 	}
 
-	products, err := service.ProductManagement().FindAllDraftsForShop(ctx, shopID, pageNum, limitNum)
+	products, err := service.ProductManagement().FindAllDraftsForShop(ctx, principal.ShopId, pageNum, limitNum)
 	if err != nil {
 		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
 		return
@@ -259,9 +352,12 @@ func (c *cProduct) GetAllDraftsForShop(ctx *gin.Context) {
 // @Failure 500 {object} response.ErrorResponseData
 // @Router /product/published [get]
 func (c *cProduct) GetAllPublishForShop(ctx *gin.Context) {
-	userID, _ := ctx.Get("user_id")
-	shopID := userID.(string)
-	
+	principal, err := usercontext.GetPrincipal(ctx)
+	if err != nil {
+		response.ErrorResponse(ctx, response.ErrCodeSessionFailed, err.Error())
+		return
+	}
+
 	// Parse page parameter
 	page := ctx.DefaultQuery("page", "1")
 	pageNum, err := strconv.Atoi(page)
@@ -276,7 +372,7 @@ func (c *cProduct) GetAllPublishForShop(ctx *gin.Context) {
 		limitNum = 10
 	}
 
-	products, err := service.ProductManagement().FindAllPublishForShop(ctx, shopID, pageNum, limitNum)
+	products, err := service.ProductManagement().FindAllPublishForShop(ctx, principal.ShopId, pageNum, limitNum)
 	if err != nil {
 		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
 		return
@@ -377,26 +473,49 @@ func (c *cProduct) GetProductsBySelled(ctx *gin.Context) {
 
 // SearchProducts searches products by keyword
 // @Summary Search products by keyword
-// @Description Search for products using a keyword
+// @Description Search published products by name, type, origin and description, the best match first. Accents are optional and a word may have a typo
 // @Tags product
 // @Accept json
 // @Produce json
 // @Param keyword query string true "Search keyword"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param product_type query string false "Product type filter"
 // @Success 200 {object} response.ResponseData
 // @Failure 500 {object} response.ErrorResponseData
 // @Router /product/search [get]
 func (c *cProduct) SearchProducts(ctx *gin.Context) {
 	keyword := ctx.Query("keyword")
-	if keyword == "" {
+	if strings.TrimSpace(keyword) == "" {
 		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, "Keyword is required")
 		return
 	}
 
-	products, err := service.ProductManagement().SearchProducts(ctx, keyword)
+	params := model.ProductQueryParams{Keyword: keyword, ProductType: ctx.Query("product_type")}
+	params.Page, _ = strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	params.Limit, _ = strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+	products, err := service.ProductManagement().SearchProducts(ctx, &params)
 	if err != nil {
 		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
 		return
 	}
 
 	response.SuccessResponse(ctx, response.CodeSuccess, products)
-} 
\ No newline at end of file
+} 
+
+// productError writes the error of a product write, invalid attributes and variants are listed field by field
+func productError(ctx *gin.Context, err error) {
+	var invalid *producttype.ValidationError
+	var invalidVariants *variant.InvalidError
+	switch {
+	case errors.As(err, &invalid):
+		response.ErrorDetailResponse(ctx, response.ErrCodeProductAttributesInvalid, err.Error(), invalid.Fields)
+	case errors.As(err, &invalidVariants):
+		response.ErrorDetailResponse(ctx, response.ErrCodeProductVariantsInvalid, err.Error(), invalidVariants.Fields)
+	case errors.Is(err, producttype.ErrUnknownType):
+		response.ErrorResponse(ctx, response.ErrCodeProductTypeInvalid, "")
+	default:
+		response.ErrorResponse(ctx, response.ErrCodeParamInvalid, err.Error())
+	}
+}
@@ -10,14 +10,55 @@ import (
 	"database/sql"
 )
 
+const consumeUserVerification = `-- name: ConsumeUserVerification :execrows
+UPDATE ` + "`" + `pre_go_acc_user_verify_9999` + "`" + `
+SET is_verified = 2,
+    verify_updated_at = now()
+WHERE verify_key_hash = ? AND verify_purpose = ? AND is_verified = 1
+`
+
+type ConsumeUserVerificationParams struct {
+	VerifyKeyHash string
+	VerifyPurpose string
+}
+
+// a verified REGISTER record creates exactly one account: is_verified 1 -> 2
+func (q *Queries) ConsumeUserVerification(ctx context.Context, arg ConsumeUserVerificationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, consumeUserVerification, arg.VerifyKeyHash, arg.VerifyPurpose)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserVerification = `-- name: DeleteUserVerification :exec
+DELETE FROM ` + "`" + `pre_go_acc_user_verify_9999` + "`" + `
+WHERE verify_key_hash = ? AND verify_purpose = ?
+`
+
+type DeleteUserVerificationParams struct {
+	VerifyKeyHash string
+	VerifyPurpose string
+}
+
+func (q *Queries) DeleteUserVerification(ctx context.Context, arg DeleteUserVerificationParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUserVerification, arg.VerifyKeyHash, arg.VerifyPurpose)
+	return err
+}
+
 const getInfoOTP = `-- name: GetInfoOTP :one
-SELECT verify_id, verify_otp, verify_key, verify_key_hash, verify_type, is_verified, is_deleted, verify_created_at, verify_updated_at
+SELECT verify_id, verify_otp, verify_key, verify_key_hash, verify_type, is_verified, is_deleted, verify_created_at, verify_updated_at, verify_purpose
 FROM ` + "`" + `pre_go_acc_user_verify_9999` + "`" + `
-WHERE verify_key_hash = ?
+WHERE verify_key_hash = ? AND verify_purpose = ?
 `
 
-func (q *Queries) GetInfoOTP(ctx context.Context, verifyKeyHash string) (PreGoAccUserVerify9999, error) {
-	row := q.db.QueryRowContext(ctx, getInfoOTP, verifyKeyHash)
+type GetInfoOTPParams struct {
+	VerifyKeyHash string
+	VerifyPurpose string
+}
+
+func (q *Queries) GetInfoOTP(ctx context.Context, arg GetInfoOTPParams) (PreGoAccUserVerify9999, error) {
+	row := q.db.QueryRowContext(ctx, getInfoOTP, arg.VerifyKeyHash, arg.VerifyPurpose)
 	var i PreGoAccUserVerify9999
 	err := row.Scan(
 		&i.VerifyID,
@@ -29,6 +70,7 @@ func (q *Queries) GetInfoOTP(ctx context.Context, verifyKeyHash string) (PreGoAc
 		&i.IsDeleted,
 		&i.VerifyCreatedAt,
 		&i.VerifyUpdatedAt,
+		&i.VerifyPurpose,
 	)
 	return i, err
 }
@@ -36,9 +78,14 @@ func (q *Queries) GetInfoOTP(ctx context.Context, verifyKeyHash string) (PreGoAc
 const getValidOTP = `-- name: GetValidOTP :one
 SELECT verify_otp, verify_key_hash, verify_key, verify_id
 FROM ` + "`" + `pre_go_acc_user_verify_9999` + "`" + `
-WHERE verify_key_hash = ? AND is_verified = 0
+WHERE verify_key_hash = ? AND verify_purpose = ? AND is_verified = 0
 `
 
+type GetValidOTPParams struct {
+	VerifyKeyHash string
+	VerifyPurpose string
+}
+
 type GetValidOTPRow struct {
 	VerifyOtp     string
 	VerifyKeyHash string
@@ -46,8 +93,8 @@ type GetValidOTPRow struct {
 	VerifyID      int32
 }
 
-func (q *Queries) GetValidOTP(ctx context.Context, verifyKeyHash string) (GetValidOTPRow, error) {
-	row := q.db.QueryRowContext(ctx, getValidOTP, verifyKeyHash)
+func (q *Queries) GetValidOTP(ctx context.Context, arg GetValidOTPParams) (GetValidOTPRow, error) {
+	row := q.db.QueryRowContext(ctx, getValidOTP, arg.VerifyKeyHash, arg.VerifyPurpose)
 	var i GetValidOTPRow
 	err := row.Scan(
 		&i.VerifyOtp,
@@ -64,12 +111,17 @@ INSERT INTO ` + "`" + `pre_go_acc_user_verify_9999` + "`" + ` (
     verify_key,
     verify_key_hash, 
     verify_type, 
+    verify_purpose,
     is_verified, 
     is_deleted, 
     verify_created_at, 
     verify_updated_at
 )
-VALUES (?, ?, ?, ?, 0, 0, NOW(), NOW())
+VALUES (?, ?, ?, ?, ?, 0, 0, NOW(), NOW())
+ON DUPLICATE KEY UPDATE
+    verify_otp = VALUES(verify_otp),
+    is_verified = 0,
+    verify_updated_at = NOW()
 `
 
 type InsertOTPVerifyParams struct {
@@ -77,14 +129,17 @@ type InsertOTPVerifyParams struct {
 	VerifyKey     string
 	VerifyKeyHash string
 	VerifyType    sql.NullInt32
+	VerifyPurpose string
 }
 
+// a new OTP for the same key and purpose replaces the previous one
 func (q *Queries) InsertOTPVerify(ctx context.Context, arg InsertOTPVerifyParams) (sql.Result, error) {
 	return q.db.ExecContext(ctx, insertOTPVerify,
 		arg.VerifyOtp,
 		arg.VerifyKey,
 		arg.VerifyKeyHash,
 		arg.VerifyType,
+		arg.VerifyPurpose,
 	)
 }
 
@@ -92,11 +147,37 @@ const updateUserVerificationStatus = `-- name: UpdateUserVerificationStatus :exe
 UPDATE ` + "`" + `pre_go_acc_user_verify_9999` + "`" + `
 SET is_verified = 1,
     verify_updated_at = now()
-WHERE verify_key_hash = ?
+WHERE verify_key_hash = ? AND verify_purpose = ?
 `
 
+type UpdateUserVerificationStatusParams struct {
+	VerifyKeyHash string
+	VerifyPurpose string
+}
+
 // update lai
-func (q *Queries) UpdateUserVerificationStatus(ctx context.Context, verifyKeyHash string) error {
-	_, err := q.db.ExecContext(ctx, updateUserVerificationStatus, verifyKeyHash)
+func (q *Queries) UpdateUserVerificationStatus(ctx context.Context, arg UpdateUserVerificationStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserVerificationStatus, arg.VerifyKeyHash, arg.VerifyPurpose)
 	return err
 }
+
+const usePendingVerification = `-- name: UsePendingVerification :execrows
+UPDATE ` + "`" + `pre_go_acc_user_verify_9999` + "`" + `
+SET is_verified = 1,
+    verify_updated_at = now()
+WHERE verify_key_hash = ? AND verify_purpose = ? AND is_verified = 0
+`
+
+type UsePendingVerificationParams struct {
+	VerifyKeyHash string
+	VerifyPurpose string
+}
+
+// a pending OTP is used once: is_verified 0 -> 1, no row when it was used already
+func (q *Queries) UsePendingVerification(ctx context.Context, arg UsePendingVerificationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, usePendingVerification, arg.VerifyKeyHash, arg.VerifyPurpose)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -129,3 +129,45 @@ func (q *Queries) LogoutUserBase(ctx context.Context, userAccount string) error
 	_, err := q.db.ExecContext(ctx, logoutUserBase, userAccount)
 	return err
 }
+
+const removeUserBase = `-- name: RemoveUserBase :exec
+DELETE FROM pre_go_acc_user_base_9999 WHERE user_id = ?
+`
+
+func (q *Queries) RemoveUserBase(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, removeUserBase, userID)
+	return err
+}
+
+const updateUserAccount = `-- name: UpdateUserAccount :exec
+UPDATE pre_go_acc_user_base_9999
+SET user_account = ?, user_updated_at = NOW()
+WHERE user_id = ?
+`
+
+type UpdateUserAccountParams struct {
+	UserAccount string
+	UserID      int32
+}
+
+func (q *Queries) UpdateUserAccount(ctx context.Context, arg UpdateUserAccountParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserAccount, arg.UserAccount, arg.UserID)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE pre_go_acc_user_base_9999
+SET user_password = ?, user_salt = ?, user_updated_at = NOW()
+WHERE user_id = ?
+`
+
+type UpdateUserPasswordParams struct {
+	UserPassword string
+	UserSalt     string
+	UserID       int32
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.UserPassword, arg.UserSalt, arg.UserID)
+	return err
+}
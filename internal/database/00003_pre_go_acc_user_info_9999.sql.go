@@ -85,10 +85,31 @@ func (q *Queries) AddUserHaveUserId(ctx context.Context, arg AddUserHaveUserIdPa
 	)
 }
 
+const anonymizeUser = `-- name: AnonymizeUser :execrows
+UPDATE ` + "`" + `pre_go_acc_user_info_9999` + "`" + `
+SET user_account = ?, user_nickname = NULL, user_avatar = NULL, user_mobile = NULL,
+user_gender = NULL, user_birthday = NULL, user_email = NULL, user_state = 0, updated_at = NOW()
+WHERE user_id = ?
+`
+
+type AnonymizeUserParams struct {
+	UserAccount string
+	UserID      uint64
+}
+
+// personal data is cleared, the row stays so order records keep their user_id
+func (q *Queries) AnonymizeUser(ctx context.Context, arg AnonymizeUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, anonymizeUser, arg.UserAccount, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const editUserByUserId = `-- name: EditUserByUserId :execresult
 UPDATE ` + "`" + `pre_go_acc_user_info_9999` + "`" + `
 SET user_nickname = ?, user_avatar = ?, user_mobile = ?, 
-user_gender = ?, user_birthday = ?, user_email = ?, updated_at = NOW()
+user_gender = ?, user_birthday = ?, updated_at = NOW()
 WHERE user_id = ? AND user_is_authentication = 1
 `
 
@@ -98,7 +119,6 @@ type EditUserByUserIdParams struct {
 	UserMobile   sql.NullString
 	UserGender   sql.NullInt16
 	UserBirthday sql.NullTime
-	UserEmail    sql.NullString
 	UserID       uint64
 }
 
@@ -109,22 +129,29 @@ func (q *Queries) EditUserByUserId(ctx context.Context, arg EditUserByUserIdPara
 		arg.UserMobile,
 		arg.UserGender,
 		arg.UserBirthday,
-		arg.UserEmail,
 		arg.UserID,
 	)
 }
 
 const findUsers = `-- name: FindUsers :many
 SELECT user_id, user_account, user_nickname, user_avatar, user_state, user_mobile, user_gender, user_birthday, user_email, user_is_authentication, created_at, updated_at FROM pre_go_acc_user_info_9999 WHERE user_account LIKE ? OR user_nickname LIKE ?
+ORDER BY user_id LIMIT ? OFFSET ?
 `
 
 type FindUsersParams struct {
 	UserAccount  string
 	UserNickname sql.NullString
+	Limit        int32
+	Offset       int32
 }
 
 func (q *Queries) FindUsers(ctx context.Context, arg FindUsersParams) ([]PreGoAccUserInfo9999, error) {
-	rows, err := q.db.QueryContext(ctx, findUsers, arg.UserAccount, arg.UserNickname)
+	rows, err := q.db.QueryContext(ctx, findUsers,
+		arg.UserAccount,
+		arg.UserNickname,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -251,7 +278,7 @@ func (q *Queries) GetUsers(ctx context.Context, userID uint64) ([]PreGoAccUserIn
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT user_id, user_account, user_nickname, user_avatar, user_state, user_mobile, user_gender, user_birthday, user_email, user_is_authentication, created_at, updated_at FROM pre_go_acc_user_info_9999 LIMIT ? OFFSET ?
+SELECT user_id, user_account, user_nickname, user_avatar, user_state, user_mobile, user_gender, user_birthday, user_email, user_is_authentication, created_at, updated_at FROM pre_go_acc_user_info_9999 ORDER BY user_id LIMIT ? OFFSET ?
 `
 
 type ListUsersParams struct {
@@ -303,3 +330,40 @@ func (q *Queries) RemoveUser(ctx context.Context, userID uint64) error {
 	_, err := q.db.ExecContext(ctx, removeUser, userID)
 	return err
 }
+
+const updateUserInfoEmail = `-- name: UpdateUserInfoEmail :exec
+UPDATE ` + "`" + `pre_go_acc_user_info_9999` + "`" + `
+SET user_account = ?, user_email = ?, updated_at = NOW()
+WHERE user_id = ?
+`
+
+type UpdateUserInfoEmailParams struct {
+	UserAccount string
+	UserEmail   sql.NullString
+	UserID      uint64
+}
+
+func (q *Queries) UpdateUserInfoEmail(ctx context.Context, arg UpdateUserInfoEmailParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserInfoEmail, arg.UserAccount, arg.UserEmail, arg.UserID)
+	return err
+}
+
+const updateUserState = `-- name: UpdateUserState :execrows
+UPDATE ` + "`" + `pre_go_acc_user_info_9999` + "`" + `
+SET user_state = ?, updated_at = NOW()
+WHERE user_id = ? AND user_state = ?
+`
+
+type UpdateUserStateParams struct {
+	UserState   uint8
+	UserID      uint64
+	UserState_2 uint8
+}
+
+func (q *Queries) UpdateUserState(ctx context.Context, arg UpdateUserStateParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUserState, arg.UserState, arg.UserID, arg.UserState_2)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
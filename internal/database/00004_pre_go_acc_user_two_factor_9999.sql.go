@@ -82,6 +82,23 @@ func (q *Queries) DisableTwoFactor(ctx context.Context, arg DisableTwoFactorPara
 	return err
 }
 
+const enableTwoFactorTypeApp = `-- name: EnableTwoFactorTypeApp :exec
+INSERT INTO pre_go_acc_user_two_factor_9999 (user_id, two_factor_auth_type, two_factor_auth_secret, two_factor_is_active, two_factor_created_at, two_factor_updated_at)
+VALUES (?, ?, ?, FALSE, NOW(), NOW())
+`
+
+type EnableTwoFactorTypeAppParams struct {
+	UserID              uint32
+	TwoFactorAuthType   PreGoAccUserTwoFactor9999TwoFactorAuthType
+	TwoFactorAuthSecret string
+}
+
+// EnableTwoFactorTypeApp
+func (q *Queries) EnableTwoFactorTypeApp(ctx context.Context, arg EnableTwoFactorTypeAppParams) error {
+	_, err := q.db.ExecContext(ctx, enableTwoFactorTypeApp, arg.UserID, arg.TwoFactorAuthType, arg.TwoFactorAuthSecret)
+	return err
+}
+
 const enableTwoFactorTypeEmail = `-- name: EnableTwoFactorTypeEmail :exec
 
 INSERT INTO pre_go_acc_user_two_factor_9999 (user_id, two_factor_auth_type, two_factor_email, two_factor_auth_secret, two_factor_is_active, two_factor_created_at, two_factor_updated_at)
@@ -101,6 +118,50 @@ func (q *Queries) EnableTwoFactorTypeEmail(ctx context.Context, arg EnableTwoFac
 	return err
 }
 
+const enableTwoFactorTypeSms = `-- name: EnableTwoFactorTypeSms :exec
+INSERT INTO pre_go_acc_user_two_factor_9999 (user_id, two_factor_auth_type, two_factor_phone, two_factor_auth_secret, two_factor_is_active, two_factor_created_at, two_factor_updated_at)
+VALUES (?, ?, ?, "OTP", FALSE, NOW(), NOW())
+`
+
+type EnableTwoFactorTypeSmsParams struct {
+	UserID            uint32
+	TwoFactorAuthType PreGoAccUserTwoFactor9999TwoFactorAuthType
+	TwoFactorPhone    sql.NullString
+}
+
+// EnableTwoFactorTypeSms
+func (q *Queries) EnableTwoFactorTypeSms(ctx context.Context, arg EnableTwoFactorTypeSmsParams) error {
+	_, err := q.db.ExecContext(ctx, enableTwoFactorTypeSms, arg.UserID, arg.TwoFactorAuthType, arg.TwoFactorPhone)
+	return err
+}
+
+const getActiveTwoFactorMethod = `-- name: GetActiveTwoFactorMethod :one
+SELECT two_factor_id, user_id, two_factor_auth_type, two_factor_auth_secret, 
+       two_factor_phone, two_factor_email, 
+       two_factor_is_active, two_factor_created_at, two_factor_updated_at
+FROM pre_go_acc_user_two_factor_9999
+WHERE user_id = ? AND two_factor_is_active = TRUE
+LIMIT 1
+`
+
+// GetActiveTwoFactorMethod
+func (q *Queries) GetActiveTwoFactorMethod(ctx context.Context, userID uint32) (PreGoAccUserTwoFactor9999, error) {
+	row := q.db.QueryRowContext(ctx, getActiveTwoFactorMethod, userID)
+	var i PreGoAccUserTwoFactor9999
+	err := row.Scan(
+		&i.TwoFactorID,
+		&i.UserID,
+		&i.TwoFactorAuthType,
+		&i.TwoFactorAuthSecret,
+		&i.TwoFactorPhone,
+		&i.TwoFactorEmail,
+		&i.TwoFactorIsActive,
+		&i.TwoFactorCreatedAt,
+		&i.TwoFactorUpdatedAt,
+	)
+	return i, err
+}
+
 const getTwoFactorMethodByID = `-- name: GetTwoFactorMethodByID :one
 SELECT two_factor_id, user_id, two_factor_auth_type, two_factor_auth_secret, 
        two_factor_phone, two_factor_email, 
@@ -251,6 +312,17 @@ func (q *Queries) ReactivateTwoFactor(ctx context.Context, arg ReactivateTwoFact
 	return err
 }
 
+const removeAllTwoFactor = `-- name: RemoveAllTwoFactor :exec
+DELETE FROM pre_go_acc_user_two_factor_9999
+WHERE user_id = ?
+`
+
+// RemoveAllTwoFactor
+func (q *Queries) RemoveAllTwoFactor(ctx context.Context, userID uint32) error {
+	_, err := q.db.ExecContext(ctx, removeAllTwoFactor, userID)
+	return err
+}
+
 const removeTwoFactor = `-- name: RemoveTwoFactor :exec
 DELETE FROM pre_go_acc_user_two_factor_9999
 WHERE user_id = ? AND two_factor_auth_type = ?
@@ -267,7 +339,7 @@ func (q *Queries) RemoveTwoFactor(ctx context.Context, arg RemoveTwoFactorParams
 	return err
 }
 
-const updateTwoFactorStatus = `-- name: UpdateTwoFactorStatus :exec
+const updateTwoFactorStatus = `-- name: UpdateTwoFactorStatus :execrows
 UPDATE pre_go_acc_user_two_factor_9999
 SET two_factor_is_active = TRUE, two_factor_updated_at = NOW()
 WHERE user_id = ? AND two_factor_auth_type = ? AND two_factor_is_active = FALSE
@@ -279,9 +351,12 @@ type UpdateTwoFactorStatusParams struct {
 }
 
 // UpdateTwoFactorStatusVerification
-func (q *Queries) UpdateTwoFactorStatus(ctx context.Context, arg UpdateTwoFactorStatusParams) error {
-	_, err := q.db.ExecContext(ctx, updateTwoFactorStatus, arg.UserID, arg.TwoFactorAuthType)
-	return err
+func (q *Queries) UpdateTwoFactorStatus(ctx context.Context, arg UpdateTwoFactorStatusParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateTwoFactorStatus, arg.UserID, arg.TwoFactorAuthType)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const verifyTwoFactor = `-- name: VerifyTwoFactor :one
@@ -9,6 +9,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"strings"
 )
 
 const countAllPublishedProducts = `-- name: CountAllPublishedProducts :one
@@ -35,18 +36,6 @@ func (q *Queries) CountProductsByType(ctx context.Context, productType string) (
 	return count, err
 }
 
-const countSearchProductsByName = `-- name: CountSearchProductsByName :one
-SELECT COUNT(*) FROM products
-WHERE product_name LIKE ? AND is_published = true
-`
-
-func (q *Queries) CountSearchProductsByName(ctx context.Context, productName string) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countSearchProductsByName, productName)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
-}
-
 const createBonsai = `-- name: CreateBonsai :execresult
 INSERT INTO bonsais (
     id, product_shop, age, height, style, species, pot_type
@@ -207,6 +196,20 @@ func (q *Queries) CreateVegetable(ctx context.Context, arg CreateVegetableParams
 	)
 }
 
+const deleteProduct = `-- name: DeleteProduct :execrows
+DELETE FROM products
+WHERE id = ?
+`
+
+// type rows and inventory are removed by ON DELETE CASCADE
+func (q *Queries) DeleteProduct(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteProduct, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getBonsaiByID = `-- name: GetBonsaiByID :one
 SELECT id, product_shop, age, height, style, species, pot_type, created_at, updated_at FROM bonsais
 WHERE id = ? LIMIT 1
@@ -356,6 +359,54 @@ func (q *Queries) ListAllPublishedProducts(ctx context.Context, arg ListAllPubli
 	return items, nil
 }
 
+const listBonsaisByIDs = `-- name: ListBonsaisByIDs :many
+SELECT id, product_shop, age, height, style, species, pot_type, created_at, updated_at FROM bonsais
+WHERE id IN (/*SLICE:ids*/?)
+`
+
+func (q *Queries) ListBonsaisByIDs(ctx context.Context, ids []string) ([]Bonsai, error) {
+	query := listBonsaisByIDs
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bonsai
+	for rows.Next() {
+		var i Bonsai
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductShop,
+			&i.Age,
+			&i.Height,
+			&i.Style,
+			&i.Species,
+			&i.PotType,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listDraftProducts = `-- name: ListDraftProducts :many
 SELECT id, product_name, product_price, product_discounted_price, product_thumb, product_description, product_quantity, product_type, sub_product_type, product_videos, product_pictures, product_status, product_selled, product_shop, is_draft, is_published, created_at, updated_at FROM products
 WHERE product_shop = ? AND is_draft = true
@@ -411,6 +462,54 @@ func (q *Queries) ListDraftProducts(ctx context.Context, arg ListDraftProductsPa
 	return items, nil
 }
 
+const listMushroomsByIDs = `-- name: ListMushroomsByIDs :many
+SELECT id, product_shop, weight, origin, freshness, package_type, created_at, updated_at FROM mushrooms
+WHERE id IN (/*SLICE:ids*/?)
+`
+
+// attributes of a page of products, one query per product type
+func (q *Queries) ListMushroomsByIDs(ctx context.Context, ids []string) ([]Mushroom, error) {
+	query := listMushroomsByIDs
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Mushroom
+	for rows.Next() {
+		var i Mushroom
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductShop,
+			&i.Weight,
+			&i.Origin,
+			&i.Freshness,
+			&i.PackageType,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listProductsByDiscount = `-- name: ListProductsByDiscount :many
 SELECT id, product_name, product_price, product_discounted_price, product_thumb, product_description, product_quantity, product_type, sub_product_type, product_videos, product_pictures, product_status, product_selled, product_shop, is_draft, is_published, created_at, updated_at FROM products
 WHERE is_published = true
@@ -629,60 +728,66 @@ func (q *Queries) ListPublishedProducts(ctx context.Context, arg ListPublishedPr
 	return items, nil
 }
 
-const publishProduct = `-- name: PublishProduct :execresult
-UPDATE products
-SET is_draft = false, is_published = true
-WHERE id = ? AND product_shop = ?
+const listPublishedShopProductIDs = `-- name: ListPublishedShopProductIDs :many
+SELECT id FROM products
+WHERE product_shop = ? AND is_published = true
 `
 
-type PublishProductParams struct {
-	ID          string
-	ProductShop string
-}
-
-func (q *Queries) PublishProduct(ctx context.Context, arg PublishProductParams) (sql.Result, error) {
-	return q.db.ExecContext(ctx, publishProduct, arg.ID, arg.ProductShop)
+// the products UnpublishShopProducts takes out of the search index
+func (q *Queries) ListPublishedShopProductIDs(ctx context.Context, productShop string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listPublishedShopProductIDs, productShop)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const searchProductsByName = `-- name: SearchProductsByName :many
-SELECT id, product_name, product_price, product_discounted_price, product_thumb, product_description, product_quantity, product_type, sub_product_type, product_videos, product_pictures, product_status, product_selled, product_shop, is_draft, is_published, created_at, updated_at FROM products
-WHERE product_name LIKE ? AND is_published = true
-ORDER BY created_at DESC
-LIMIT ? OFFSET ?
+const listVegetablesByIDs = `-- name: ListVegetablesByIDs :many
+SELECT id, product_shop, weight, origin, freshness, package_type, created_at, updated_at FROM vegetables
+WHERE id IN (/*SLICE:ids*/?)
 `
 
-type SearchProductsByNameParams struct {
-	ProductName string
-	Limit       int32
-	Offset      int32
-}
-
-func (q *Queries) SearchProductsByName(ctx context.Context, arg SearchProductsByNameParams) ([]Product, error) {
-	rows, err := q.db.QueryContext(ctx, searchProductsByName, arg.ProductName, arg.Limit, arg.Offset)
+func (q *Queries) ListVegetablesByIDs(ctx context.Context, ids []string) ([]Vegetable, error) {
+	query := listVegetablesByIDs
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Product
+	var items []Vegetable
 	for rows.Next() {
-		var i Product
+		var i Vegetable
 		if err := rows.Scan(
 			&i.ID,
-			&i.ProductName,
-			&i.ProductPrice,
-			&i.ProductDiscountedPrice,
-			&i.ProductThumb,
-			&i.ProductDescription,
-			&i.ProductQuantity,
-			&i.ProductType,
-			&i.SubProductType,
-			&i.ProductVideos,
-			&i.ProductPictures,
-			&i.ProductStatus,
-			&i.ProductSelled,
 			&i.ProductShop,
-			&i.IsDraft,
-			&i.IsPublished,
+			&i.Weight,
+			&i.Origin,
+			&i.Freshness,
+			&i.PackageType,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -699,6 +804,21 @@ func (q *Queries) SearchProductsByName(ctx context.Context, arg SearchProductsBy
 	return items, nil
 }
 
+const publishProduct = `-- name: PublishProduct :execresult
+UPDATE products
+SET is_draft = false, is_published = true
+WHERE id = ? AND product_shop = ?
+`
+
+type PublishProductParams struct {
+	ID          string
+	ProductShop string
+}
+
+func (q *Queries) PublishProduct(ctx context.Context, arg PublishProductParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, publishProduct, arg.ID, arg.ProductShop)
+}
+
 const unpublishProduct = `-- name: UnpublishProduct :execresult
 UPDATE products
 SET is_draft = true, is_published = false
@@ -714,6 +834,20 @@ func (q *Queries) UnpublishProduct(ctx context.Context, arg UnpublishProductPara
 	return q.db.ExecContext(ctx, unpublishProduct, arg.ID, arg.ProductShop)
 }
 
+const unpublishShopProducts = `-- name: UnpublishShopProducts :execrows
+UPDATE products
+SET is_draft = true, is_published = false
+WHERE product_shop = ? AND is_published = true
+`
+
+func (q *Queries) UnpublishShopProducts(ctx context.Context, productShop string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unpublishShopProducts, productShop)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateBonsai = `-- name: UpdateBonsai :execresult
 UPDATE bonsais
 SET 
@@ -9,6 +9,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 type PreGoAccUserTwoFactor9999TwoFactorAuthType string
@@ -71,6 +72,7 @@ type Bonsai struct {
 type Inventory struct {
 	ID        int32
 	ProductID string
+	SkuID     sql.NullString
 	ShopID    string
 	Location  sql.NullString
 	Stock     int32
@@ -105,6 +107,24 @@ type PreGoAccUserBase9999 struct {
 	IsTwoFactorEnabled sql.NullInt32
 }
 
+type PreGoAccUserDeletion9999 struct {
+	UserID              uint64
+	DeletionRequestedAt time.Time
+	DeletionScheduledAt time.Time
+	DeletionRequestedIp string
+}
+
+// pre_go_acc_user_identity_9999
+type PreGoAccUserIdentity9999 struct {
+	IdentityID          uint32
+	UserID              uint32
+	IdentityProvider    string
+	IdentitySubject     string
+	IdentityEmail       sql.NullString
+	IdentityCreatedAt   sql.NullTime
+	IdentityLastLoginAt sql.NullTime
+}
+
 // pre_go_acc_user_info_9999
 type PreGoAccUserInfo9999 struct {
 	// User ID
@@ -133,6 +153,35 @@ type PreGoAccUserInfo9999 struct {
 	UpdatedAt sql.NullTime
 }
 
+// pre_go_acc_user_login_history_9999
+type PreGoAccUserLoginHistory9999 struct {
+	LoginID                uint64
+	UserID                 sql.NullInt32
+	LoginAccount           string
+	LoginMethod            string
+	LoginOutcome           string
+	LoginIp                string
+	LoginUserAgent         string
+	LoginDeviceFingerprint string
+	LoginCountry           string
+	LoginCreatedAt         sql.NullTime
+}
+
+type PreGoAccUserPasskey9999 struct {
+	PasskeyID             uint32
+	UserID                uint32
+	PasskeyCredentialID   []byte
+	PasskeyPublicKey      []byte
+	PasskeyAlgorithm      int32
+	PasskeySignCount      uint32
+	PasskeyAaguid         []byte
+	PasskeyTransports     string
+	PasskeyName           string
+	PasskeyBackupEligible bool
+	PasskeyCreatedAt      sql.NullTime
+	PasskeyLastUsedAt     sql.NullTime
+}
+
 // pre_go_acc_user_two_factor_9999
 type PreGoAccUserTwoFactor9999 struct {
 	TwoFactorID         uint32
@@ -146,6 +195,15 @@ type PreGoAccUserTwoFactor9999 struct {
 	TwoFactorUpdatedAt  sql.NullTime
 }
 
+// pre_go_acc_user_two_factor_recovery_9999
+type PreGoAccUserTwoFactorRecovery9999 struct {
+	RecoveryID        uint32
+	UserID            uint32
+	RecoveryCodeHash  string
+	RecoveryUsedAt    sql.NullTime
+	RecoveryCreatedAt sql.NullTime
+}
+
 // account_user_verify
 type PreGoAccUserVerify9999 struct {
 	VerifyID        int32
@@ -157,6 +215,24 @@ type PreGoAccUserVerify9999 struct {
 	IsDeleted       sql.NullInt32
 	VerifyCreatedAt sql.NullTime
 	VerifyUpdatedAt sql.NullTime
+	// REGISTER, RESET_PASSWORD ... an OTP is only valid for its purpose
+	VerifyPurpose string
+}
+
+// pre_go_shop_api_key_9999
+type PreGoShopApiKey9999 struct {
+	KeyID         uint32
+	ShopID        uint64
+	KeyName       string
+	KeyPrefix     string
+	KeyHash       string
+	KeyScopes     string
+	KeyAllowedIps string
+	KeyLastUsedAt sql.NullTime
+	KeyLastUsedIp sql.NullString
+	KeyExpiresAt  sql.NullTime
+	KeyRevokedAt  sql.NullTime
+	KeyCreatedAt  sql.NullTime
 }
 
 // Products table
@@ -181,6 +257,29 @@ type Product struct {
 	UpdatedAt              sql.NullTime
 }
 
+// Product options table
+type ProductOption struct {
+	ID           int32
+	ProductID    string
+	OptionName   string
+	OptionValues json.RawMessage
+	Position     int32
+}
+
+// Product SKUs table
+type ProductSku struct {
+	ID                 string
+	ProductID          string
+	SkuCode            string
+	SkuOptions         json.RawMessage
+	SkuPrice           string
+	SkuDiscountedPrice sql.NullString
+	SkuWeight          sql.NullString
+	Position           int32
+	CreatedAt          sql.NullTime
+	UpdatedAt          sql.NullTime
+}
+
 // Vegetable products table
 type Vegetable struct {
 	ID          string
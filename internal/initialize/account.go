@@ -0,0 +1,50 @@
+package initialize
+
+import (
+	"context"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/service"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDeletionSweepMinutes = 60
+	deletionSweepLockKey        = "account:deletion:sweep"
+)
+
+// InitAccountDeletion purges the accounts whose deletion grace period is over, on one instance at a time
+func InitAccountDeletion() {
+	minutes := global.Config.Account.DeletionSweepMinutes
+	if minutes <= 0 {
+		minutes = defaultDeletionSweepMinutes
+	}
+	interval := time.Duration(minutes) * time.Minute
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			sweepDeletedAccounts(interval)
+			<-ticker.C
+		}
+	}()
+}
+
+func sweepDeletedAccounts(interval time.Duration) {
+	ctx := context.Background()
+	// the lock outlives the sweep, another instance skips this round
+	ok, err := global.Rdb.SetNX(ctx, deletionSweepLockKey, 1, interval/2).Result()
+	if err != nil || !ok {
+		return
+	}
+	purged, err := service.UserInfo().PurgeDeletedAccounts(ctx)
+	if err != nil {
+		global.Logger.Error("Purge deleted accounts failed", zap.Error(err))
+		return
+	}
+	if purged > 0 {
+		global.Logger.Info("Deleted accounts purged", zap.Int("count", purged))
+	}
+}
@@ -0,0 +1,52 @@
+package initialize
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/sendto"
+
+	"go.uber.org/zap"
+)
+
+// InitEmail selects the backend used to deliver mails.
+// A missing or unknown provider fails at startup instead of silently dropping OTP mails
+func InitEmail() {
+	c := global.Config.Email
+	senderEmail := c.SenderEmail
+	if senderEmail == "" {
+		senderEmail = os.Getenv("SENDER_EMAIL")
+	}
+	sendto.SetDefaultFrom(sendto.EmailAddress{Address: senderEmail, Name: c.SenderName})
+
+	apiKey := c.SendGridAPIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("SENDGRID_API_KEY")
+	}
+	provider := c.Provider
+	if provider == "" {
+		switch {
+		case apiKey != "":
+			provider = "sendgrid"
+		case global.Config.Server.Mode == "dev":
+			provider = "outbox"
+		default:
+			panic("email.provider is not set and SENDGRID_API_KEY is empty")
+		}
+	}
+
+	switch provider {
+	case "sendgrid":
+		sendto.SetEmailSender(sendto.NewSendGridSender(apiKey))
+	case "smtp":
+		timeout := time.Duration(c.SMTPTimeoutSeconds) * time.Second
+		sendto.SetEmailSender(sendto.NewSMTPSender(c.SMTPHost, c.SMTPPort, c.SMTPUsername, c.SMTPPassword, timeout))
+	case "outbox":
+		sendto.SetEmailSender(sendto.NewOutboxSender(c.OutboxDir, c.OutboxLimit))
+	default:
+		panic(fmt.Sprintf("email.provider %q is not supported", provider))
+	}
+	global.Logger.Info("Email sender initialized", zap.String("provider", provider))
+}
@@ -0,0 +1,107 @@
+package initialize
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/auth"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// longest-lived token we sign is the refresh token (7 days)
+const maxTokenTTL = 7 * 24 * time.Hour
+
+// InitJWTKeys loads the signing keys, the active one signs and all of them verify
+func InitJWTKeys() {
+	c := global.Config.JWT
+	ks := auth.NewKeySet(c.ACTIVE_KID, maxTokenTTL)
+	for _, kc := range c.KEYS {
+		key, err := loadSigningKey(kc.Kid, kc.Algorithm, kc.PrivateKeyFile, kc.PublicKeyFile, kc.RetiredAt)
+		if err != nil {
+			panic(fmt.Errorf("load jwt key %s: %w", kc.Kid, err))
+		}
+		if err = ks.Add(key); err != nil {
+			panic(err)
+		}
+	}
+
+	if len(c.KEYS) == 0 {
+		if global.Config.Server.Mode != "dev" {
+			panic("jwt.KEYS is empty, configure at least one signing key")
+		}
+		// dev without keys: throw-away key, tokens don't survive a restart
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			panic(err)
+		}
+		ks.ActiveKid = "dev-" + uuid.New().String()[:8]
+		if err = ks.Add(&auth.SigningKey{Kid: ks.ActiveKid, Alg: auth.AlgEdDSA, Private: private}); err != nil {
+			panic(err)
+		}
+		global.Logger.Warn("No jwt signing key configured, using an ephemeral EdDSA key", zap.String("kid", ks.ActiveKid))
+	}
+	if _, err := ks.Active(); err != nil {
+		panic(fmt.Errorf("jwt.ACTIVE_KID %q: %w", ks.ActiveKid, err))
+	}
+	auth.SetKeySet(ks)
+	global.Logger.Info("JWT signing keys initialized", zap.String("active_kid", ks.ActiveKid), zap.Int("keys", len(c.KEYS)))
+}
+
+func loadSigningKey(kid string, alg string, privateFile string, publicFile string, retiredAt string) (*auth.SigningKey, error) {
+	key := &auth.SigningKey{Kid: kid, Alg: alg}
+	if retiredAt != "" {
+		t, err := time.Parse(time.RFC3339, retiredAt)
+		if err != nil {
+			return nil, err
+		}
+		key.RetiredAt = t
+	}
+	if privateFile != "" {
+		pem, err := os.ReadFile(privateFile)
+		if err != nil {
+			return nil, err
+		}
+		var private crypto.PrivateKey
+		switch alg {
+		case auth.AlgRS256:
+			private, err = jwt.ParseRSAPrivateKeyFromPEM(pem)
+		case auth.AlgEdDSA:
+			private, err = jwt.ParseEdPrivateKeyFromPEM(pem)
+		default:
+			return nil, fmt.Errorf("algorithm %q is not supported", alg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("private key can't sign")
+		}
+		key.Private = signer
+		return key, nil
+	}
+	if publicFile == "" {
+		return nil, fmt.Errorf("private_key_file or public_key_file is required")
+	}
+	pem, err := os.ReadFile(publicFile)
+	if err != nil {
+		return nil, err
+	}
+	switch alg {
+	case auth.AlgRS256:
+		key.Public, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+	case auth.AlgEdDSA:
+		key.Public, err = jwt.ParseEdPublicKeyFromPEM(pem)
+	default:
+		return nil, fmt.Errorf("algorithm %q is not supported", alg)
+	}
+	return key, err
+}
@@ -5,6 +5,7 @@ import (
 	"go_ecommerce/global"
 	"go_ecommerce/internal/common"
 	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/po"
 	"time"
 
 	"go.uber.org/zap"
@@ -44,6 +45,7 @@ func InitMysql() {
 
 	// Run migrations for products tables if needed
 	migrateProductTables()
+	migrateRoleTables()
 }
 
 // setPool sets the MySQL connection pool settings
@@ -95,3 +97,16 @@ func migrateProductTables() {
 		global.Logger.Info("Migration products tables successful")
 	}
 }
+
+// migrateRoleTables creates go_db_role, go_db_user and the go_user_roles join table
+func migrateRoleTables() {
+	err := global.Mdb.AutoMigrate(
+		&po.Role{},
+		&po.User{},
+	)
+	if err != nil {
+		global.Logger.Error("Migration role tables failed", zap.Error(err))
+	} else {
+		global.Logger.Info("Migration role tables successful")
+	}
+}
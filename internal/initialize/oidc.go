@@ -0,0 +1,40 @@
+package initialize
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/oidc"
+
+	"go.uber.org/zap"
+)
+
+// InitOIDC discovers the configured social login providers.
+// A provider that cannot be reached is logged and left out, the password login keeps working
+func InitOIDC() {
+	for _, c := range global.Config.OIDC.Providers {
+		secret := c.ClientSecret
+		if secret == "" {
+			secret = os.Getenv("OIDC_" + strings.ToUpper(c.Name) + "_CLIENT_SECRET")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		p, err := oidc.Discover(ctx, oidc.Config{
+			Name:         c.Name,
+			Issuer:       c.Issuer,
+			ClientID:     c.ClientID,
+			ClientSecret: secret,
+			RedirectURL:  c.RedirectURL,
+			Scopes:       c.Scopes,
+		}, nil)
+		cancel()
+		if err != nil {
+			global.Logger.Error("InitOIDC error", zap.String("provider", c.Name), zap.Error(err))
+			continue
+		}
+		oidc.Register(p)
+		global.Logger.Info("InitOIDC provider ready", zap.String("provider", c.Name))
+	}
+}
@@ -0,0 +1,20 @@
+package initialize
+
+import (
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/crypto"
+
+	"go.uber.org/zap"
+)
+
+// InitPasswordHasher selects the algorithm used to hash new passwords
+func InitPasswordHasher() {
+	p := global.Config.Password
+	switch p.Algorithm {
+	case "bcrypt":
+		crypto.SetPasswordHasher(crypto.NewBcryptHasher(p.BcryptCost))
+	default:
+		crypto.SetPasswordHasher(crypto.NewArgon2idHasher(p.Argon2Memory, p.Argon2Iterations, p.Argon2Parallelism))
+	}
+	global.Logger.Info("Password hasher initialized", zap.String("algorithm", p.Algorithm))
+}
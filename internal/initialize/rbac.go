@@ -0,0 +1,35 @@
+package initialize
+
+import (
+	"context"
+	"go_ecommerce/global"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/repo"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/rbac"
+	"go_ecommerce/pkg/response"
+
+	"go.uber.org/zap"
+)
+
+// InitRBAC creates the role rows and gives the admin role to the bootstrap accounts
+func InitRBAC() {
+	ctx := context.Background()
+	if err := repo.NewRoleRepository().EnsureRoles(ctx, rbac.Roles()); err != nil {
+		global.Logger.Error("Seed roles failed", zap.Error(err))
+		return
+	}
+	queries := database.New(global.Mdbc)
+	for _, account := range global.Config.RBAC.BootstrapAdmins {
+		userBase, err := queries.GetOneUserInfo(ctx, account)
+		if err != nil {
+			global.Logger.Error("Bootstrap admin not found", zap.String("account", account), zap.Error(err))
+			continue
+		}
+		code, _, err := service.UserRole().AssignRole(ctx, &model.UserRoleInput{UserId: uint64(userBase.UserID), Role: rbac.RoleAdmin})
+		if err != nil || code != response.CodeSuccess {
+			global.Logger.Error("Bootstrap admin failed", zap.String("account", account), zap.Error(err))
+		}
+	}
+}
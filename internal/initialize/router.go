@@ -2,7 +2,10 @@ package initialize
 
 import (
 	"go_ecommerce/global"
+	"go_ecommerce/internal/controlller/account"
+	"go_ecommerce/internal/controlller/dev"
 	"go_ecommerce/internal/routers"
+	"go_ecommerce/internal/utils/sendto"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,6 +21,10 @@ func InitRouter() *gin.Engine {
 		gin.SetMode(gin.ReleaseMode)
 		r = gin.New()
 	}
+	// ClientIP feeds the per-IP OTP lock, X-Forwarded-For is only honored from known proxies
+	if err := r.SetTrustedProxies(global.Config.Server.TrustedProxies); err != nil {
+		panic(err)
+	}
 	// midderware
 	// r.Use() // logging
 	// r.Use() // cross
@@ -25,10 +32,19 @@ func InitRouter() *gin.Engine {
 	managerRouter := routers.RouterGroupApp.Manager
 	userRouter := routers.RouterGroupApp.User
 
+	// public keys of the token signers, other services verify our tokens with them
+	r.GET("/.well-known/jwks.json", account.JWKS.GetJWKS)
+
 	MainGroup := r.Group("/api/v1")
 	{
 		MainGroup.GET("/check_status") // tracking monitor
 	}
+	if global.Config.Server.Mode == "dev" && global.Config.Email.DevMailEndpoint && sendto.Outbox() != nil {
+		// captured mails of the outbox email provider, they contain every OTP: explicit opt-in only
+		devGroup := MainGroup.Group("/dev")
+		devGroup.GET("/mails", dev.Mail.ListMails)
+		devGroup.DELETE("/mails", dev.Mail.ClearMails)
+	}
 	{
 		managerRouter.InitUserRouter(MainGroup)
 		managerRouter.InitAdminRouter(MainGroup)
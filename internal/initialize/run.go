@@ -12,12 +12,21 @@ func Run() *gin.Engine {
 	LoadConfig()
 	fmt.Println("Load configuration mysql", global.Config.Mysql.Username)
 	InitLogger()
+	InitPasswordHasher()
+	InitJWTKeys()
+	InitSMS()
+	InitEmail()
+	InitOIDC()
+	InitWebAuthn()
 
 	global.Logger.Debug("config log ok", zap.String("ok", "success"))
 	InitMysql()
 	InitMysqlC()
 	InitService()
 	InitRedis()
+	InitRBAC()
+	InitAccountDeletion()
+	InitProductSearch()
 
 	r := InitRouter()
 	return r
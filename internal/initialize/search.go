@@ -0,0 +1,42 @@
+package initialize
+
+import (
+	"context"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/service"
+
+	"go.uber.org/zap"
+)
+
+const defaultSearchRebuildMinutes = 10
+
+// InitProductSearch builds the product search index before the server starts. Each instance
+// keeps its own index: the writes of the other instances come over redis pub/sub, and the
+// periodic rebuild picks up a change whose message was lost. A search reads its page from the
+// database again, a product unpublished in the meantime is never shown
+func InitProductSearch() {
+	minutes := global.Config.Search.RebuildMinutes
+	if minutes <= 0 {
+		minutes = defaultSearchRebuildMinutes
+	}
+	rebuildSearchIndex()
+	go service.ProductManagement().ListenSearchChanges(context.Background())
+	go func() {
+		ticker := time.NewTicker(time.Duration(minutes) * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			rebuildSearchIndex()
+		}
+	}()
+}
+
+func rebuildSearchIndex() {
+	start := time.Now()
+	if err := service.ProductManagement().RebuildSearchIndex(context.Background()); err != nil {
+		global.Logger.Error("Rebuild product search index failed", zap.Error(err))
+		return
+	}
+	global.Logger.Debug("Product search index rebuilt", zap.Duration("took", time.Since(start)))
+}
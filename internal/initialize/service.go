@@ -3,6 +3,7 @@ package initialize
 import (
 	"go_ecommerce/global"
 	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/repo"
 	"go_ecommerce/internal/service"
 	"go_ecommerce/internal/service/impl"
 )
@@ -11,7 +12,10 @@ func InitService() {
 	queries := database.New(global.Mdbc)
 	// User serive interface
 	service.InitUserLogin(impl.NewUserLoginImpl(queries))
-	
-	// Add product service initialization
+	service.InitUserRole(impl.NewUserRoleImpl(queries, repo.NewRoleRepository()))
+	// the account purge takes the products of a deleted shop out of the search
 	service.InitProductManagement(impl.NewProductService())
+	service.InitUserInfo(impl.NewUserInfoImpl(queries, service.UserRole(), service.ProductManagement()))
+	service.InitUserAdmin(impl.NewUserAdminImpl(queries, service.UserRole()))
+	service.InitShopApiKey(impl.NewShopApiKeyImpl(queries, service.UserRole()))
 }
@@ -0,0 +1,31 @@
+package initialize
+
+import (
+	"os"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/sendto"
+
+	"go.uber.org/zap"
+)
+
+// InitSMS selects the provider used to send SMS OTP
+func InitSMS() {
+	c := global.Config.SMS
+	switch c.Provider {
+	case "http":
+		apiKey := c.GatewayAPIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("SMS_GATEWAY_API_KEY")
+		}
+		timeout := time.Duration(c.TimeoutSeconds) * time.Second
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		sendto.SetSMSSender(sendto.NewHTTPSMSSender(c.GatewayURL, apiKey, c.Brandname, timeout))
+	default:
+		sendto.SetSMSSender(sendto.NewFileSMSSender(c.FilePath))
+	}
+	global.Logger.Info("SMS sender initialized", zap.String("provider", c.Provider))
+}
@@ -0,0 +1,35 @@
+package initialize
+
+import (
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/webauthn"
+
+	"go.uber.org/zap"
+)
+
+const defaultWebAuthnChallengeTTL = 5 * time.Minute
+
+// InitWebAuthn enables passkeys when a relying party is configured
+func InitWebAuthn() {
+	c := global.Config.WebAuthn
+	if c.RPID == "" {
+		return
+	}
+	if len(c.Origins) == 0 {
+		global.Logger.Error("InitWebAuthn error: no origins configured, passkeys stay disabled", zap.String("rp_id", c.RPID))
+		return
+	}
+	timeout := time.Duration(c.ChallengeTTLSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultWebAuthnChallengeTTL
+	}
+	webauthn.SetRelyingParty(&webauthn.RelyingParty{
+		ID:      c.RPID,
+		Name:    c.RPName,
+		Origins: c.Origins,
+		Timeout: timeout,
+	})
+	global.Logger.Info("InitWebAuthn passkeys ready", zap.String("rp_id", c.RPID))
+}
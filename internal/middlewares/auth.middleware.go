@@ -2,8 +2,15 @@ package middlewares
 
 import (
 	"context"
+	"errors"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/apikey"
 	"go_ecommerce/internal/utils/auth"
+	"go_ecommerce/internal/utils/cache"
+	usercontext "go_ecommerce/internal/utils/context"
+	"go_ecommerce/internal/utils/session"
 	"log"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,10 +33,82 @@ func AuthenMiddleware() gin.HandlerFunc {
 			c.AbortWithStatusJSON(401, gin.H{"code": 40001, "err": "invalid token", "description": ""})
 			return
 		}
-		// update claims to context
-		log.Println("claims::: UUID::", claims.Subject) // 11clitoken....
-		ctx := context.WithValue(c.Request.Context(), "subjectUUID", claims.Subject)
-		c.Request = c.Request.WithContext(ctx)
+		// session must still exist (logout / logout-all delete it)
+		alive, err := session.Touch(c.Request.Context(), claims.Subject)
+		if err != nil || !alive {
+			c.AbortWithStatusJSON(401, gin.H{"code": 40001, "err": "session revoked", "description": ""})
+			return
+		}
+		// resolve the caller once, handlers and services read it with usercontext.GetPrincipal
+		principal, err := loadPrincipal(c.Request.Context(), claims.Subject)
+		if err != nil {
+			c.AbortWithStatusJSON(401, gin.H{"code": 40001, "err": "session revoked", "description": ""})
+			return
+		}
+		c.Request = c.Request.WithContext(usercontext.WithPrincipal(c.Request.Context(), principal))
 		c.Next()
 	}
 }
+
+// AuthenOrApiKeyMiddleware also accepts shop API keys (X-API-Key or Authorization: ApiKey),
+// only for routes a machine client may use; account routes stay on AuthenMiddleware
+func AuthenOrApiKeyMiddleware() gin.HandlerFunc {
+	authen := AuthenMiddleware()
+	return func(c *gin.Context) {
+		key, found := auth.ExtractApiKey(c)
+		if !found {
+			authen(c)
+			return
+		}
+		principal, err := service.ShopApiKey().Authenticate(c.Request.Context(), key, c.ClientIP())
+		if errors.Is(err, apikey.ErrIPNotAllowed) {
+			c.AbortWithStatusJSON(403, gin.H{"code": 40001, "err": "api key not allowed from this address", "description": ""})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(401, gin.H{"code": 40001, "err": "invalid api key", "description": ""})
+			return
+		}
+		c.Request = c.Request.WithContext(usercontext.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// loadPrincipal builds the principal from the session payload and metadata
+func loadPrincipal(ctx context.Context, subToken string) (*usercontext.Principal, error) {
+	var infoUser usercontext.InfoUserUUID
+	if err := cache.GetCache(ctx, subToken, &infoUser); err != nil {
+		return nil, err
+	}
+	principal := &usercontext.Principal{
+		UserId:      infoUser.UserId,
+		UserAccount: infoUser.UserAccount,
+		ShopId:      strconv.FormatUint(infoUser.UserId, 10),
+		SessionId:   session.ID(subToken),
+		SubToken:    subToken,
+		AuthLevel:   usercontext.AuthLevelPassword,
+	}
+	info, found, err := session.Get(ctx, subToken)
+	if err != nil {
+		return nil, err
+	}
+	if found && info.AuthLevel > 0 {
+		principal.AuthLevel = info.AuthLevel
+	}
+	principal.Admin = found && info.Admin && principal.AuthLevel >= usercontext.AuthLevelTwoFactor
+	// roles are cached per session, assigning a role clears the cache
+	roles, found, err := session.CachedRoles(ctx, subToken)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		if roles, err = service.UserRole().GetRoles(ctx, infoUser.UserId); err != nil {
+			return nil, err
+		}
+		if err = session.CacheRoles(ctx, subToken, roles); err != nil {
+			return nil, err
+		}
+	}
+	principal.Roles = roles
+	return principal, nil
+}
@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	usercontext "go_ecommerce/internal/utils/context"
+	"go_ecommerce/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission rejects callers whose roles (or API key scopes) don't grant the permission, use after AuthenMiddleware
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := usercontext.GetPrincipal(c)
+		if err != nil {
+			c.AbortWithStatusJSON(401, gin.H{"code": 40001, "err": "Unauthorized", "description": ""})
+			return
+		}
+		if !principal.Can(permission) {
+			c.AbortWithStatusJSON(403, gin.H{"code": response.ErrCodePermissionDenied, "err": "permission denied", "description": permission})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdminSession only lets in sessions opened by the admin login (password + second factor)
+func RequireAdminSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := usercontext.GetPrincipal(c)
+		if err != nil {
+			c.AbortWithStatusJSON(401, gin.H{"code": 40001, "err": "Unauthorized", "description": ""})
+			return
+		}
+		if !principal.Admin {
+			c.AbortWithStatusJSON(403, gin.H{"code": response.ErrCodePermissionDenied, "err": "admin session required", "description": "log in with /admin/login"})
+			return
+		}
+		c.Next()
+	}
+}
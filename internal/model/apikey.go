@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// shop API keys, for POS terminals and partner integrations
+type CreateApiKeyInput struct {
+	ShopId        uint64   `json:"-"`
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`          // product:read, product:create, product:update, product:publish, inventory:write
+	AllowedIps    []string `json:"allowed_ips"`     // IPs or CIDRs, empty allows any address
+	ExpiresInDays int      `json:"expires_in_days"` // 0 never expires
+}
+
+type ApiKeyOutput struct {
+	KeyId      uint32     `json:"key_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIps []string   `json:"allowed_ips"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIp string     `json:"last_used_ip"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateApiKeyOutput struct {
+	ApiKeyOutput
+	Key string `json:"key"` // shown only once
+}
+
+type ApiKeyInput struct {
+	ShopId uint64 `json:"-"`
+	KeyId  uint32 `json:"-"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // two factor authentication
 type RefreshTokenInput struct {
 	RefreshToken string `json:"refreshToken"`
@@ -8,22 +10,118 @@ type SetupTwoFactorAuthInput struct {
 	UserId            uint32 `json:"user_id"`
 	TwoFactorAuthType string `json:"two_factor_auth_type"`
 	TwoFactorEmail    string `json:"two_factor_email"`
+	TwoFactorPhone    string `json:"two_factor_phone"` // SMS: any Vietnamese format, stored in E.164
+}
+
+type SetupTwoFactorAuthOutput struct {
+	TwoFactorAuthType string `json:"two_factor_auth_type"`
+	Secret            string `json:"secret,omitempty"`      // APP: base32 secret for manual entry
+	OtpAuthURL        string `json:"otpauth_url,omitempty"` // APP: otpauth:// provisioning URI
+	QRCode            string `json:"qr_code,omitempty"`     // APP: data:image/png;base64 QR code
 }
 
 type TwoFactorVerificationInput struct {
+	UserId            uint32 `json:"user_id"`
+	TwoFactorAuthType string `json:"two_factor_auth_type"`
+	TwoFactorCode     string `json:"two_factor_code"`
+	ClientIp          string `json:"-"`
+}
+
+type TwoFactorVerificationOutput struct {
+	RecoveryCodes []string `json:"recovery_codes,omitempty"` // shown only once
+}
+
+type RegenerateRecoveryCodesInput struct {
 	UserId        uint32 `json:"user_id"`
 	TwoFactorCode string `json:"two_factor_code"`
+	ClientIp      string `json:"-"`
 }
 
 type LoginOutput struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
 	Message      string `json:"message"`
+	// set when two-factor authentication is required, finish with /user/login/two-factor
+	ChallengeToken    string `json:"challengeToken,omitempty"`
+	TwoFactorAuthType string `json:"twoFactorAuthType,omitempty"`
+	// the challenge can also be completed with a passkey, see /user/login/passkey/start
+	PasskeyAvailable bool `json:"passkeyAvailable,omitempty"`
+}
+
+type LoginTwoFactorInput struct {
+	ChallengeToken string `json:"challenge_token"`
+	TwoFactorCode  string `json:"two_factor_code"`
+	LoginClient
 }
 
 type LoginInput struct {
 	UserAccount  string `json:"user_account"`
 	UserPassword string `json:"user_password"`
+	LoginClient
+}
+
+// LoginClient describes the device a session is created for
+type LoginClient struct {
+	DeviceName  string `json:"device_name"` // optional, derived from user agent when empty
+	ClientIp    string `json:"-"`
+	UserAgent   string `json:"-"`
+	Fingerprint string `json:"-"` // see loginaudit.Fingerprint
+	Country     string `json:"-"` // ISO country from the CDN header, empty when unknown
+}
+
+// social login (OpenID Connect)
+type OIDCStartOutput struct {
+	AuthURL string `json:"auth_url"` // redirect the user here
+	State   string `json:"state"`
+}
+
+type OIDCCallbackInput struct {
+	Provider string `json:"-"`
+	Code     string `json:"code"`
+	State    string `json:"state"`
+	LoginClient
+}
+
+// sessions
+type SessionInput struct {
+	UserId      uint64 `json:"-"`
+	UserAccount string `json:"-"`
+	SubToken    string `json:"-"` // session of the current request
+	SessionId   string `json:"session_id"`
+}
+
+type LoginHistoryInput struct {
+	UserId      uint64 `json:"-"`
+	Fingerprint string `json:"-"` // device of the request, marks its own entries
+	Page        int    `form:"page"`
+	Limit       int    `form:"limit"`
+}
+
+type LoginHistoryEntry struct {
+	Method        string    `json:"method"`  // password, two_factor, admin, passkey, oidc:<provider>
+	Outcome       string    `json:"outcome"` // success, challenge, failed, locked, not_activated
+	ClientIp      string    `json:"client_ip"`
+	Device        string    `json:"device"`
+	UserAgent     string    `json:"user_agent"`
+	Country       string    `json:"country"`
+	CurrentDevice bool      `json:"current_device"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type LoginHistoryOutput struct {
+	Entries []LoginHistoryEntry `json:"entries"`
+	Page    int                 `json:"page"`
+	Limit   int                 `json:"limit"`
+}
+
+type SessionOutput struct {
+	SessionId  string    `json:"session_id"`
+	Device     string    `json:"device"`
+	ClientIp   string    `json:"client_ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
 }
 
 type UpdatePasswordRegisterInput struct {
@@ -31,6 +129,42 @@ type UpdatePasswordRegisterInput struct {
 	UserPassword string `json:"user_password"`
 }
 
+type ForgotPasswordInput struct {
+	UserAccount string `json:"user_account"`
+}
+
+type ResetPasswordInput struct {
+	UserAccount string `json:"user_account"`
+	VerifyCode  string `json:"verify_code"`
+	NewPassword string `json:"new_password"`
+	ClientIp    string `json:"-"`
+}
+
+type ChangePasswordInput struct {
+	UserId      uint64 `json:"-"`
+	UserAccount string `json:"-"`
+	SubToken    string `json:"-"` // current session is kept, the others are revoked
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+type ChangeEmailInput struct {
+	UserId      uint64 `json:"-"`
+	UserAccount string `json:"-"`
+	NewEmail    string `json:"new_email"`
+	Password    string `json:"password"`
+}
+
+type ConfirmChangeEmailInput struct {
+	UserId     uint64 `json:"-"`
+	VerifyCode string `json:"verify_code"`
+	ClientIp   string `json:"-"`
+}
+
+type ChangeEmailOutput struct {
+	UserAccount string `json:"user_account"`
+}
+
 type RegisterInput struct {
 	VerifyKey     string `json:"verify_key"`
 	VerifyType    int    `json:"verify_type"`
@@ -40,6 +174,7 @@ type RegisterInput struct {
 type VerifyInput struct {
 	VerifyKey  string `json:"verify_key"`
 	VerifyCode string `json:"verify_code"`
+	ClientIp   string `json:"-"`
 }
 
 type VerifyOTPOutput struct {
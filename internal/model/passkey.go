@@ -0,0 +1,50 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// passkeys (WebAuthn)
+type PasskeyOptionsOutput struct {
+	PublicKey interface{} `json:"publicKey"` // argument of navigator.credentials.create / get
+}
+
+type PasskeyRegisterInput struct {
+	UserId      uint64          `json:"-"`
+	UserAccount string          `json:"-"`
+	SubToken    string          `json:"-"`
+	Name        string          `json:"name"`       // optional, e.g. "iPhone", derived from the user agent when empty
+	Credential  json.RawMessage `json:"credential"` // PublicKeyCredential returned by create, binary fields in base64url
+	UserAgent   string          `json:"-"`
+}
+
+type PasskeyOutput struct {
+	PasskeyId  uint32     `json:"passkey_id"`
+	Name       string     `json:"name"`
+	Synced     bool       `json:"synced"` // backed up by the platform, e.g. iCloud Keychain
+	Transports []string   `json:"transports"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type PasskeyInput struct {
+	UserId    uint64 `json:"-"`
+	PasskeyId uint32 `json:"-"`
+}
+
+type PasskeyLoginStartInput struct {
+	UserAccount    string `json:"user_account"`    // optional, empty lets the user pick a passkey
+	ChallengeToken string `json:"challenge_token"` // completes a password login waiting for its second factor
+}
+
+type PasskeyLoginInput struct {
+	Credential json.RawMessage `json:"credential"` // PublicKeyCredential returned by get
+	LoginClient
+}
+
+type PasskeyStepUpInput struct {
+	UserId     uint64          `json:"-"`
+	SubToken   string          `json:"-"`
+	Credential json.RawMessage `json:"credential"`
+}
@@ -13,8 +13,8 @@ type ProductModel struct {
 	ProductQuantity      int       `json:"product_quantity"`
 	ProductType          string    `json:"product_type"`
 	SubProductType       string    `json:"sub_product_type"`
-	ProductVideos        []string  `json:"product_videos"`
-	ProductPictures      []string  `json:"product_pictures"`
+	ProductVideos        []string  `json:"product_videos" gorm:"serializer:json"`
+	ProductPictures      []string  `json:"product_pictures" gorm:"serializer:json"`
 	ProductStatus        string    `json:"product_status"`
 	ProductSelled        int       `json:"product_selled"`
 	ProductShop          string    `json:"product_shop"`
@@ -22,6 +22,14 @@ type ProductModel struct {
 	IsPublished          bool      `json:"is_published" gorm:"default:false"`
 	CreatedAt            time.Time `json:"created_at"`
 	UpdatedAt            time.Time `json:"updated_at"`
+
+	// attributes of the product type, e.g. weight of a Mushroom, loaded from the type table
+	Attributes map[string]interface{} `json:"attributes" gorm:"-"`
+
+	// variants: options and SKUs are returned by the product detail, lists only have the price range
+	Options    []ProductOption `json:"options,omitempty" gorm:"-"`
+	Skus       []SkuModel      `json:"skus,omitempty" gorm:"-"`
+	PriceRange *PriceRange     `json:"price_range,omitempty" gorm:"-"`
 }
 
 // TableName ghi đè tên bảng trong gorm
@@ -89,6 +97,11 @@ type ProductInput struct {
 	ProductPictures      []string               `json:"product_pictures"`
 	ProductStatus        string                 `json:"product_status"`
 	ProductAttributes    map[string]interface{} `json:"product_attributes"`
+
+	// variant matrix, see SkuInput. Without options the product has a single SKU that takes the
+	// product price and quantity, with options and no skus every combination becomes a SKU
+	Options []ProductOption `json:"options"`
+	Skus    []SkuInput      `json:"skus"`
 }
 
 // InventoryInput là cấu trúc cho dữ liệu đầu vào khi tạo inventory
@@ -99,6 +112,12 @@ type InventoryInput struct {
 	Stock     int    `json:"stock"`
 }
 
+// StockInput sets the stock of a product, 0 marks it sold out
+type StockInput struct {
+	Stock   *int   `json:"stock"`
+	SkuCode string `json:"sku_code"` // required when the product has several SKUs
+}
+
 // ProductQueryParams là cấu trúc cho tham số truy vấn sản phẩm
 type ProductQueryParams struct {
 	Page        int    `form:"page" json:"page"`
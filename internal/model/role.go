@@ -0,0 +1,12 @@
+package model
+
+type UserRoleInput struct {
+	UserId uint64 `json:"-"`
+	Role   string `json:"role"`
+}
+
+type UserRolesOutput struct {
+	UserId      uint64   `json:"user_id"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,10 @@
+package model
+
+// SearchDocument is the searchable text of a published product
+type SearchDocument struct {
+	ID                 string
+	ProductName        string
+	ProductType        string
+	SubProductType     string
+	ProductDescription string // HTML, see CreateProduct
+}
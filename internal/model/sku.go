@@ -0,0 +1,34 @@
+package model
+
+// ProductOption is a variant dimension of a product, e.g. size: 250g, 500g, 1kg
+type ProductOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// SkuInput is one row of the variant matrix in the create and update API
+type SkuInput struct {
+	SkuCode       string            `json:"sku_code"`         // optional, derived from the option values
+	Options       map[string]string `json:"options"`          // one value for each option of the product
+	Price         float64           `json:"price"`            // 0 keeps the current price, a new SKU takes the product price
+	DiscountPrice float64           `json:"discounted_price"` // only read together with price
+	Weight        float64           `json:"weight"`           // shipping weight in kg
+	Stock         *int              `json:"stock"`            // nil keeps the current stock, a new SKU starts at 0
+}
+
+// SkuModel is a sellable variant of a product with its stock
+type SkuModel struct {
+	ID            string            `json:"id"`
+	SkuCode       string            `json:"sku_code"`
+	Options       map[string]string `json:"options"`
+	Price         float64           `json:"price"`
+	DiscountPrice float64           `json:"discounted_price"`
+	Weight        float64           `json:"weight"`
+	Stock         int               `json:"stock"`
+}
+
+// PriceRange is the price of the cheapest and of the most expensive SKU
+type PriceRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
@@ -0,0 +1,74 @@
+package model
+
+import "time"
+
+// UserInfoOutput is a row of pre_go_acc_user_info_9999 as returned by the API
+type UserInfoOutput struct {
+	UserId           uint64     `json:"user_id"`
+	UserAccount      string     `json:"user_account"`
+	UserNickname     string     `json:"user_nickname"`
+	UserAvatar       string     `json:"user_avatar"`
+	UserState        uint8      `json:"user_state"` // 0-Locked, 1-Activated, 2-Not Activated
+	UserMobile       string     `json:"user_mobile"`
+	UserGender       int16      `json:"user_gender"` // 0-Secret, 1-Male, 2-Female
+	UserBirthday     *time.Time `json:"user_birthday"`
+	UserEmail        string     `json:"user_email"`
+	IsAuthentication uint8      `json:"user_is_authentication"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// admin
+type FindUsersInput struct {
+	Keyword string `form:"q"` // matches account or nickname, empty lists every user
+	Page    int    `form:"page"`
+	Limit   int    `form:"limit"`
+}
+
+type UserListOutput struct {
+	Users []UserInfoOutput `json:"users"`
+	Page  int              `json:"page"`
+	Limit int              `json:"limit"`
+}
+
+type AdminUserInput struct {
+	UserId  uint64 `json:"-"`
+	AdminId uint64 `json:"-"` // an admin can't lock or remove itself
+}
+
+type AdminRegisterUserInput struct {
+	UserAccount  string `json:"user_account"` // email or phone number
+	UserPassword string `json:"user_password"`
+	UserNickname string `json:"user_nickname"`
+}
+
+// profile of the logged in user, omitted fields are left unchanged
+type UpdateUserInfoInput struct {
+	UserId       uint64  `json:"-"`
+	UserNickname *string `json:"user_nickname"`
+	UserAvatar   *string `json:"user_avatar"`   // http(s) URL, empty removes it
+	UserGender   *int16  `json:"user_gender"`   // 0-Secret, 1-Male, 2-Female
+	UserBirthday *string `json:"user_birthday"` // YYYY-MM-DD, empty removes it
+	UserMobile   *string `json:"user_mobile"`   // stored as +84xxxxxxxxx, empty removes it
+}
+
+// personal data export, the archive is built in the background
+type DataExportOutput struct {
+	Status      string     `json:"status"` // pending, ready, failed
+	RequestedAt time.Time  `json:"requested_at"`
+	ReadyAt     *time.Time `json:"ready_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // download before this
+	Size        int        `json:"size,omitempty"`       // bytes
+}
+
+// account deletion, takes effect after the grace period
+type DeleteAccountInput struct {
+	UserId      uint64 `json:"-"`
+	UserAccount string `json:"-"`
+	ClientIp    string `json:"-"`
+	Password    string `json:"password"`
+}
+
+type DeleteAccountOutput struct {
+	ScheduledAt time.Time `json:"scheduled_at"` // signing in before this cancels the deletion
+}
@@ -7,7 +7,7 @@ import (
 type Role struct {
 	gorm.Model
 	ID int64 `gorm:"column:id; type:int; not null;  primaryKey; autoIncrement; comment:'primary key is ID'"`
-	RoleName string `gorm:"column:role_name"`
+	RoleName string `gorm:"column:role_name; type:varchar(64); uniqueIndex"`
 	RoleNote string `gorm:"column:role_note; type:text"`
 }
  func (r *Role) TableName()string  {
@@ -15,6 +15,9 @@ import (
 )
 
 type IProductRepository interface {
+	// WithTx returns the repository bound to the transaction, see IUnitOfWork
+	WithTx(tx *Tx) IProductRepository
+
 	// Create methods
 	CreateProduct(ctx context.Context, product *model.ProductModel) error
 	CreateMushroom(ctx context.Context, mushroom *model.MushroomModel) error
@@ -24,11 +27,20 @@ type IProductRepository interface {
 	
 	// Read methods
 	FindProduct(ctx context.Context, productID string) (*model.ProductModel, error)
+	FindMushroomsByIDs(ctx context.Context, productIDs []string) ([]model.MushroomModel, error)
+	FindVegetablesByIDs(ctx context.Context, productIDs []string) ([]model.VegetableModel, error)
+	FindBonsaisByIDs(ctx context.Context, productIDs []string) ([]model.BonsaiModel, error)
 	
 	// Update methods
 	UpdateProductByID(ctx context.Context, productID string, updateData map[string]interface{}) error
+	UpdateMushroom(ctx context.Context, mushroom *model.MushroomModel) error
+	UpdateVegetable(ctx context.Context, vegetable *model.VegetableModel) error
+	UpdateBonsai(ctx context.Context, bonsai *model.BonsaiModel) error
 	PublishProductByShop(ctx context.Context, productID string, shopID string) error
 	UnPublishProductByShop(ctx context.Context, productID string, shopID string) error
+
+	// Delete methods
+	DeleteProduct(ctx context.Context, productID string) error
 	
 	// List methods
 	FindAllDraftsForShop(ctx context.Context, shopID string, limit, offset int) ([]model.ProductModel, error)
@@ -36,7 +48,11 @@ type IProductRepository interface {
 	FindAllProducts(ctx context.Context, params *model.ProductQueryParams) (*model.ProductResponse, error)
 	FindProductsByDiscount(ctx context.Context, params *model.ProductQueryParams) (*model.ProductResponse, error)
 	FindProductsBySelled(ctx context.Context, params *model.ProductQueryParams) (*model.ProductResponse, error)
-	SearchProducts(ctx context.Context, keyword string) ([]model.ProductModel, error)
+	FindPublishedProductsByIDs(ctx context.Context, ids []string) ([]model.ProductModel, error)
+
+	// Search index: the text of published products
+	FindSearchDocuments(ctx context.Context) ([]model.SearchDocument, error)
+	FindSearchDocument(ctx context.Context, productID string) (*model.SearchDocument, error)
 	
 	// Count methods
 	CountProducts(ctx context.Context, query map[string]interface{}) (int64, error)
@@ -54,6 +70,14 @@ func NewProductRepository() IProductRepository {
 	}
 }
 
+// WithTx shares the transaction between the sqlc queries and GORM
+func (p *productRepository) WithTx(tx *Tx) IProductRepository {
+	return &productRepository{
+		db:   tx.DB,
+		sqlc: tx.Queries,
+	}
+}
+
 // CreateProduct creates a new product using sqlc
 func (p *productRepository) CreateProduct(ctx context.Context, product *model.ProductModel) error {
 	// Convert JSON arrays to strings for database
@@ -218,9 +242,112 @@ func (p *productRepository) FindProduct(ctx context.Context, productID string) (
 	return product, nil
 }
 
+// FindMushroomsByIDs loads the mushroom rows of many products in one query
+func (p *productRepository) FindMushroomsByIDs(ctx context.Context, productIDs []string) ([]model.MushroomModel, error) {
+	rows, err := p.sqlc.ListMushroomsByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	mushrooms := make([]model.MushroomModel, 0, len(rows))
+	for _, row := range rows {
+		weight, _ := strconv.ParseFloat(row.Weight.String, 64)
+		mushrooms = append(mushrooms, model.MushroomModel{
+			ID:          row.ID,
+			ProductShop: row.ProductShop,
+			Weight:      weight,
+			Origin:      row.Origin.String,
+			Freshness:   row.Freshness.String,
+			PackageType: row.PackageType.String,
+		})
+	}
+	return mushrooms, nil
+}
+
+// FindVegetablesByIDs loads the vegetable rows of many products in one query
+func (p *productRepository) FindVegetablesByIDs(ctx context.Context, productIDs []string) ([]model.VegetableModel, error) {
+	rows, err := p.sqlc.ListVegetablesByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	vegetables := make([]model.VegetableModel, 0, len(rows))
+	for _, row := range rows {
+		weight, _ := strconv.ParseFloat(row.Weight.String, 64)
+		vegetables = append(vegetables, model.VegetableModel{
+			ID:          row.ID,
+			ProductShop: row.ProductShop,
+			Weight:      weight,
+			Origin:      row.Origin.String,
+			Freshness:   row.Freshness.String,
+			PackageType: row.PackageType.String,
+		})
+	}
+	return vegetables, nil
+}
+
+// FindBonsaisByIDs loads the bonsai rows of many products in one query
+func (p *productRepository) FindBonsaisByIDs(ctx context.Context, productIDs []string) ([]model.BonsaiModel, error) {
+	rows, err := p.sqlc.ListBonsaisByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	bonsais := make([]model.BonsaiModel, 0, len(rows))
+	for _, row := range rows {
+		bonsais = append(bonsais, model.BonsaiModel{
+			ID:          row.ID,
+			ProductShop: row.ProductShop,
+			Age:         int(row.Age.Int32),
+			Height:      int(row.Height.Int32),
+			Style:       row.Style.String,
+			Species:     row.Species.String,
+			PotType:     row.PotType.String,
+		})
+	}
+	return bonsais, nil
+}
+
 // UpdateProductByID updates a product by ID using gorm
 func (p *productRepository) UpdateProductByID(ctx context.Context, productID string, updateData map[string]interface{}) error {
-	return p.db.Model(&model.ProductModel{}).Where("id = ?", productID).Updates(updateData).Error
+	return p.db.WithContext(ctx).Model(&model.ProductModel{}).Where("id = ?", productID).Updates(updateData).Error
+}
+
+// UpdateMushroom changes the attributes of a mushroom, zero values keep the stored value
+func (p *productRepository) UpdateMushroom(ctx context.Context, mushroom *model.MushroomModel) error {
+	_, err := p.sqlc.UpdateMushroom(ctx, database.UpdateMushroomParams{
+		Weight:      sql.NullString{String: fmt.Sprintf("%.2f", mushroom.Weight), Valid: mushroom.Weight > 0},
+		NULLIF:      mushroom.Origin,
+		NULLIF_2:    mushroom.Freshness,
+		NULLIF_3:    mushroom.PackageType,
+		ID:          mushroom.ID,
+		ProductShop: mushroom.ProductShop,
+	})
+	return err
+}
+
+// UpdateVegetable changes the attributes of a vegetable, zero values keep the stored value
+func (p *productRepository) UpdateVegetable(ctx context.Context, vegetable *model.VegetableModel) error {
+	_, err := p.sqlc.UpdateVegetable(ctx, database.UpdateVegetableParams{
+		Weight:      sql.NullString{String: fmt.Sprintf("%.2f", vegetable.Weight), Valid: vegetable.Weight > 0},
+		NULLIF:      vegetable.Origin,
+		NULLIF_2:    vegetable.Freshness,
+		NULLIF_3:    vegetable.PackageType,
+		ID:          vegetable.ID,
+		ProductShop: vegetable.ProductShop,
+	})
+	return err
+}
+
+// UpdateBonsai changes the attributes of a bonsai, zero values keep the stored value
+func (p *productRepository) UpdateBonsai(ctx context.Context, bonsai *model.BonsaiModel) error {
+	_, err := p.sqlc.UpdateBonsai(ctx, database.UpdateBonsaiParams{
+		Age:         sql.NullInt32{Int32: int32(bonsai.Age), Valid: bonsai.Age > 0},
+		Height:      sql.NullInt32{Int32: int32(bonsai.Height), Valid: bonsai.Height > 0},
+		NULLIF:      bonsai.Style,
+		NULLIF_2:    bonsai.Species,
+		NULLIF_3:    bonsai.PotType,
+		ID:          bonsai.ID,
+		ProductShop: bonsai.ProductShop,
+	})
+	return err
 }
 
 // PublishProductByShop publishes a product using sqlc
@@ -232,6 +359,18 @@ func (p *productRepository) PublishProductByShop(ctx context.Context, productID
 	return err
 }
 
+// DeleteProduct deletes a product, its type row and inventory go with it (ON DELETE CASCADE)
+func (p *productRepository) DeleteProduct(ctx context.Context, productID string) error {
+	found, err := p.sqlc.DeleteProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if found == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // UnPublishProductByShop unpublishes a product using sqlc
 func (p *productRepository) UnPublishProductByShop(ctx context.Context, productID string, shopID string) error {
 	_, err := p.sqlc.UnpublishProduct(ctx, database.UnpublishProductParams{
@@ -410,32 +549,6 @@ func (p *productRepository) FindAllProducts(ctx context.Context, params *model.P
 			return nil, err
 		}
 		
-		// Convert to model
-		for _, prod := range dbProducts {
-			product := convertDbProductToModel(prod)
-			products = append(products, product)
-		}
-	} else if params.Keyword != "" {
-		// If there's a keyword search
-		searchPattern := "%" + params.Keyword + "%"
-		
-		// Get count
-		count, err := p.sqlc.CountSearchProductsByName(ctx, searchPattern)
-		if err != nil {
-			return nil, err
-		}
-		totalCount = count
-		
-		// Get products
-		dbProducts, err := p.sqlc.SearchProductsByName(ctx, database.SearchProductsByNameParams{
-			ProductName: searchPattern,
-			Limit:       int32(params.Limit),
-			Offset:      int32(offset),
-		})
-		if err != nil {
-			return nil, err
-		}
-		
 		// Convert to model
 		for _, prod := range dbProducts {
 			product := convertDbProductToModel(prod)
@@ -560,29 +673,59 @@ func (p *productRepository) FindProductsBySelled(ctx context.Context, params *mo
 	}, nil
 }
 
-// SearchProducts searches products by keyword using sqlc
-func (p *productRepository) SearchProducts(ctx context.Context, keyword string) ([]model.ProductModel, error) {
-	searchPattern := "%" + keyword + "%"
-	
-	dbProducts, err := p.sqlc.SearchProductsByName(ctx, database.SearchProductsByNameParams{
-		ProductName: searchPattern,
-		Limit:       100, // Reasonable limit
-		Offset:      0,
-	})
+// FindPublishedProductsByIDs loads a page of search hits in the order of ids
+func (p *productRepository) FindPublishedProductsByIDs(ctx context.Context, ids []string) ([]model.ProductModel, error) {
+	dbProducts, err := p.sqlc.ListPublishedProductsByIDs(ctx, ids)
 	if err != nil {
 		return nil, err
 	}
-	
-	// Convert to model
-	var products []model.ProductModel
+
+	byID := make(map[string]model.ProductModel, len(dbProducts))
 	for _, prod := range dbProducts {
-		product := convertDbProductToModel(prod)
-		products = append(products, product)
+		byID[prod.ID] = convertDbProductToModel(prod)
+	}
+	products := make([]model.ProductModel, 0, len(dbProducts))
+	for _, id := range ids {
+		if product, ok := byID[id]; ok {
+			products = append(products, product)
+		}
 	}
-	
 	return products, nil
 }
 
+// FindSearchDocuments returns the text of every published product
+func (p *productRepository) FindSearchDocuments(ctx context.Context) ([]model.SearchDocument, error) {
+	rows, err := p.sqlc.ListSearchDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]model.SearchDocument, 0, len(rows))
+	for _, row := range rows {
+		docs = append(docs, convertSearchDocument(database.GetSearchDocumentRow(row)))
+	}
+	return docs, nil
+}
+
+// FindSearchDocument returns the text of a product, sql.ErrNoRows when it is not published
+func (p *productRepository) FindSearchDocument(ctx context.Context, productID string) (*model.SearchDocument, error) {
+	row, err := p.sqlc.GetSearchDocument(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	doc := convertSearchDocument(row)
+	return &doc, nil
+}
+
+func convertSearchDocument(row database.GetSearchDocumentRow) model.SearchDocument {
+	return model.SearchDocument{
+		ID:                 row.ID,
+		ProductName:        row.ProductName,
+		ProductType:        row.ProductType,
+		SubProductType:     row.SubProductType.String,
+		ProductDescription: row.ProductDescription.String,
+	}
+}
+
 // CountProducts counts products based on query using gorm
 func (p *productRepository) CountProducts(ctx context.Context, query map[string]interface{}) (int64, error) {
 	var count int64
@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"go_ecommerce/global"
+	"go_ecommerce/internal/po"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// go_db_user rows share the id of the account (user_base.user_id), they only
+// exist to hold the go_user_roles assignments of that account.
+
+type IRoleRepository interface {
+	EnsureRoles(ctx context.Context, roleNames []string) error
+	GetUserRoles(ctx context.Context, userId uint64) ([]string, error)
+	AssignRole(ctx context.Context, userId uint64, userAccount string, roleName string) error
+	RemoveRole(ctx context.Context, userId uint64, roleName string) error
+	RemoveUser(ctx context.Context, userId uint64) error
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository() IRoleRepository {
+	return &roleRepository{db: global.Mdb}
+}
+
+// EnsureRoles creates the missing go_db_role rows
+func (r *roleRepository) EnsureRoles(ctx context.Context, roleNames []string) error {
+	for _, name := range roleNames {
+		var role po.Role
+		if err := r.db.WithContext(ctx).Where(po.Role{RoleName: name}).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUserRoles returns the role names assigned to the account
+func (r *roleRepository) GetUserRoles(ctx context.Context, userId uint64) ([]string, error) {
+	var names []string
+	err := r.db.WithContext(ctx).Model(&po.Role{}).
+		Joins("JOIN go_user_roles ur ON ur.role_id = go_db_role.id").
+		Where("ur.user_id = ?", userId).
+		Pluck("go_db_role.role_name", &names).Error
+	return names, err
+}
+
+// AssignRole adds the role to the account, assigning a role twice is a no-op
+func (r *roleRepository) AssignRole(ctx context.Context, userId uint64, userAccount string, roleName string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var role po.Role
+		if err := tx.Where(po.Role{RoleName: roleName}).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+		user := po.User{Model: gorm.Model{ID: uint(userId)}}
+		err := tx.Where(&user).
+			Attrs(po.User{UUID: uuid.New(), UserName: userAccount, IsActive: true}).
+			FirstOrCreate(&user).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&user).Association("Roles").Append(&role)
+	})
+}
+
+// RemoveRole removes the role from the account
+func (r *roleRepository) RemoveRole(ctx context.Context, userId uint64, roleName string) error {
+	var role po.Role
+	err := r.db.WithContext(ctx).Where(po.Role{RoleName: roleName}).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	user := po.User{Model: gorm.Model{ID: uint(userId)}}
+	return r.db.WithContext(ctx).Model(&user).Association("Roles").Delete(&role)
+}
+
+// RemoveUser deletes the go_db_user row of the account and its role assignments
+func (r *roleRepository) RemoveUser(ctx context.Context, userId uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user := po.User{Model: gorm.Model{ID: uint(userId)}}
+		if err := tx.Model(&user).Association("Roles").Clear(); err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&user).Error
+	})
+}
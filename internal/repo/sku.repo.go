@@ -0,0 +1,215 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+)
+
+// ISkuRepository stores the variants of products: options, SKUs and the stock per SKU
+type ISkuRepository interface {
+	// WithTx returns the repository bound to the transaction, see IUnitOfWork
+	WithTx(tx *Tx) ISkuRepository
+
+	FindOptions(ctx context.Context, productID string) ([]model.ProductOption, error)
+	FindSkus(ctx context.Context, productID string) ([]model.SkuModel, error)
+	FindPriceRanges(ctx context.Context, productIDs []string) (map[string]model.PriceRange, error)
+
+	ReplaceOptions(ctx context.Context, productID string, options []model.ProductOption) error
+	CreateSku(ctx context.Context, productID string, shopID string, sku *model.SkuModel, position int) error
+	UpdateSku(ctx context.Context, shopID string, sku *model.SkuModel, position int) error
+	DeleteSku(ctx context.Context, skuID string) error
+	UpdateStock(ctx context.Context, skuID string, shopID string, stock int) error
+	UpdateSummary(ctx context.Context, productID string, price float64, discountPrice float64, quantity int) error
+}
+
+type skuRepository struct {
+	sqlc *database.Queries
+}
+
+func NewSkuRepository() ISkuRepository {
+	return &skuRepository{
+		sqlc: database.New(global.Mdbc),
+	}
+}
+
+func (s *skuRepository) WithTx(tx *Tx) ISkuRepository {
+	return &skuRepository{sqlc: tx.Queries}
+}
+
+func (s *skuRepository) FindOptions(ctx context.Context, productID string) ([]model.ProductOption, error) {
+	rows, err := s.sqlc.ListProductOptions(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	options := make([]model.ProductOption, 0, len(rows))
+	for _, row := range rows {
+		var values []string
+		if err = json.Unmarshal(row.OptionValues, &values); err != nil {
+			return nil, err
+		}
+		options = append(options, model.ProductOption{Name: row.OptionName, Values: values})
+	}
+	return options, nil
+}
+
+// FindSkus returns the SKUs of a product in display order, with their stock
+func (s *skuRepository) FindSkus(ctx context.Context, productID string) ([]model.SkuModel, error) {
+	rows, err := s.sqlc.ListProductSkus(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	skus := make([]model.SkuModel, 0, len(rows))
+	for _, row := range rows {
+		sku := model.SkuModel{
+			ID:      row.ID,
+			SkuCode: row.SkuCode,
+			Stock:   int(row.Stock),
+		}
+		if err = json.Unmarshal(row.SkuOptions, &sku.Options); err != nil {
+			return nil, err
+		}
+		sku.Price, _ = strconv.ParseFloat(row.SkuPrice, 64)
+		if row.SkuDiscountedPrice.Valid {
+			sku.DiscountPrice, _ = strconv.ParseFloat(row.SkuDiscountedPrice.String, 64)
+		}
+		if row.SkuWeight.Valid {
+			sku.Weight, _ = strconv.ParseFloat(row.SkuWeight.String, 64)
+		}
+		skus = append(skus, sku)
+	}
+	return skus, nil
+}
+
+// FindPriceRanges loads the price range of many products in one query
+func (s *skuRepository) FindPriceRanges(ctx context.Context, productIDs []string) (map[string]model.PriceRange, error) {
+	rows, err := s.sqlc.ListSkuPriceRanges(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	ranges := make(map[string]model.PriceRange, len(rows))
+	for _, row := range rows {
+		ranges[row.ProductID] = model.PriceRange{Min: decimal(row.MinPrice), Max: decimal(row.MaxPrice)}
+	}
+	return ranges, nil
+}
+
+// ReplaceOptions deletes the options of the product and inserts the new ones in their order
+func (s *skuRepository) ReplaceOptions(ctx context.Context, productID string, options []model.ProductOption) error {
+	if err := s.sqlc.DeleteProductOptions(ctx, productID); err != nil {
+		return err
+	}
+	for i, o := range options {
+		values, err := json.Marshal(o.Values)
+		if err != nil {
+			return err
+		}
+		err = s.sqlc.CreateProductOption(ctx, database.CreateProductOptionParams{
+			ProductID:    productID,
+			OptionName:   o.Name,
+			OptionValues: values,
+			Position:     int32(i),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateSku inserts the SKU and its inventory row, sku.ID must be set
+func (s *skuRepository) CreateSku(ctx context.Context, productID string, shopID string, sku *model.SkuModel, position int) error {
+	options, err := json.Marshal(sku.Options)
+	if err != nil {
+		return err
+	}
+	err = s.sqlc.CreateProductSku(ctx, database.CreateProductSkuParams{
+		ID:                 sku.ID,
+		ProductID:          productID,
+		SkuCode:            sku.SkuCode,
+		SkuOptions:         options,
+		SkuPrice:           fmt.Sprintf("%.2f", sku.Price),
+		SkuDiscountedPrice: nullDecimal(sku.DiscountPrice),
+		SkuWeight:          nullDecimal(sku.Weight),
+		Position:           int32(position),
+	})
+	if err != nil {
+		return err
+	}
+	return s.sqlc.CreateSkuInventory(ctx, database.CreateSkuInventoryParams{
+		ProductID: productID,
+		SkuID:     sql.NullString{String: sku.ID, Valid: true},
+		ShopID:    shopID,
+		Stock:     int32(sku.Stock),
+	})
+}
+
+// UpdateSku changes the SKU and sets its stock
+func (s *skuRepository) UpdateSku(ctx context.Context, shopID string, sku *model.SkuModel, position int) error {
+	options, err := json.Marshal(sku.Options)
+	if err != nil {
+		return err
+	}
+	err = s.sqlc.UpdateProductSku(ctx, database.UpdateProductSkuParams{
+		SkuOptions:         options,
+		SkuPrice:           fmt.Sprintf("%.2f", sku.Price),
+		SkuDiscountedPrice: nullDecimal(sku.DiscountPrice),
+		SkuWeight:          nullDecimal(sku.Weight),
+		Position:           int32(position),
+		ID:                 sku.ID,
+	})
+	if err != nil {
+		return err
+	}
+	return s.UpdateStock(ctx, sku.ID, shopID, sku.Stock)
+}
+
+// DeleteSku deletes the SKU, its inventory row goes with it (ON DELETE CASCADE)
+func (s *skuRepository) DeleteSku(ctx context.Context, skuID string) error {
+	return s.sqlc.DeleteProductSku(ctx, skuID)
+}
+
+func (s *skuRepository) UpdateStock(ctx context.Context, skuID string, shopID string, stock int) error {
+	return s.sqlc.UpdateSkuStock(ctx, database.UpdateSkuStockParams{
+		Stock:  int32(stock),
+		SkuID:  sql.NullString{String: skuID, Valid: true},
+		ShopID: shopID,
+	})
+}
+
+// UpdateSummary sets the price and quantity of the products row from its SKUs, see variant.Summary
+func (s *skuRepository) UpdateSummary(ctx context.Context, productID string, price float64, discountPrice float64, quantity int) error {
+	return s.sqlc.UpdateProductSummary(ctx, database.UpdateProductSummaryParams{
+		ProductPrice:           fmt.Sprintf("%.2f", price),
+		ProductDiscountedPrice: nullDecimal(discountPrice),
+		ProductQuantity:        int32(quantity),
+		ID:                     productID,
+	})
+}
+
+func nullDecimal(v float64) sql.NullString {
+	return sql.NullString{String: fmt.Sprintf("%.2f", v), Valid: v > 0}
+}
+
+// decimal reads an aggregate of a DECIMAL column, the driver returns it as text
+func decimal(v interface{}) float64 {
+	switch n := v.(type) {
+	case []byte:
+		f, _ := strconv.ParseFloat(string(n), 64)
+		return f
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"go_ecommerce/global"
+	"go_ecommerce/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// Tx is one database transaction shared by the sqlc queries and GORM, a service
+// operation that uses both commits or rolls back as a whole
+type Tx struct {
+	Tx      *sql.Tx
+	Queries *database.Queries
+	DB      *gorm.DB // nil when GORM is not initialized
+}
+
+type IUnitOfWork interface {
+	// Do runs fn in a transaction, committed when fn returns nil and rolled back on an error or a panic
+	Do(ctx context.Context, fn func(tx *Tx) error) error
+}
+
+type unitOfWork struct {
+	db   *sql.DB
+	gorm *gorm.DB
+}
+
+// NewUnitOfWork begins its transactions on global.Mdbc, GORM statements run on the same connection
+func NewUnitOfWork() IUnitOfWork {
+	return &unitOfWork{
+		db:   global.Mdbc,
+		gorm: global.Mdb,
+	}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(tx *Tx) error) error {
+	sqlTx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// no-op after Commit, also runs when fn panics
+	defer sqlTx.Rollback()
+
+	tx := &Tx{Tx: sqlTx, Queries: database.New(u.db).WithTx(sqlTx)}
+	if u.gorm != nil {
+		// a new session keeps the GORM config, only the connection pool is replaced by the transaction
+		tx.DB = u.gorm.Session(&gorm.Session{NewDB: true, Context: ctx, SkipDefaultTransaction: true})
+		tx.DB.Statement.ConnPool = sqlTx
+	}
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return sqlTx.Commit()
+}
@@ -1,23 +1,20 @@
 package manager
 
-import "github.com/gin-gonic/gin"
+import (
+	"go_ecommerce/internal/controlller/manager"
 
-type AdminRouter struct{
+	"github.com/gin-gonic/gin"
+)
 
+type AdminRouter struct {
 }
-func (r *AdminRouter) InitAdminRouter(Router *gin.RouterGroup){
 
-	// private router
-	adminRouterPublic := Router.Group("/admin")
-	{
-		adminRouterPublic.POST("/login")
-
-	}
+func (r *AdminRouter) InitAdminRouter(Router *gin.RouterGroup) {
 
-	// private router
-	userRouterPrivate := Router.Group("/admin")
+	// public router, an admin session always needs the second factor
+	adminRouterPublic := Router.Group("/admin")
 	{
-		userRouterPrivate.POST("active-user")
-
+		adminRouterPublic.POST("/login", manager.Admin.Login)
+		adminRouterPublic.POST("/login/two-factor", manager.Admin.LoginTwoFactor)
 	}
-}
\ No newline at end of file
+}
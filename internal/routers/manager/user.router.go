@@ -1,21 +1,30 @@
 package manager
 
-import "github.com/gin-gonic/gin"
+import (
+	"go_ecommerce/internal/controlller/manager"
+	"go_ecommerce/internal/middlewares"
+	"go_ecommerce/internal/utils/rbac"
+
+	"github.com/gin-gonic/gin"
+)
 
 type UserRouter struct{}
 
 func (pr *UserRouter) InitUserRouter(Router *gin.RouterGroup) {
-	// public router
-	userRouterPublic := Router.Group("/admin/user")
-	{
-		userRouterPublic.POST("/register")
-	}
-	// private router
+	// private router, only sessions opened by /admin/login
 	userRouterPrivate := Router.Group("/admin/user")
 	// userRouterPrivate.Use(Limiter())
-	// userRouterPrivate.Use(Authen())
-	// userRouterPrivate.Use(Permission())
+	userRouterPrivate.Use(middlewares.AuthenMiddleware(), middlewares.RequireAdminSession())
 	{
-		userRouterPrivate.POST("/active-user")
+		userRouterPrivate.GET("", middlewares.RequirePermission(rbac.PermUserRead), manager.User.FindUsers)
+		userRouterPrivate.GET("/:id", middlewares.RequirePermission(rbac.PermUserRead), manager.User.FindOneUser)
+		userRouterPrivate.POST("/register", middlewares.RequirePermission(rbac.PermUserLock), manager.User.RegisterUser)
+		userRouterPrivate.POST("/:id/activate", middlewares.RequirePermission(rbac.PermUserLock), manager.User.ActivateUser)
+		userRouterPrivate.POST("/:id/lock", middlewares.RequirePermission(rbac.PermUserLock), manager.User.LockUser)
+		userRouterPrivate.POST("/:id/unlock", middlewares.RequirePermission(rbac.PermUserLock), manager.User.UnlockUser)
+		userRouterPrivate.DELETE("/:id", middlewares.RequirePermission(rbac.PermUserDelete), manager.User.RemoveUser)
+		userRouterPrivate.GET("/:id/roles", middlewares.RequirePermission(rbac.PermRoleAssign), manager.Role.ListUserRoles)
+		userRouterPrivate.POST("/:id/roles", middlewares.RequirePermission(rbac.PermRoleAssign), manager.Role.AssignRole)
+		userRouterPrivate.DELETE("/:id/roles/:role", middlewares.RequirePermission(rbac.PermRoleAssign), manager.Role.RemoveRole)
 	}
 }
@@ -3,6 +3,7 @@ package user
 import (
 	"go_ecommerce/internal/controlller/product"
 	"go_ecommerce/internal/middlewares"
+	"go_ecommerce/internal/utils/rbac"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,24 +17,29 @@ func (r *ProductRouter) InitProductRouter(Router *gin.RouterGroup) {
 		productRouterPublic.GET("", product.Product.GetAllProducts)
 		productRouterPublic.GET("/:id", product.Product.GetProductByID)
 		productRouterPublic.GET("/search", product.Product.SearchProducts)
+		productRouterPublic.GET("/types", product.Product.ProductTypes)
 		productRouterPublic.GET("/discounts", product.Product.GetProductsByDiscount)
 		productRouterPublic.GET("/bestsellers", product.Product.GetProductsBySelled)
 	}
 
-	// Private routes for product management
+	// Private routes for product management, shop API keys are accepted within their scopes
 	productRouterPrivate := Router.Group("/product")
-	productRouterPrivate.Use(middlewares.AuthenMiddleware())
+	productRouterPrivate.Use(middlewares.AuthenOrApiKeyMiddleware())
 	{
 		// Product CRUD operations
-		productRouterPrivate.POST("/create", product.Product.CreateProduct)
-		productRouterPrivate.PUT("/update/:id", product.Product.UpdateProduct)
+		productRouterPrivate.POST("/create", middlewares.RequirePermission(rbac.PermProductCreate), product.Product.CreateProduct)
+		productRouterPrivate.PUT("/update/:id", middlewares.RequirePermission(rbac.PermProductUpdate), product.Product.UpdateProduct)
+		productRouterPrivate.DELETE("/delete/:id", middlewares.RequirePermission(rbac.PermProductUpdate), product.Product.DeleteProduct)
 		
 		// Product status management
-		productRouterPrivate.PUT("/publish/:id", product.Product.PublishProduct)
-		productRouterPrivate.PUT("/unpublish/:id", product.Product.UnPublishProduct)
+		productRouterPrivate.PUT("/publish/:id", middlewares.RequirePermission(rbac.PermProductPublish), product.Product.PublishProduct)
+		productRouterPrivate.PUT("/unpublish/:id", middlewares.RequirePermission(rbac.PermProductPublish), product.Product.UnPublishProduct)
+
+		// Stock levels (POS terminals, partner stock sync)
+		productRouterPrivate.PUT("/stock/:id", middlewares.RequirePermission(rbac.PermInventoryWrite), product.Product.UpdateStock)
 		
 		// Shop-specific product lists
-		productRouterPrivate.GET("/drafts", product.Product.GetAllDraftsForShop)
-		productRouterPrivate.GET("/published", product.Product.GetAllPublishForShop)
+		productRouterPrivate.GET("/drafts", middlewares.RequirePermission(rbac.PermProductRead), product.Product.GetAllDraftsForShop)
+		productRouterPrivate.GET("/published", middlewares.RequirePermission(rbac.PermProductRead), product.Product.GetAllPublishForShop)
 	}
 }
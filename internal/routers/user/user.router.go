@@ -37,15 +37,47 @@ func (r *UserRouter) InitUserRouter(Router *gin.RouterGroup) {
 		userRouterPublic.POST("/refresh-token", account.Login.RefreshToken)
 		userRouterPublic.POST("/register", account.Login.Register)
 		userRouterPublic.POST("/login", account.Login.Login)
+		userRouterPublic.POST("/login/two-factor", account.Login.LoginTwoFactor)
+		userRouterPublic.POST("/login/passkey/start", account.Passkey.LoginStart)
+		userRouterPublic.POST("/login/passkey/finish", account.Passkey.LoginFinish)
 		userRouterPublic.POST("/verify-account", account.Login.VerifyOTP)
 		userRouterPublic.POST("/update-pass-register", account.Login.UpdatePasswordRegister)
+		userRouterPublic.POST("/password/forgot", account.Password.ForgotPassword)
+		userRouterPublic.POST("/password/reset", account.Password.ResetPassword)
+		userRouterPublic.GET("/oidc/:provider/start", account.OIDC.Start)
+		userRouterPublic.POST("/oidc/:provider/callback", account.OIDC.Callback)
 	}
 	// private router
 	userRouterPrivate := Router.Group("/user")
 	userRouterPrivate.Use(middlewares.AuthenMiddleware())
 	{
-		userRouterPrivate.GET("get-info")
+		userRouterPrivate.GET("/me", account.Info.GetInfo)
+		userRouterPrivate.PATCH("/me", account.Info.UpdateInfo)
+		userRouterPrivate.DELETE("/me", account.Info.DeleteAccount)
+		userRouterPrivate.POST("/me/export", account.Info.RequestExport)
+		userRouterPrivate.GET("/me/export", account.Info.GetExport)
+		userRouterPrivate.GET("/me/export/download", account.Info.DownloadExport)
+		// kept for older app builds, same payload as GET /me
+		userRouterPrivate.GET("/get-info", account.Info.GetInfo)
+		userRouterPrivate.PUT("/password", account.Password.ChangePassword)
+		userRouterPrivate.POST("/email/change", account.Email.RequestChangeEmail)
+		userRouterPrivate.POST("/email/change/confirm", account.Email.ConfirmChangeEmail)
+		userRouterPrivate.POST("/logout", account.Session.Logout)
+		userRouterPrivate.POST("/logout-all", account.Session.LogoutAll)
+		userRouterPrivate.GET("/sessions", account.Session.ListSessions)
+		userRouterPrivate.DELETE("/sessions/:id", account.Session.RevokeSession)
+		userRouterPrivate.GET("/login-history", account.Session.LoginHistory)
 		userRouterPrivate.POST("/two-factor/setup", account.TwoFA.SetupTwoFactorAuth)
 		userRouterPrivate.POST("/two-factor/verify", account.TwoFA.VerifyTwoFactorAuth)
+		userRouterPrivate.POST("/two-factor/recovery-codes", account.TwoFA.RegenerateRecoveryCodes)
+		userRouterPrivate.POST("/passkeys/register/start", account.Passkey.RegisterStart)
+		userRouterPrivate.POST("/passkeys/register/finish", account.Passkey.RegisterFinish)
+		userRouterPrivate.GET("/passkeys", account.Passkey.ListPasskeys)
+		userRouterPrivate.DELETE("/passkeys/:id", account.Passkey.RemovePasskey)
+		userRouterPrivate.POST("/passkeys/verify/start", account.Passkey.StepUpStart)
+		userRouterPrivate.POST("/passkeys/verify/finish", account.Passkey.StepUpFinish)
+		userRouterPrivate.POST("/api-keys", account.ApiKey.CreateKey)
+		userRouterPrivate.GET("/api-keys", account.ApiKey.ListKeys)
+		userRouterPrivate.DELETE("/api-keys/:id", account.ApiKey.RevokeKey)
 	}
 }
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"go_ecommerce/internal/model"
+	usercontext "go_ecommerce/internal/utils/context"
+)
+
+type (
+	IShopApiKey interface {
+		CreateKey(ctx context.Context, in *model.CreateApiKeyInput) (codeResult int, out model.CreateApiKeyOutput, err error)
+		ListKeys(ctx context.Context, shopId uint64) (codeResult int, out []model.ApiKeyOutput, err error)
+		RevokeKey(ctx context.Context, in *model.ApiKeyInput) (codeResult int, err error)
+		// Authenticate resolves the principal of a request made with an API key
+		Authenticate(ctx context.Context, key string, clientIp string) (*usercontext.Principal, error)
+	}
+)
+
+var (
+	localShopApiKey IShopApiKey
+)
+
+func ShopApiKey() IShopApiKey {
+	if localShopApiKey == nil {
+		panic("implement localShopApiKey not found for interface IShopApiKey")
+	}
+	return localShopApiKey
+}
+
+func InitShopApiKey(i IShopApiKey) {
+	localShopApiKey = i
+}
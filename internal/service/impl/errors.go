@@ -1,12 +1,17 @@
 package impl
 
-import "errors"
+import (
+	"errors"
+
+	"go_ecommerce/internal/utils/producttype"
+)
 
 // Các error constants
 var (
 	ErrInvalidInput       = errors.New("invalid input")
-	ErrInvalidProductType = errors.New("invalid product type")
+	ErrInvalidProductType = producttype.ErrUnknownType
 	ErrUnauthorized       = errors.New("unauthorized")
 	ErrNotFound           = errors.New("resource not found")
 	ErrServerError        = errors.New("internal server error")
+	ErrSkuRequired        = errors.New("sku_code is required, the product has several SKUs")
 ) 
\ No newline at end of file
@@ -0,0 +1,185 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/utils/producttype"
+	"go_ecommerce/internal/utils/search"
+
+	"go.uber.org/zap"
+)
+
+// productSearchChannel carries "<instance> <product id>" for each product an instance writes,
+// the other instances reindex it from the database, see ListenSearchChanges
+const productSearchChannel = "product:search:changed"
+
+// fields of the product search, a match in the name ranks above one in the type, the description comes last
+var productSearchFields = []search.Field{
+	{Name: "name", Boost: 3},
+	{Name: "type", Boost: 2},
+	{Name: "attributes", Boost: 1.5},
+	{Name: "description", Boost: 1},
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// searchDocument is what the index keeps of a product, the product type narrows a search.
+// attributes is the text of its searchable attributes, see searchAttributes
+func searchDocument(doc model.SearchDocument, attributes string) search.Document {
+	return search.Document{
+		ID:  doc.ID,
+		Tag: doc.ProductType,
+		Fields: map[string]string{
+			"name":        doc.ProductName,
+			"type":        doc.ProductType + " " + doc.SubProductType,
+			"attributes":  attributes,
+			"description": htmlTag.ReplaceAllString(doc.ProductDescription, " "),
+		},
+	}
+}
+
+// searchAttributes returns the text of the searchable attributes of each product by product ID,
+// with one query per product type, see producttype.Field.Searchable
+func (s *productService) searchAttributes(ctx context.Context, docs []model.SearchDocument) (map[string]string, error) {
+	idsByType := map[string][]string{}
+	for _, doc := range docs {
+		idsByType[doc.ProductType] = append(idsByType[doc.ProductType], doc.ID)
+	}
+	texts := make(map[string]string, len(docs))
+	for typeName, productIDs := range idsByType {
+		productType, err := producttype.Get(typeName)
+		if err != nil {
+			// a type that is no longer registered, its products are indexed without attributes
+			continue
+		}
+		attrs, err := productType.Load(ctx, s.productRepo, productIDs)
+		if err != nil {
+			return nil, err
+		}
+		for id, a := range attrs {
+			texts[id] = productType.SearchText(a)
+		}
+	}
+	return texts, nil
+}
+
+// RebuildSearchIndex loads every published product into the search index, it picks up the
+// writes of the other instances
+func (s *productService) RebuildSearchIndex(ctx context.Context) error {
+	docs, err := s.productRepo.FindSearchDocuments(ctx)
+	if err != nil {
+		return err
+	}
+	attributes, err := s.searchAttributes(ctx, docs)
+	if err != nil {
+		return err
+	}
+	indexed := make([]search.Document, 0, len(docs))
+	for _, doc := range docs {
+		indexed = append(indexed, searchDocument(doc, attributes[doc.ID]))
+	}
+	s.search.Reset(indexed)
+	return nil
+}
+
+// reindex updates the product in the search index after a committed write,
+// a product that is not published is removed
+func (s *productService) reindex(ctx context.Context, productID string) {
+	doc, err := s.productRepo.FindSearchDocument(ctx, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.search.Remove(productID)
+		return
+	}
+	var attributes map[string]string
+	if err == nil {
+		attributes, err = s.searchAttributes(ctx, []model.SearchDocument{*doc})
+	}
+	if err != nil {
+		// the write stands, the next rebuild picks the product up
+		global.Logger.Error("reindex product failed", zap.String("product_id", productID), zap.Error(err))
+		return
+	}
+	s.search.Put(searchDocument(*doc, attributes[productID]))
+}
+
+// ReindexProducts updates the products in the search index after a committed write, on this
+// instance at once and on the others through productSearchChannel
+func (s *productService) ReindexProducts(ctx context.Context, productIDs []string) {
+	for _, productID := range productIDs {
+		s.reindex(ctx, productID)
+		s.publishSearchChange(ctx, productID)
+	}
+}
+
+// publishSearchChange tells the other instances to reindex the product, a lost message
+// is picked up by the next rebuild
+func (s *productService) publishSearchChange(ctx context.Context, productID string) {
+	if err := global.Rdb.Publish(ctx, productSearchChannel, s.instance+" "+productID).Err(); err != nil {
+		global.Logger.Error("publish search change failed", zap.String("product_id", productID), zap.Error(err))
+	}
+}
+
+// ListenSearchChanges reindexes the products written by the other instances until ctx is done
+func (s *productService) ListenSearchChanges(ctx context.Context) {
+	sub := global.Rdb.Subscribe(ctx, productSearchChannel)
+	defer sub.Close()
+	changes := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-changes:
+			if !ok {
+				return
+			}
+			instance, productID, found := strings.Cut(msg.Payload, " ")
+			if found && instance != s.instance {
+				s.reindex(ctx, productID)
+			}
+		}
+	}
+}
+
+// SearchProducts finds published products by keyword, the best match first. Accents are optional
+// ("nam huong" finds "nấm hương"), a word may have a typo and the last word may be unfinished
+func (s *productService) SearchProducts(ctx context.Context, params *model.ProductQueryParams) (*model.ProductResponse, error) {
+	if strings.TrimSpace(params.Keyword) == "" {
+		return nil, ErrInvalidInput
+	}
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 10
+	}
+
+	hits, total := s.search.Search(params.Keyword, params.ProductType, (params.Page-1)*params.Limit, params.Limit)
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	products, err := s.productRepo.FindPublishedProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.withAttributes(ctx, products); err != nil {
+		return nil, err
+	}
+	if err = s.withPriceRanges(ctx, products); err != nil {
+		return nil, err
+	}
+
+	return &model.ProductResponse{
+		CurrentPage: params.Page,
+		TotalPages:  int(math.Ceil(float64(total) / float64(params.Limit))),
+		Total:       total,
+		Data:        products,
+	}, nil
+}
@@ -2,10 +2,16 @@ package impl
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"go_ecommerce/internal/model"
 	"go_ecommerce/internal/repo"
 	"go_ecommerce/internal/service"
-	"go_ecommerce/internal/utils/auth"
+	usercontext "go_ecommerce/internal/utils/context"
+	"go_ecommerce/internal/utils/producttype"
+	"go_ecommerce/internal/utils/rbac"
+	"go_ecommerce/internal/utils/search"
+	"go_ecommerce/internal/utils/variant"
 	"strings"
 	"time"
 
@@ -14,12 +20,20 @@ import (
 
 type productService struct {
 	productRepo repo.IProductRepository
+	skuRepo     repo.ISkuRepository
+	uow         repo.IUnitOfWork
+	search      *search.Index // published products, see RebuildSearchIndex
+	instance    string        // tells the search changes of this instance from the others
 }
 
 // NewProductService tạo một instance mới của service product
 func NewProductService() service.IProductManagement {
 	return &productService{
 		productRepo: repo.NewProductRepository(),
+		skuRepo:     repo.NewSkuRepository(),
+		uow:         repo.NewUnitOfWork(),
+		search:      search.NewIndex(productSearchFields...),
+		instance:    uuid.NewString(),
 	}
 }
 
@@ -29,15 +43,40 @@ var _ service.IProductManagement = (*productService)(nil)
 // CreateProduct tạo một sản phẩm mới dựa trên loại sản phẩm
 func (s *productService) CreateProduct(ctx context.Context, input *model.ProductInput) (interface{}, error) {
 	// Validate input
-	if input.ProductName == "" || input.ProductPrice <= 0 {
+	// a matrix with SKU rows carries the prices, the product price is the default of the rows
+	if input.ProductName == "" || (input.ProductPrice <= 0 && len(input.Skus) == 0) {
 		return nil, ErrInvalidInput
 	}
 
-	// Get user from context or auth token
-	userId, err := auth.ExtractUserID(ctx)
+	principal, err := usercontext.GetPrincipal(ctx)
 	if err != nil {
 		return nil, err
 	}
+	userId := principal.ShopId
+	if !principal.Can(rbac.PermProductCreate) {
+		return nil, ErrUnauthorized
+	}
+
+	// the registry knows the attributes and the table of each product type
+	productType, err := producttype.Get(input.ProductType)
+	if err != nil {
+		return nil, ErrInvalidProductType
+	}
+	attrs, err := productType.Validate(input.ProductAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	// every product has at least one SKU, the products row shows the cheapest price and the total stock
+	options, skus, err := variant.Build(input.Options, input.Skus, nil, variant.Defaults{
+		Price:         input.ProductPrice,
+		DiscountPrice: input.ProductDiscountPrice,
+		Stock:         input.ProductQuantity,
+	})
+	if err != nil {
+		return nil, err
+	}
+	price, discountPrice, quantity := variant.Summary(skus)
 
 	// Process HTML description
 	description := input.ProductDescription
@@ -59,11 +98,11 @@ func (s *productService) CreateProduct(ctx context.Context, input *model.Product
 	product := &model.ProductModel{
 		ID:                   productID,
 		ProductName:          input.ProductName,
-		ProductPrice:         input.ProductPrice,
-		ProductDiscountPrice: input.ProductDiscountPrice,
+		ProductPrice:         price,
+		ProductDiscountPrice: discountPrice,
 		ProductThumb:         input.ProductThumb,
 		ProductDescription:   input.ProductDescription,
-		ProductQuantity:      input.ProductQuantity,
+		ProductQuantity:      quantity,
 		ProductType:          input.ProductType,
 		SubProductType:       input.SubProductType,
 		ProductVideos:        input.ProductVideos,
@@ -74,120 +113,70 @@ func (s *productService) CreateProduct(ctx context.Context, input *model.Product
 		IsPublished:          false,
 		CreatedAt:            time.Now(),
 		UpdatedAt:            time.Now(),
+		Options:              options,
+		Skus:                 skus,
+	}
+	for i := range skus {
+		skus[i].ID = uuid.New().String()
 	}
 
-	// Create specific product type based on product_type
-	var err2 error
-	switch input.ProductType {
-	case "Mushroom":
-		mushroom := &model.MushroomModel{
-			ID:          productID,
-			ProductShop: userId,
-		}
-
-		// Extract attributes from input
-		if attrs, ok := input.ProductAttributes["weight"].(float64); ok {
-			mushroom.Weight = attrs
-		}
-		if attrs, ok := input.ProductAttributes["origin"].(string); ok {
-			mushroom.Origin = attrs
-		}
-		if attrs, ok := input.ProductAttributes["freshness"].(string); ok {
-			mushroom.Freshness = attrs
-		}
-		if attrs, ok := input.ProductAttributes["package_type"].(string); ok {
-			mushroom.PackageType = attrs
-		}
-
-		err2 = s.productRepo.CreateMushroom(ctx, mushroom)
-	case "Vegetable":
-		vegetable := &model.VegetableModel{
-			ID:          productID,
-			ProductShop: userId,
-		}
-
-		// Extract attributes from input
-		if attrs, ok := input.ProductAttributes["weight"].(float64); ok {
-			vegetable.Weight = attrs
-		}
-		if attrs, ok := input.ProductAttributes["origin"].(string); ok {
-			vegetable.Origin = attrs
-		}
-		if attrs, ok := input.ProductAttributes["freshness"].(string); ok {
-			vegetable.Freshness = attrs
-		}
-		if attrs, ok := input.ProductAttributes["package_type"].(string); ok {
-			vegetable.PackageType = attrs
-		}
-
-		err2 = s.productRepo.CreateVegetable(ctx, vegetable)
-	case "Bonsai":
-		bonsai := &model.BonsaiModel{
-			ID:          productID,
-			ProductShop: userId,
-		}
-
-		// Extract attributes from input
-		if attrs, ok := input.ProductAttributes["age"].(int); ok {
-			bonsai.Age = attrs
-		}
-		if attrs, ok := input.ProductAttributes["height"].(int); ok {
-			bonsai.Height = attrs
+	// one transaction: the products row first, the type row, options and SKUs reference it
+	err = s.uow.Do(ctx, func(tx *repo.Tx) error {
+		r := s.productRepo.WithTx(tx)
+		if err := r.CreateProduct(ctx, product); err != nil {
+			return err
 		}
-		if attrs, ok := input.ProductAttributes["style"].(string); ok {
-			bonsai.Style = attrs
+		if err := productType.Create(ctx, r, productID, userId, attrs); err != nil {
+			return err
 		}
-		if attrs, ok := input.ProductAttributes["species"].(string); ok {
-			bonsai.Species = attrs
+		sr := s.skuRepo.WithTx(tx)
+		if len(options) > 0 {
+			if err := sr.ReplaceOptions(ctx, productID, options); err != nil {
+				return err
+			}
 		}
-		if attrs, ok := input.ProductAttributes["pot_type"].(string); ok {
-			bonsai.PotType = attrs
+		// each SKU gets its inventory row, the stock lives there
+		for i := range skus {
+			if err := sr.CreateSku(ctx, productID, userId, &skus[i], i); err != nil {
+				return err
+			}
 		}
-
-		err2 = s.productRepo.CreateBonsai(ctx, bonsai)
-	default:
-		return nil, ErrInvalidProductType
-	}
-
-	if err2 != nil {
-		return nil, err2
-	}
-
-	// Create the main product
-	err = s.productRepo.CreateProduct(ctx, product)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create inventory record
-	inventory := &model.InventoryInput{
-		ProductID: productID,
-		ShopID:    userId,
-		Stock:     input.ProductQuantity,
-	}
-	err = s.productRepo.InsertInventory(ctx, inventory)
+		return nil
+	})
 	if err != nil {
-		// Handle error, maybe delete the product if inventory creation fails
 		return nil, err
 	}
 
 	return product, nil
 }
 
-// UpdateProduct cập nhật sản phẩm hiện có
+// UpdateProduct cập nhật sản phẩm hiện có, all rows change in one transaction
 func (s *productService) UpdateProduct(ctx context.Context, productID string, input *model.ProductInput) error {
-	userId, err := auth.ExtractUserID(ctx)
+	principal, err := usercontext.GetPrincipal(ctx)
 	if err != nil {
 		return err
 	}
+	err = s.uow.Do(ctx, func(tx *repo.Tx) error {
+		return s.updateProduct(ctx, s.productRepo.WithTx(tx), s.skuRepo.WithTx(tx), principal, productID, input)
+	})
+	if err != nil {
+		return err
+	}
+	s.ReindexProducts(ctx, []string{productID})
+	return nil
+}
+
+func (s *productService) updateProduct(ctx context.Context, r repo.IProductRepository, sr repo.ISkuRepository, principal *usercontext.Principal, productID string, input *model.ProductInput) error {
+	userId := principal.ShopId
 
 	// Find the product to check ownership
-	product, err := s.productRepo.FindProduct(ctx, productID)
+	product, err := r.FindProduct(ctx, productID)
 	if err != nil {
 		return err
 	}
 
-	if product.ProductShop != userId {
+	// moderators may fix products of any shop
+	if product.ProductShop != userId && !principal.Can(rbac.PermProductModerate) {
 		return ErrUnauthorized
 	}
 
@@ -197,9 +186,6 @@ func (s *productService) UpdateProduct(ctx context.Context, productID string, in
 	if input.ProductName != "" {
 		updateData["product_name"] = input.ProductName
 	}
-	if input.ProductPrice > 0 {
-		updateData["product_price"] = input.ProductPrice
-	}
 	if input.ProductThumb != "" {
 		updateData["product_thumb"] = input.ProductThumb
 	}
@@ -215,15 +201,9 @@ func (s *productService) UpdateProduct(ctx context.Context, productID string, in
 		}
 		updateData["product_description"] = htmlContent
 	}
-	if input.ProductQuantity > 0 {
-		updateData["product_quantity"] = input.ProductQuantity
-	}
 	if input.ProductStatus != "" {
 		updateData["product_status"] = input.ProductStatus
 	}
-	if input.ProductDiscountPrice > 0 {
-		updateData["product_discounted_price"] = input.ProductDiscountPrice
-	}
 	if len(input.ProductVideos) > 0 {
 		updateData["product_videos"] = input.ProductVideos
 	}
@@ -237,101 +217,277 @@ func (s *productService) UpdateProduct(ctx context.Context, productID string, in
 	updateData["updated_at"] = time.Now()
 
 	// Update specific product type attributes
-	switch product.ProductType {
-	case "Mushroom":
-		mushroomAttrs := map[string]interface{}{}
-		if attrs, ok := input.ProductAttributes["weight"].(float64); ok {
-			mushroomAttrs["weight"] = attrs
-		}
-		if attrs, ok := input.ProductAttributes["origin"].(string); ok {
-			mushroomAttrs["origin"] = attrs
-		}
-		if attrs, ok := input.ProductAttributes["freshness"].(string); ok {
-			mushroomAttrs["freshness"] = attrs
-		}
-		if attrs, ok := input.ProductAttributes["package_type"].(string); ok {
-			mushroomAttrs["package_type"] = attrs
-		}
-
-		if len(mushroomAttrs) > 0 {
-			err = s.productRepo.UpdateProductByID(ctx, productID, mushroomAttrs)
+	if len(input.ProductAttributes) > 0 {
+		productType, err := producttype.Get(product.ProductType)
+		if err != nil {
+			return ErrInvalidProductType
+		}
+		attrs, err := productType.ValidatePartial(input.ProductAttributes)
+		if err != nil {
+			return err
+		}
+		// the type table is keyed by the shop of the product, a moderator is not its owner
+		if len(attrs) > 0 {
+			err = productType.Update(ctx, r, productID, product.ProductShop, attrs)
 			if err != nil {
 				return err
 			}
 		}
-	case "Vegetable":
-		vegetableAttrs := map[string]interface{}{}
-		if attrs, ok := input.ProductAttributes["weight"].(float64); ok {
-			vegetableAttrs["weight"] = attrs
-		}
-		if attrs, ok := input.ProductAttributes["origin"].(string); ok {
-			vegetableAttrs["origin"] = attrs
-		}
-		if attrs, ok := input.ProductAttributes["freshness"].(string); ok {
-			vegetableAttrs["freshness"] = attrs
-		}
-		if attrs, ok := input.ProductAttributes["package_type"].(string); ok {
-			vegetableAttrs["package_type"] = attrs
+	}
+
+	// price and quantity are kept per SKU, the products row follows them
+	if err = s.updateVariants(ctx, sr, product, input); err != nil {
+		return err
+	}
+
+	// Update main product
+	if len(updateData) > 0 {
+		return r.UpdateProductByID(ctx, productID, updateData)
+	}
+
+	return nil
+}
+
+// updateVariants saves the variant matrix of the input: SKUs that are matched by code are updated,
+// new ones are created and the ones left out are deleted. The product price and quantity
+// only apply to a product with a single SKU
+func (s *productService) updateVariants(ctx context.Context, sr repo.ISkuRepository, product *model.ProductModel, input *model.ProductInput) error {
+	if input.Options == nil && input.Skus == nil &&
+		input.ProductPrice <= 0 && input.ProductDiscountPrice <= 0 && input.ProductQuantity <= 0 {
+		return nil
+	}
+	existing, err := sr.FindSkus(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	options := input.Options
+	if options == nil {
+		if options, err = sr.FindOptions(ctx, product.ID); err != nil {
+			return err
 		}
+	}
 
-		if len(vegetableAttrs) > 0 {
-			err = s.productRepo.UpdateProductByID(ctx, productID, vegetableAttrs)
-			if err != nil {
-				return err
+	rows := input.Skus
+	if input.Options == nil && input.Skus == nil {
+		if len(existing) > 1 {
+			var fields []variant.FieldError
+			if input.ProductPrice > 0 {
+				fields = append(fields, variant.FieldError{Field: "product_price", Message: "is set per SKU, the product has variants"})
 			}
+			if input.ProductDiscountPrice > 0 {
+				fields = append(fields, variant.FieldError{Field: "product_discounted_price", Message: "is set per SKU, the product has variants"})
+			}
+			if input.ProductQuantity > 0 {
+				fields = append(fields, variant.FieldError{Field: "product_quantity", Message: "is set per SKU, the product has variants"})
+			}
+			return &variant.InvalidError{Fields: fields}
+		}
+		if len(existing) == 1 {
+			row := model.SkuInput{
+				SkuCode:       existing[0].SkuCode,
+				Options:       existing[0].Options,
+				Price:         input.ProductPrice,
+				DiscountPrice: input.ProductDiscountPrice,
+			}
+			if row.Price <= 0 {
+				row.Price = existing[0].Price
+			}
+			if row.DiscountPrice <= 0 {
+				row.DiscountPrice = existing[0].DiscountPrice
+			}
+			if input.ProductQuantity > 0 {
+				row.Stock = &input.ProductQuantity
+			}
+			rows = []model.SkuInput{row}
 		}
-	case "Bonsai":
-		bonsaiAttrs := map[string]interface{}{}
-		if attrs, ok := input.ProductAttributes["age"].(int); ok {
-			bonsaiAttrs["age"] = attrs
-		}
-		if attrs, ok := input.ProductAttributes["height"].(int); ok {
-			bonsaiAttrs["height"] = attrs
-		}
-		if attrs, ok := input.ProductAttributes["style"].(string); ok {
-			bonsaiAttrs["style"] = attrs
-		}
-		if attrs, ok := input.ProductAttributes["species"].(string); ok {
-			bonsaiAttrs["species"] = attrs
-		}
-		if attrs, ok := input.ProductAttributes["pot_type"].(string); ok {
-			bonsaiAttrs["pot_type"] = attrs
-		}
+	}
 
-		if len(bonsaiAttrs) > 0 {
-			err = s.productRepo.UpdateProductByID(ctx, productID, bonsaiAttrs)
-			if err != nil {
+	// new SKUs of a regenerated matrix take the product price
+	d := variant.Defaults{Price: product.ProductPrice, DiscountPrice: product.ProductDiscountPrice, Stock: product.ProductQuantity}
+	if input.ProductPrice > 0 {
+		d.Price, d.DiscountPrice = input.ProductPrice, input.ProductDiscountPrice
+	}
+	if input.ProductQuantity > 0 {
+		d.Stock = input.ProductQuantity
+	}
+	options, skus, err := variant.Build(options, rows, existing, d)
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[string]bool, len(skus))
+	for _, sku := range skus {
+		kept[sku.ID] = true
+	}
+	for _, sku := range existing {
+		if !kept[sku.ID] {
+			if err = sr.DeleteSku(ctx, sku.ID); err != nil {
 				return err
 			}
 		}
 	}
-
-	// Update main product
-	if len(updateData) > 0 {
-		return s.productRepo.UpdateProductByID(ctx, productID, updateData)
+	// the inventory rows belong to the shop of the product, a moderator is not its owner
+	for i := range skus {
+		if skus[i].ID == "" {
+			skus[i].ID = uuid.New().String()
+			err = sr.CreateSku(ctx, product.ID, product.ProductShop, &skus[i], i)
+		} else {
+			err = sr.UpdateSku(ctx, product.ProductShop, &skus[i], i)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if input.Options != nil {
+		if err = sr.ReplaceOptions(ctx, product.ID, options); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	price, discountPrice, quantity := variant.Summary(skus)
+	return sr.UpdateSummary(ctx, product.ID, price, discountPrice, quantity)
 }
 
 // PublishProduct đăng tải sản phẩm
 func (s *productService) PublishProduct(ctx context.Context, productID string, shopID string) error {
-	return s.productRepo.PublishProductByShop(ctx, productID, shopID)
+	if err := s.productRepo.PublishProductByShop(ctx, productID, shopID); err != nil {
+		return err
+	}
+	s.ReindexProducts(ctx, []string{productID})
+	return nil
 }
 
 // UnPublishProduct hủy đăng tải sản phẩm
 func (s *productService) UnPublishProduct(ctx context.Context, productID string, shopID string) error {
-	return s.productRepo.UnPublishProductByShop(ctx, productID, shopID)
+	if err := s.productRepo.UnPublishProductByShop(ctx, productID, shopID); err != nil {
+		return err
+	}
+	s.ReindexProducts(ctx, []string{productID})
+	return nil
+}
+
+// UpdateStock sets the stock of a SKU of the shop (POS terminals, partner stock sync),
+// skuCode may be left out when the product has a single SKU
+func (s *productService) UpdateStock(ctx context.Context, productID string, shopID string, skuCode string, stock int) error {
+	if stock < 0 {
+		return ErrInvalidInput
+	}
+	err := s.uow.Do(ctx, func(tx *repo.Tx) error {
+		product, err := s.productRepo.WithTx(tx).FindProduct(ctx, productID)
+		if err != nil {
+			return err
+		}
+		if product.ProductShop != shopID {
+			return ErrNotFound
+		}
+		sr := s.skuRepo.WithTx(tx)
+		skus, err := sr.FindSkus(ctx, productID)
+		if err != nil {
+			return err
+		}
+		if skuCode == "" && len(skus) > 1 {
+			return ErrSkuRequired
+		}
+		var sku *model.SkuModel
+		for i := range skus {
+			if skus[i].SkuCode == skuCode || skuCode == "" {
+				sku = &skus[i]
+			}
+		}
+		if sku == nil {
+			return ErrNotFound
+		}
+		sku.Stock = stock
+		if err = sr.UpdateStock(ctx, sku.ID, shopID, stock); err != nil {
+			return err
+		}
+		// product_quantity is the total stock of the SKUs
+		price, discountPrice, quantity := variant.Summary(skus)
+		return sr.UpdateSummary(ctx, productID, price, discountPrice, quantity)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// DeleteProduct xóa sản phẩm, the type row and inventory are deleted with it
+func (s *productService) DeleteProduct(ctx context.Context, productID string) error {
+	principal, err := usercontext.GetPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	err = s.uow.Do(ctx, func(tx *repo.Tx) error {
+		r := s.productRepo.WithTx(tx)
+		product, err := r.FindProduct(ctx, productID)
+		if err != nil {
+			return err
+		}
+		// moderators may remove products of any shop
+		if product.ProductShop != principal.ShopId && !principal.Can(rbac.PermProductModerate) {
+			return ErrUnauthorized
+		}
+		return r.DeleteProduct(ctx, productID)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err == nil {
+		s.search.Remove(productID)
+		s.publishSearchChange(ctx, productID)
+	}
+	return err
 }
 
 // FindProduct tìm sản phẩm theo ID
 func (s *productService) FindProduct(ctx context.Context, productID string) (*model.ProductModel, error) {
-	return s.productRepo.FindProduct(ctx, productID)
+	product, err := s.productRepo.FindProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	products := []model.ProductModel{*product}
+	if err = s.withAttributes(ctx, products); err != nil {
+		return nil, err
+	}
+	product = &products[0]
+
+	if product.Options, err = s.skuRepo.FindOptions(ctx, productID); err != nil {
+		return nil, err
+	}
+	if product.Skus, err = s.skuRepo.FindSkus(ctx, productID); err != nil {
+		return nil, err
+	}
+	for i, sku := range product.Skus {
+		if i == 0 {
+			product.PriceRange = &model.PriceRange{Min: sku.Price, Max: sku.Price}
+		}
+		if sku.Price < product.PriceRange.Min {
+			product.PriceRange.Min = sku.Price
+		}
+		if sku.Price > product.PriceRange.Max {
+			product.PriceRange.Max = sku.Price
+		}
+	}
+	return product, nil
 }
 
 // FindAllProducts tìm tất cả sản phẩm theo tham số
 func (s *productService) FindAllProducts(ctx context.Context, params *model.ProductQueryParams) (*model.ProductResponse, error) {
-	return s.productRepo.FindAllProducts(ctx, params)
+	// a keyword goes to the search index, ranked by relevance
+	if strings.TrimSpace(params.Keyword) != "" {
+		return s.SearchProducts(ctx, params)
+	}
+	res, err := s.productRepo.FindAllProducts(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.withAttributes(ctx, res.Data); err != nil {
+		return nil, err
+	}
+	if err = s.withPriceRanges(ctx, res.Data); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 // FindAllDraftsForShop tìm tất cả sản phẩm nháp của một shop
@@ -344,7 +500,17 @@ func (s *productService) FindAllDraftsForShop(ctx context.Context, shopID string
 	}
 	offset := (page - 1) * limit
 
-	return s.productRepo.FindAllDraftsForShop(ctx, shopID, limit, offset)
+	products, err := s.productRepo.FindAllDraftsForShop(ctx, shopID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.withAttributes(ctx, products); err != nil {
+		return nil, err
+	}
+	if err = s.withPriceRanges(ctx, products); err != nil {
+		return nil, err
+	}
+	return products, nil
 }
 
 // FindAllPublishForShop tìm tất cả sản phẩm đã đăng tải của một shop
@@ -357,20 +523,91 @@ func (s *productService) FindAllPublishForShop(ctx context.Context, shopID strin
 	}
 	offset := (page - 1) * limit
 
-	return s.productRepo.FindAllPublishForShop(ctx, shopID, limit, offset)
+	products, err := s.productRepo.FindAllPublishForShop(ctx, shopID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.withAttributes(ctx, products); err != nil {
+		return nil, err
+	}
+	if err = s.withPriceRanges(ctx, products); err != nil {
+		return nil, err
+	}
+	return products, nil
 }
 
 // GetProductsByDiscount lấy sản phẩm sắp xếp theo giảm giá
 func (s *productService) GetProductsByDiscount(ctx context.Context, params *model.ProductQueryParams) (*model.ProductResponse, error) {
-	return s.productRepo.FindProductsByDiscount(ctx, params)
+	res, err := s.productRepo.FindProductsByDiscount(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.withAttributes(ctx, res.Data); err != nil {
+		return nil, err
+	}
+	if err = s.withPriceRanges(ctx, res.Data); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 // GetProductsBySelled lấy sản phẩm sắp xếp theo số lượng đã bán
 func (s *productService) GetProductsBySelled(ctx context.Context, params *model.ProductQueryParams) (*model.ProductResponse, error) {
-	return s.productRepo.FindProductsBySelled(ctx, params)
+	res, err := s.productRepo.FindProductsBySelled(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.withAttributes(ctx, res.Data); err != nil {
+		return nil, err
+	}
+	if err = s.withPriceRanges(ctx, res.Data); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
-// SearchProducts tìm kiếm sản phẩm theo từ khóa
-func (s *productService) SearchProducts(ctx context.Context, keyword string) ([]model.ProductModel, error) {
-	return s.productRepo.SearchProducts(ctx, keyword)
-} 
\ No newline at end of file
+// withAttributes fills the attributes of the products, with one query per product type
+func (s *productService) withAttributes(ctx context.Context, products []model.ProductModel) error {
+	idsByType := map[string][]string{}
+	for _, p := range products {
+		idsByType[p.ProductType] = append(idsByType[p.ProductType], p.ID)
+	}
+	for typeName, productIDs := range idsByType {
+		productType, err := producttype.Get(typeName)
+		if err != nil {
+			// a type that is no longer registered, its products are listed without attributes
+			continue
+		}
+		attrs, err := productType.Load(ctx, s.productRepo, productIDs)
+		if err != nil {
+			return err
+		}
+		for i := range products {
+			if products[i].ProductType == typeName {
+				products[i].Attributes = attrs[products[i].ID]
+			}
+		}
+	}
+	return nil
+} 
+
+// withPriceRanges fills the price range of the products from their SKUs, in one query
+func (s *productService) withPriceRanges(ctx context.Context, products []model.ProductModel) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	ranges, err := s.skuRepo.FindPriceRanges(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		if r, ok := ranges[products[i].ID]; ok {
+			products[i].PriceRange = &model.PriceRange{Min: r.Min, Max: r.Max}
+		}
+	}
+	return nil
+}
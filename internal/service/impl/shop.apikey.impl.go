@@ -0,0 +1,229 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/consts"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/apikey"
+	usercontext "go_ecommerce/internal/utils/context"
+	"go_ecommerce/internal/utils/rbac"
+	"go_ecommerce/pkg/response"
+
+	"go.uber.org/zap"
+)
+
+const (
+	apiKeyMaxActive     = 20
+	apiKeyMaxNameLength = 100
+	apiKeyMaxExpireDays = 365
+	// last-used is written at most this often per key and address
+	apiKeyTouchInterval = time.Minute
+)
+
+type sShopApiKey struct {
+	r     *database.Queries
+	roles service.IUserRole
+}
+
+func NewShopApiKeyImpl(r *database.Queries, roles service.IUserRole) *sShopApiKey {
+	return &sShopApiKey{
+		r:     r,
+		roles: roles,
+	}
+}
+
+// CreateKey issues a key for the shop, the scopes must be granted by the owner's roles
+func (s *sShopApiKey) CreateKey(ctx context.Context, in *model.CreateApiKeyInput) (codeResult int, out model.CreateApiKeyOutput, err error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" || len(name) > apiKeyMaxNameLength {
+		return response.ErrCodeParamInvalid, out, fmt.Errorf("name is required, at most %d characters", apiKeyMaxNameLength)
+	}
+	if in.ExpiresInDays < 0 || in.ExpiresInDays > apiKeyMaxExpireDays {
+		return response.ErrCodeParamInvalid, out, fmt.Errorf("expires_in_days must be between 0 and %d", apiKeyMaxExpireDays)
+	}
+	roles, err := s.roles.GetRoles(ctx, in.ShopId)
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	scopes := make([]string, 0, len(in.Scopes))
+	for _, scope := range in.Scopes {
+		if !apikey.IsScope(scope) {
+			return response.ErrCodeApiKeyScopeInvalid, out, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !rbac.Can(roles, scope) {
+			return response.ErrCodeApiKeyScopeInvalid, out, fmt.Errorf("scope %q is not granted to the account", scope)
+		}
+		if !containsString(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return response.ErrCodeApiKeyScopeInvalid, out, fmt.Errorf("at least one scope is required")
+	}
+	allowedIps, err := apikey.NormalizeAllowedIPs(in.AllowedIps)
+	if err != nil {
+		return response.ErrCodeParamInvalid, out, err
+	}
+	if len(allowedIps) > 1024 {
+		return response.ErrCodeParamInvalid, out, fmt.Errorf("too many allowed IPs")
+	}
+	active, err := s.r.CountActiveShopApiKeys(ctx, in.ShopId)
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	if active >= apiKeyMaxActive {
+		return response.ErrCodeApiKeyLimit, out, fmt.Errorf("revoke a key first, at most %d active keys", apiKeyMaxActive)
+	}
+
+	prefix, secret, key, err := apikey.Generate()
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	expiresAt := sql.NullTime{}
+	if in.ExpiresInDays > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().AddDate(0, 0, in.ExpiresInDays), Valid: true}
+	}
+	result, err := s.r.AddApiKey(ctx, database.AddApiKeyParams{
+		ShopID:        in.ShopId,
+		KeyName:       name,
+		KeyPrefix:     prefix,
+		KeyHash:       apikey.HashSecret(secret),
+		KeyScopes:     strings.Join(scopes, ","),
+		KeyAllowedIps: allowedIps,
+		KeyExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	keyId, err := result.LastInsertId()
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	row, err := s.r.GetApiKey(ctx, uint32(keyId))
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	out.ApiKeyOutput = toApiKeyOutput(row)
+	out.Key = key
+	global.Logger.Info("api key created", zap.Uint64("shop_id", in.ShopId), zap.String("prefix", prefix), zap.Strings("scopes", scopes))
+	return response.CodeSuccess, out, nil
+}
+
+func (s *sShopApiKey) ListKeys(ctx context.Context, shopId uint64) (codeResult int, out []model.ApiKeyOutput, err error) {
+	rows, err := s.r.ListShopApiKeys(ctx, shopId)
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	out = make([]model.ApiKeyOutput, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toApiKeyOutput(row))
+	}
+	return response.CodeSuccess, out, nil
+}
+
+// RevokeKey stops the key at once, the row stays for the audit
+func (s *sShopApiKey) RevokeKey(ctx context.Context, in *model.ApiKeyInput) (codeResult int, err error) {
+	revoked, err := s.r.RevokeApiKey(ctx, database.RevokeApiKeyParams{
+		KeyID:  in.KeyId,
+		ShopID: in.ShopId,
+	})
+	if err != nil {
+		return response.CodeFail, err
+	}
+	if revoked == 0 {
+		return response.ErrCodeApiKeyNotFound, fmt.Errorf("api key not found or already revoked")
+	}
+	global.Logger.Info("api key revoked", zap.Uint64("shop_id", in.ShopId), zap.Uint32("key_id", in.KeyId))
+	return response.CodeSuccess, nil
+}
+
+// Authenticate checks the secret, validity, owner state and IP allowlist of the key
+func (s *sShopApiKey) Authenticate(ctx context.Context, key string, clientIp string) (*usercontext.Principal, error) {
+	prefix, secret, ok := apikey.Parse(key)
+	if !ok {
+		return nil, apikey.ErrInvalidKey
+	}
+	row, err := s.r.GetApiKeyByPrefix(ctx, prefix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apikey.ErrInvalidKey
+	} else if err != nil {
+		return nil, err
+	}
+	if !apikey.MatchSecret(row.KeyHash, secret) || row.KeyRevokedAt.Valid {
+		return nil, apikey.ErrInvalidKey
+	}
+	if row.KeyExpiresAt.Valid && time.Now().After(row.KeyExpiresAt.Time) {
+		return nil, apikey.ErrInvalidKey
+	}
+	// a locked owner locks the keys of the shop as well
+	if row.UserState != consts.UserStateActivated {
+		return nil, apikey.ErrInvalidKey
+	}
+	if !apikey.AllowsIP(row.KeyAllowedIps, clientIp) {
+		return nil, apikey.ErrIPNotAllowed
+	}
+	roles, err := s.roles.GetRoles(ctx, row.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !row.KeyLastUsedAt.Valid || time.Since(row.KeyLastUsedAt.Time) > apiKeyTouchInterval || row.KeyLastUsedIp.String != clientIp {
+		err = s.r.TouchApiKey(ctx, database.TouchApiKeyParams{
+			KeyLastUsedIp: sql.NullString{String: clientIp, Valid: clientIp != ""},
+			KeyID:         row.KeyID,
+		})
+		if err != nil {
+			global.Logger.Error("touch api key failed", zap.Uint32("key_id", row.KeyID), zap.Error(err))
+		}
+	}
+	return &usercontext.Principal{
+		UserId:      row.ShopID,
+		UserAccount: row.UserAccount,
+		Roles:       roles,
+		ShopId:      strconv.FormatUint(row.ShopID, 10),
+		AuthLevel:   usercontext.AuthLevelPassword,
+		ApiKeyId:    row.KeyID,
+		Scopes:      apikey.SplitList(row.KeyScopes),
+	}, nil
+}
+
+func toApiKeyOutput(row database.PreGoShopApiKey9999) model.ApiKeyOutput {
+	out := model.ApiKeyOutput{
+		KeyId:      row.KeyID,
+		Name:       row.KeyName,
+		Prefix:     row.KeyPrefix,
+		Scopes:     apikey.SplitList(row.KeyScopes),
+		AllowedIps: apikey.SplitList(row.KeyAllowedIps),
+		LastUsedIp: row.KeyLastUsedIp.String,
+		CreatedAt:  row.KeyCreatedAt.Time,
+	}
+	if row.KeyLastUsedAt.Valid {
+		out.LastUsedAt = &row.KeyLastUsedAt.Time
+	}
+	if row.KeyExpiresAt.Valid {
+		out.ExpiresAt = &row.KeyExpiresAt.Time
+	}
+	if row.KeyRevokedAt.Valid {
+		out.RevokedAt = &row.KeyRevokedAt.Time
+	}
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
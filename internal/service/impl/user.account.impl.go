@@ -0,0 +1,362 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/consts"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/utils/accountdata"
+	"go_ecommerce/internal/utils/crypto"
+	"go_ecommerce/internal/utils/sendto"
+	"go_ecommerce/internal/utils/session"
+	"go_ecommerce/pkg/response"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDeletionGraceDays     = 14
+	defaultExportTTLHours        = 24
+	defaultExportCooldownMinutes = 60
+	exportBuildTimeout           = 5 * time.Minute
+	// deletions processed per sweep, the rest wait for the next one
+	deletionSweepBatch = 100
+)
+
+// exportIdentity is a linked social login in the export
+type exportIdentity struct {
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email"`
+	LinkedAt    time.Time  `json:"linked_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+func settingOrDefault(v int, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// RequestExport starts building the archive in the background, the user is mailed when it is ready
+func (s *sUserInfo) RequestExport(ctx context.Context, userId uint64) (codeResult int, out model.DataExportOutput, err error) {
+	cfg := global.Config.Account
+	cooldown := time.Duration(settingOrDefault(cfg.ExportCooldownMinutes, defaultExportCooldownMinutes)) * time.Minute
+	job, err := accountdata.Start(ctx, userId, cooldown)
+	if errors.Is(err, accountdata.ErrTooSoon) {
+		return response.ErrCodeExportTooSoon, out, err
+	}
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+
+	// the request context is gone once we return
+	go s.buildExport(userId, job)
+	return response.CodeSuccess, toDataExportOutput(job), nil
+}
+
+func (s *sUserInfo) GetExport(ctx context.Context, userId uint64) (codeResult int, out model.DataExportOutput, err error) {
+	job, found, err := accountdata.Get(ctx, userId)
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	if !found {
+		return response.ErrCodeExportNotReady, out, accountdata.ErrNotReady
+	}
+	return response.CodeSuccess, toDataExportOutput(job), nil
+}
+
+func (s *sUserInfo) DownloadExport(ctx context.Context, userId uint64) (codeResult int, archive []byte, err error) {
+	archive, err = accountdata.Archive(ctx, userId)
+	if errors.Is(err, accountdata.ErrNotReady) {
+		return response.ErrCodeExportNotReady, nil, err
+	}
+	if err != nil {
+		return response.CodeFail, nil, err
+	}
+	return response.CodeSuccess, archive, nil
+}
+
+func (s *sUserInfo) buildExport(userId uint64, job accountdata.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportBuildTimeout)
+	defer cancel()
+
+	sections, email, err := s.exportSections(ctx, userId)
+	var archive []byte
+	if err == nil {
+		archive, err = accountdata.Build(userId, sections, time.Now())
+	}
+	if err != nil {
+		global.Logger.Error("build data export failed", zap.Uint64("user_id", userId), zap.Error(err))
+		if errFail := accountdata.Fail(ctx, userId, job); errFail != nil {
+			global.Logger.Error("mark data export failed", zap.Uint64("user_id", userId), zap.Error(errFail))
+		}
+		return
+	}
+
+	ttl := time.Duration(settingOrDefault(global.Config.Account.ExportTTLHours, defaultExportTTLHours)) * time.Hour
+	job, err = accountdata.Finish(ctx, userId, job, archive, ttl)
+	if err != nil {
+		global.Logger.Error("store data export failed", zap.Uint64("user_id", userId), zap.Error(err))
+		return
+	}
+	global.Logger.Info("data export ready", zap.Uint64("user_id", userId), zap.Int("size", job.Size))
+	if email != "" {
+		sendto.SendEmail(ctx, accountdata.ExportReadyMail(email, *job.ExpiresAt))
+	}
+}
+
+// exportSections collects the personal data of the user, one file per section
+func (s *sUserInfo) exportSections(ctx context.Context, userId uint64) (sections []accountdata.Section, email string, err error) {
+	_, profile, err := s.GetInfoByUserId(ctx, userId)
+	if err != nil {
+		return nil, "", err
+	}
+	sections = append(sections, accountdata.Section{Name: "profile", Data: profile})
+
+	var history []model.LoginHistoryEntry
+	for page := 1; ; page++ {
+		_, rs, err := s.login.LoginHistory(ctx, &model.LoginHistoryInput{UserId: userId, Page: page, Limit: loginHistoryMaxLimit})
+		if err != nil {
+			return nil, "", err
+		}
+		history = append(history, rs.Entries...)
+		if len(rs.Entries) < loginHistoryMaxLimit {
+			break
+		}
+	}
+	sections = append(sections, accountdata.Section{Name: "login_history", Data: history})
+
+	_, sessions, err := s.login.ListSessions(ctx, &model.SessionInput{UserId: userId})
+	if err != nil {
+		return nil, "", err
+	}
+	sections = append(sections, accountdata.Section{Name: "sessions", Data: sessions})
+
+	rows, err := s.r.ListUserIdentities(ctx, uint32(userId))
+	if err != nil {
+		return nil, "", err
+	}
+	identities := make([]exportIdentity, 0, len(rows))
+	for _, row := range rows {
+		identity := exportIdentity{
+			Provider: row.IdentityProvider,
+			Subject:  row.IdentitySubject,
+			Email:    row.IdentityEmail.String,
+			LinkedAt: row.IdentityCreatedAt.Time,
+		}
+		if row.IdentityLastLoginAt.Valid {
+			identity.LastLoginAt = &row.IdentityLastLoginAt.Time
+		}
+		identities = append(identities, identity)
+	}
+	sections = append(sections, accountdata.Section{Name: "linked_accounts", Data: identities})
+
+	// credential ids and public keys are not personal data, name and usage are
+	passkeys, err := s.r.ListUserPasskeys(ctx, uint32(userId))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(passkeys) > 0 {
+		out := make([]model.PasskeyOutput, 0, len(passkeys))
+		for _, passkey := range passkeys {
+			out = append(out, toPasskeyOutput(passkey))
+		}
+		sections = append(sections, accountdata.Section{Name: "passkeys", Data: out})
+	}
+
+	_, roles, err := s.roles.ListUserRoles(ctx, userId)
+	if err != nil {
+		return nil, "", err
+	}
+	sections = append(sections, accountdata.Section{Name: "roles", Data: roles})
+
+	keys, err := s.r.ListShopApiKeys(ctx, userId)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(keys) > 0 {
+		apiKeys := make([]model.ApiKeyOutput, 0, len(keys))
+		for _, key := range keys {
+			apiKeys = append(apiKeys, toApiKeyOutput(key))
+		}
+		sections = append(sections, accountdata.Section{Name: "api_keys", Data: apiKeys})
+	}
+	// addresses, orders and reviews are added here once their tables exist
+	return sections, profile.UserEmail, nil
+}
+
+// DeleteAccount schedules the deletion and signs the user out everywhere
+func (s *sUserInfo) DeleteAccount(ctx context.Context, in *model.DeleteAccountInput) (codeResult int, out model.DeleteAccountOutput, err error) {
+	// 1. re-authenticate with the current password
+	userBase, err := s.r.GetOneUserInfo(ctx, in.UserAccount)
+	if err != nil {
+		return response.ErrCodeAuthFailed, out, err
+	}
+	if match, _ := crypto.MatchingPassword(userBase.UserPassword, in.Password, userBase.UserSalt); !match {
+		return response.ErrCodeAuthFailed, out, fmt.Errorf("current password is incorrect")
+	}
+
+	// 2. schedule
+	pending, err := s.r.GetUserDeletion(ctx, in.UserId)
+	if err == nil {
+		return response.ErrCodeDeletionPending, out, fmt.Errorf("account deletion is already scheduled for %s", pending.DeletionScheduledAt.UTC().Format(time.RFC3339))
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return response.CodeFail, out, err
+	}
+	graceDays := settingOrDefault(global.Config.Account.DeletionGraceDays, defaultDeletionGraceDays)
+	out.ScheduledAt = time.Now().UTC().AddDate(0, 0, graceDays).Truncate(time.Second)
+	err = s.r.AddUserDeletion(ctx, database.AddUserDeletionParams{
+		UserID:              in.UserId,
+		DeletionScheduledAt: out.ScheduledAt,
+		DeletionRequestedIp: in.ClientIp,
+	})
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+
+	// 3. sign out everywhere, signing in again cancels the deletion
+	if err = session.RevokeAll(ctx, in.UserId); err != nil {
+		global.Logger.Error("revoke sessions of deleted account failed", zap.Uint64("user_id", in.UserId), zap.Error(err))
+	}
+	if infoUser, err := s.r.GetUser(ctx, in.UserId); err == nil && infoUser.UserEmail.String != "" {
+		go sendto.SendEmail(context.Background(), accountdata.DeletionScheduledMail(infoUser.UserEmail.String, out.ScheduledAt))
+	}
+	global.Logger.Info("account deletion scheduled", zap.Uint64("user_id", in.UserId), zap.Time("scheduled_at", out.ScheduledAt))
+	return response.CodeSuccess, out, nil
+}
+
+// PurgeDeletedAccounts erases the accounts whose grace period is over
+func (s *sUserInfo) PurgeDeletedAccounts(ctx context.Context) (purged int, err error) {
+	due, err := s.r.ListDueUserDeletions(ctx, database.ListDueUserDeletionsParams{
+		DeletionScheduledAt: time.Now(),
+		Limit:               deletionSweepBatch,
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, item := range due {
+		if err := s.purgeAccount(ctx, item.UserID); err != nil {
+			global.Logger.Error("purge deleted account failed", zap.Uint64("user_id", item.UserID), zap.Error(err))
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeAccount removes credentials and linked data and anonymizes user_info.
+// The user_info row and the shop products stay, order records refer to them
+func (s *sUserInfo) purgeAccount(ctx context.Context, userId uint64) error {
+	infoUser, err := s.r.GetUser(ctx, userId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	tx, err := global.Mdbc.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := s.r.WithTx(tx)
+	if err = qtx.DeleteRecoveryCodes(ctx, uint32(userId)); err != nil {
+		return err
+	}
+	if err = qtx.RemoveAllTwoFactor(ctx, uint32(userId)); err != nil {
+		return err
+	}
+	if err = qtx.RemoveUserLoginHistory(ctx, sql.NullInt32{Int32: int32(userId), Valid: true}); err != nil {
+		return err
+	}
+	if err = qtx.RemoveShopApiKeys(ctx, userId); err != nil {
+		return err
+	}
+	if err = qtx.RemoveUserIdentities(ctx, uint32(userId)); err != nil {
+		return err
+	}
+	if err = qtx.RemoveUserPasskeys(ctx, uint32(userId)); err != nil {
+		return err
+	}
+	if err = qtx.RemoveUserBase(ctx, int32(userId)); err != nil {
+		return err
+	}
+	// the old account can be registered again
+	for _, purpose := range []string{consts.VERIFY_PURPOSE_REGISTER, consts.VERIFY_PURPOSE_RESET_PASSWORD} {
+		if infoUser.UserAccount == "" {
+			break
+		}
+		err = qtx.DeleteUserVerification(ctx, database.DeleteUserVerificationParams{
+			VerifyKeyHash: crypto.GetHash(infoUser.UserAccount),
+			VerifyPurpose: purpose,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	_, err = qtx.AnonymizeUser(ctx, database.AnonymizeUserParams{
+		UserAccount: fmt.Sprintf("deleted:%d", userId),
+		UserID:      userId,
+	})
+	if err != nil {
+		return err
+	}
+	unpublished, err := qtx.ListPublishedShopProductIDs(ctx, strconv.FormatUint(userId, 10))
+	if err != nil {
+		return err
+	}
+	if _, err = qtx.UnpublishShopProducts(ctx, strconv.FormatUint(userId, 10)); err != nil {
+		return err
+	}
+	if _, err = qtx.RemoveUserDeletion(ctx, userId); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	// the account is gone, the rest is cleanup
+	if err = session.RevokeAll(ctx, userId); err != nil {
+		global.Logger.Error("revoke sessions of purged account failed", zap.Uint64("user_id", userId), zap.Error(err))
+	}
+	if err = s.roles.RemoveAllRoles(ctx, userId); err != nil {
+		global.Logger.Error("remove roles of purged account failed", zap.Uint64("user_id", userId), zap.Error(err))
+	}
+	if err = accountdata.Remove(ctx, userId); err != nil {
+		global.Logger.Error("remove data export of purged account failed", zap.Uint64("user_id", userId), zap.Error(err))
+	}
+	// the unpublished products leave the search of every instance
+	s.products.ReindexProducts(ctx, unpublished)
+	global.Logger.Info("account purged", zap.Uint64("user_id", userId))
+	return nil
+}
+
+// cancelAccountDeletion: signing in during the grace period keeps the account
+func (s *sUserLogin) cancelAccountDeletion(ctx context.Context, userId uint64) {
+	rows, err := s.r.RemoveUserDeletion(ctx, userId)
+	if err != nil {
+		global.Logger.Error("cancel account deletion failed", zap.Uint64("user_id", userId), zap.Error(err))
+		return
+	}
+	if rows > 0 {
+		global.Logger.Info("account deletion cancelled by sign-in", zap.Uint64("user_id", userId))
+	}
+}
+
+func toDataExportOutput(job accountdata.Job) model.DataExportOutput {
+	return model.DataExportOutput{
+		Status:      job.Status,
+		RequestedAt: job.RequestedAt,
+		ReadyAt:     job.ReadyAt,
+		ExpiresAt:   job.ExpiresAt,
+		Size:        job.Size,
+	}
+}
@@ -0,0 +1,348 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/consts"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/accountdata"
+	"go_ecommerce/internal/utils/crypto"
+	"go_ecommerce/internal/utils/loginaudit"
+	"go_ecommerce/internal/utils/phone"
+	"go_ecommerce/internal/utils/rbac"
+	"go_ecommerce/internal/utils/session"
+	"go_ecommerce/pkg/response"
+
+	"go.uber.org/zap"
+)
+
+const (
+	adminUsersDefaultLimit = 20
+	adminUsersMaxLimit     = 100
+)
+
+type sUserAdmin struct {
+	r     *database.Queries
+	login *sUserLogin
+	roles service.IUserRole
+}
+
+func NewUserAdminImpl(r *database.Queries, roles service.IUserRole) *sUserAdmin {
+	return &sUserAdmin{
+		r:     r,
+		login: NewUserLoginImpl(r),
+		roles: roles,
+	}
+}
+
+// Login starts an admin session: password, admin access and a second factor are all required
+func (s *sUserAdmin) Login(ctx context.Context, in *model.LoginInput) (codeResult int, out model.LoginOutput, err error) {
+	userBase, codeResult, err := s.login.checkPassword(ctx, in)
+	if err != nil {
+		s.login.auditLogin(ctx, userBase.UserID, in.UserAccount, loginaudit.MethodAdmin, loginOutcome(codeResult), in.LoginClient)
+		return codeResult, out, err
+	}
+	roles, err := s.roles.GetRoles(ctx, uint64(userBase.UserID))
+	if err != nil {
+		return response.ErrCodeAuthFailed, out, err
+	}
+	if !rbac.Can(roles, rbac.PermAdminAccess) {
+		s.login.auditLogin(ctx, userBase.UserID, userBase.UserAccount, loginaudit.MethodAdmin, loginaudit.OutcomeFailed, in.LoginClient)
+		return response.ErrCodePermissionDenied, out, fmt.Errorf("account has no admin access")
+	}
+	isTwoFactorEnable, err := s.r.IsTwoFactorEnabled(ctx, uint32(userBase.UserID))
+	if err != nil {
+		return response.ErrCodeAuthFailed, out, err
+	}
+	if isTwoFactorEnable == 0 {
+		return response.ErrCodeAdminTwoFactorRequired, out, fmt.Errorf("enable two-factor authentication before using the admin API")
+	}
+	codeResult, out, err = s.login.startLoginChallenge(ctx, userBase, true)
+	if err == nil {
+		s.login.auditLogin(ctx, userBase.UserID, userBase.UserAccount, loginaudit.MethodAdmin, loginaudit.OutcomeChallenge, in.LoginClient)
+	}
+	return codeResult, out, err
+}
+
+// RegisterUser creates an account on behalf of a user, it stays Not Activated until ActivateUser
+func (s *sUserAdmin) RegisterUser(ctx context.Context, in *model.AdminRegisterUserInput) (codeResult int, out model.UserInfoOutput, err error) {
+	account, err := normalizeAccount(in.UserAccount)
+	if err != nil || account == "" {
+		return response.ErrCodeParamInvalid, out, fmt.Errorf("user account is not valid")
+	}
+	if len(in.UserPassword) < consts.PASSWORD_MIN_LENGTH {
+		return response.ErrCodeParamInvalid, out, fmt.Errorf("password must be at least %d characters", consts.PASSWORD_MIN_LENGTH)
+	}
+	exists, err := s.r.CheckUserBaseExists(ctx, account)
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	if exists > 0 {
+		return response.ErrCodeUserHassExits, out, fmt.Errorf("user has already registered")
+	}
+	passwordHash, err := crypto.HashPassword(in.UserPassword)
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	nickname := strings.TrimSpace(in.UserNickname)
+	if nickname == "" {
+		nickname = account
+	}
+	userEmail := sql.NullString{String: account, Valid: true}
+	userMobile := sql.NullString{String: "", Valid: true}
+	if phone.IsPhone(account) {
+		userEmail = sql.NullString{}
+		userMobile = sql.NullString{String: account, Valid: true}
+	}
+
+	tx, err := global.Mdbc.BeginTx(ctx, nil)
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	defer tx.Rollback()
+	qtx := s.r.WithTx(tx)
+	newUserBase, err := qtx.AddUserBase(ctx, database.AddUserBaseParams{
+		UserAccount:  account,
+		UserPassword: passwordHash,
+	})
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	userId, err := newUserBase.LastInsertId()
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	_, err = qtx.AddUserHaveUserId(ctx, database.AddUserHaveUserIdParams{
+		UserID:               uint64(userId),
+		UserAccount:          account,
+		UserNickname:         sql.NullString{String: nickname, Valid: true},
+		UserAvatar:           sql.NullString{String: "", Valid: true},
+		UserState:            consts.UserStateNotActivated,
+		UserMobile:           userMobile,
+		UserGender:           sql.NullInt16{Int16: 0, Valid: true},
+		UserBirthday:         sql.NullTime{Time: time.Time{}, Valid: false},
+		UserEmail:            userEmail,
+		UserIsAuthentication: 1,
+	})
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	if err = tx.Commit(); err != nil {
+		return response.CodeFail, out, err
+	}
+	return s.FindOneUser(ctx, uint64(userId))
+}
+
+// FindUsers searches accounts by account or nickname, an empty keyword lists every account
+func (s *sUserAdmin) FindUsers(ctx context.Context, in *model.FindUsersInput) (codeResult int, out model.UserListOutput, err error) {
+	out.Page, out.Limit = in.Page, in.Limit
+	if out.Page < 1 {
+		out.Page = 1
+	}
+	if out.Limit < 1 {
+		out.Limit = adminUsersDefaultLimit
+	} else if out.Limit > adminUsersMaxLimit {
+		out.Limit = adminUsersMaxLimit
+	}
+	offset := int32((out.Page - 1) * out.Limit)
+
+	var users []database.PreGoAccUserInfo9999
+	keyword := strings.TrimSpace(in.Keyword)
+	if keyword == "" {
+		users, err = s.r.ListUsers(ctx, database.ListUsersParams{Limit: int32(out.Limit), Offset: offset})
+	} else {
+		pattern := "%" + escapeLike(keyword) + "%"
+		users, err = s.r.FindUsers(ctx, database.FindUsersParams{
+			UserAccount:  pattern,
+			UserNickname: sql.NullString{String: pattern, Valid: true},
+			Limit:        int32(out.Limit),
+			Offset:       offset,
+		})
+	}
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	out.Users = make([]model.UserInfoOutput, 0, len(users))
+	for _, user := range users {
+		out.Users = append(out.Users, toUserInfoOutput(user))
+	}
+	return response.CodeSuccess, out, nil
+}
+
+func (s *sUserAdmin) FindOneUser(ctx context.Context, userId uint64) (codeResult int, out model.UserInfoOutput, err error) {
+	user, err := s.r.GetUser(ctx, userId)
+	if err != nil {
+		return userLookupCode(err), out, err
+	}
+	return response.CodeSuccess, toUserInfoOutput(user), nil
+}
+
+// ActivateUser: Not Activated -> Activated
+func (s *sUserAdmin) ActivateUser(ctx context.Context, in *model.AdminUserInput) (codeResult int, out model.UserInfoOutput, err error) {
+	return s.setUserState(ctx, in, consts.UserStateActivated, consts.UserStateNotActivated)
+}
+
+// LockUser: Activated / Not Activated -> Locked, every session of the user is revoked
+func (s *sUserAdmin) LockUser(ctx context.Context, in *model.AdminUserInput) (codeResult int, out model.UserInfoOutput, err error) {
+	if in.UserId == in.AdminId {
+		return response.ErrCodeParamInvalid, out, fmt.Errorf("an admin can't lock its own account")
+	}
+	codeResult, out, err = s.setUserState(ctx, in, consts.UserStateLocked, consts.UserStateActivated, consts.UserStateNotActivated)
+	if err != nil {
+		return codeResult, out, err
+	}
+	if err = session.RevokeAll(ctx, in.UserId); err != nil {
+		return response.ErrCodeSessionFailed, out, err
+	}
+	return codeResult, out, nil
+}
+
+// UnlockUser: Locked -> Activated
+func (s *sUserAdmin) UnlockUser(ctx context.Context, in *model.AdminUserInput) (codeResult int, out model.UserInfoOutput, err error) {
+	return s.setUserState(ctx, in, consts.UserStateActivated, consts.UserStateLocked)
+}
+
+// setUserState moves the user to state to, only from one of the from states
+func (s *sUserAdmin) setUserState(ctx context.Context, in *model.AdminUserInput, to uint8, from ...uint8) (codeResult int, out model.UserInfoOutput, err error) {
+	if codeResult, err = s.checkManage(ctx, in); err != nil {
+		return codeResult, out, err
+	}
+	user, err := s.r.GetUser(ctx, in.UserId)
+	if err != nil {
+		return userLookupCode(err), out, err
+	}
+	allowed := false
+	for _, state := range from {
+		allowed = allowed || user.UserState == state
+	}
+	if !allowed {
+		return response.ErrCodeUserStateInvalid, out, fmt.Errorf("user state %d can't change to %d", user.UserState, to)
+	}
+	// the current state is part of the update, a concurrent change makes it a no-op
+	changed, err := s.r.UpdateUserState(ctx, database.UpdateUserStateParams{
+		UserState:   to,
+		UserID:      in.UserId,
+		UserState_2: user.UserState,
+	})
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	if changed == 0 {
+		return response.ErrCodeUserStateInvalid, out, fmt.Errorf("user state has changed, try again")
+	}
+	global.Logger.Info("user state changed", zap.Uint64("user_id", in.UserId), zap.Uint64("admin_id", in.AdminId),
+		zap.Uint8("from", user.UserState), zap.Uint8("to", to))
+	return s.FindOneUser(ctx, in.UserId)
+}
+
+// checkManage refuses to act on a user whose roles the admin does not outrank, see rbac.CanManage
+func (s *sUserAdmin) checkManage(ctx context.Context, in *model.AdminUserInput) (codeResult int, err error) {
+	adminRoles, err := s.roles.GetRoles(ctx, in.AdminId)
+	if err != nil {
+		return response.CodeFail, err
+	}
+	userRoles, err := s.roles.GetRoles(ctx, in.UserId)
+	if err != nil {
+		return response.CodeFail, err
+	}
+	if !rbac.CanManage(adminRoles, userRoles) {
+		return response.ErrCodePermissionDenied, fmt.Errorf("user %d has roles that admin %d can't manage", in.UserId, in.AdminId)
+	}
+	return response.CodeSuccess, nil
+}
+
+// RemoveUser deletes the account, its 2FA methods, roles and data export, and revokes its sessions
+func (s *sUserAdmin) RemoveUser(ctx context.Context, in *model.AdminUserInput) (codeResult int, err error) {
+	if in.UserId == in.AdminId {
+		return response.ErrCodeParamInvalid, fmt.Errorf("an admin can't remove its own account")
+	}
+	if codeResult, err = s.checkManage(ctx, in); err != nil {
+		return codeResult, err
+	}
+	if _, err = s.r.GetUser(ctx, in.UserId); err != nil {
+		return userLookupCode(err), err
+	}
+
+	tx, err := global.Mdbc.BeginTx(ctx, nil)
+	if err != nil {
+		return response.CodeFail, err
+	}
+	defer tx.Rollback()
+	qtx := s.r.WithTx(tx)
+	if err = qtx.DeleteRecoveryCodes(ctx, uint32(in.UserId)); err != nil {
+		return response.CodeFail, err
+	}
+	if err = qtx.RemoveAllTwoFactor(ctx, uint32(in.UserId)); err != nil {
+		return response.CodeFail, err
+	}
+	if err = qtx.RemoveUserLoginHistory(ctx, sql.NullInt32{Int32: int32(in.UserId), Valid: true}); err != nil {
+		return response.CodeFail, err
+	}
+	if err = qtx.RemoveShopApiKeys(ctx, in.UserId); err != nil {
+		return response.CodeFail, err
+	}
+	if err = qtx.RemoveUserIdentities(ctx, uint32(in.UserId)); err != nil {
+		return response.CodeFail, err
+	}
+	if err = qtx.RemoveUserPasskeys(ctx, uint32(in.UserId)); err != nil {
+		return response.CodeFail, err
+	}
+	if _, err = qtx.RemoveUserDeletion(ctx, in.UserId); err != nil {
+		return response.CodeFail, err
+	}
+	if err = qtx.RemoveUser(ctx, in.UserId); err != nil {
+		return response.CodeFail, err
+	}
+	if err = qtx.RemoveUserBase(ctx, int32(in.UserId)); err != nil {
+		return response.CodeFail, err
+	}
+	if err = tx.Commit(); err != nil {
+		return response.CodeFail, err
+	}
+
+	// the account is gone, the rest is cleanup
+	if err = session.RevokeAll(ctx, in.UserId); err != nil {
+		global.Logger.Error("revoke sessions of removed user failed", zap.Uint64("user_id", in.UserId), zap.Error(err))
+	}
+	if err = s.roles.RemoveAllRoles(ctx, in.UserId); err != nil {
+		global.Logger.Error("remove roles of removed user failed", zap.Uint64("user_id", in.UserId), zap.Error(err))
+	}
+	if err = accountdata.Remove(ctx, in.UserId); err != nil {
+		global.Logger.Error("remove data export of removed user failed", zap.Uint64("user_id", in.UserId), zap.Error(err))
+	}
+	global.Logger.Info("user removed", zap.Uint64("user_id", in.UserId), zap.Uint64("admin_id", in.AdminId))
+	return response.CodeSuccess, nil
+}
+
+// escapeLike makes the keyword match literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func toUserInfoOutput(user database.PreGoAccUserInfo9999) model.UserInfoOutput {
+	out := model.UserInfoOutput{
+		UserId:           user.UserID,
+		UserAccount:      user.UserAccount,
+		UserNickname:     user.UserNickname.String,
+		UserAvatar:       user.UserAvatar.String,
+		UserState:        user.UserState,
+		UserMobile:       user.UserMobile.String,
+		UserGender:       user.UserGender.Int16,
+		UserEmail:        user.UserEmail.String,
+		IsAuthentication: user.UserIsAuthentication,
+		CreatedAt:        user.CreatedAt.Time,
+		UpdatedAt:        user.UpdatedAt.Time,
+	}
+	if user.UserBirthday.Valid {
+		birthday := user.UserBirthday.Time
+		out.UserBirthday = &birthday
+	}
+	return out
+}
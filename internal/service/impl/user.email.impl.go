@@ -0,0 +1,209 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/consts"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/utils"
+	"go_ecommerce/internal/utils/cache"
+	"go_ecommerce/internal/utils/crypto"
+	"go_ecommerce/internal/utils/otppolicy"
+	"go_ecommerce/internal/utils/random"
+	"go_ecommerce/internal/utils/sendto"
+	"go_ecommerce/internal/utils/session"
+	"go_ecommerce/pkg/response"
+
+	"go.uber.org/zap"
+)
+
+// pendingEmailChange is stored in redis between RequestChangeEmail and ConfirmChangeEmail
+type pendingEmailChange struct {
+	NewEmail string `json:"new_email"`
+	Otp      string `json:"otp"`
+}
+
+func getChangeEmailKey(userId uint64) string {
+	return utils.GetUserPurposeKey(crypto.GetHash(strconv.FormatUint(userId, 10)), consts.VERIFY_PURPOSE_CHANGE_EMAIL)
+}
+
+// RequestChangeEmail sends an OTP to the new address, nothing changes until it is confirmed
+func (s *sUserLogin) RequestChangeEmail(ctx context.Context, in *model.ChangeEmailInput) (codeResult int, err error) {
+	newEmail := strings.ToLower(strings.TrimSpace(in.NewEmail))
+	if addr, err := mail.ParseAddress(newEmail); err != nil || addr.Address != newEmail {
+		return response.ErrCodeParamInvalid, fmt.Errorf("new email is not valid")
+	}
+	if newEmail == strings.ToLower(in.UserAccount) {
+		return response.ErrCodeParamInvalid, fmt.Errorf("new email is the current email")
+	}
+
+	// 1. re-authenticate with the current password
+	userBase, err := s.r.GetOneUserInfo(ctx, in.UserAccount)
+	if err != nil {
+		return response.ErrCodeAuthFailed, err
+	}
+	if match, _ := crypto.MatchingPassword(userBase.UserPassword, in.Password, userBase.UserSalt); !match {
+		return response.ErrCodeAuthFailed, fmt.Errorf("current password is incorrect")
+	}
+
+	// 2. new email must be free
+	userFound, err := s.r.CheckUserBaseExists(ctx, newEmail)
+	if err != nil {
+		return response.ErrCodeUserHassExits, err
+	}
+	if userFound > 0 {
+		return response.ErrCodeUserHassExits, fmt.Errorf("email is already used by another account")
+	}
+
+	// 3. resend cooldown / lock
+	policyKey := strconv.FormatUint(in.UserId, 10)
+	if err = otppolicy.ChangeEmail.CheckSend(ctx, policyKey); err != nil {
+		return otpPolicyCode(err, response.ErrInvalidOtp), err
+	}
+
+	// 4. save otp in redis and the verify record with its purpose
+	otpNew := strconv.Itoa(random.GenerateSixDigiOtp())
+	pendingJson, err := json.Marshal(pendingEmailChange{NewEmail: newEmail, Otp: otpNew})
+	if err != nil {
+		return response.ErrInvalidOtp, err
+	}
+	err = global.Rdb.SetEx(ctx, getChangeEmailKey(in.UserId), pendingJson, time.Duration(consts.TIME_OTP_CHANGE_EMAIL)*time.Minute).Err()
+	if err != nil {
+		return response.ErrInvalidOtp, err
+	}
+	_, err = s.r.InsertOTPVerify(ctx, database.InsertOTPVerifyParams{
+		VerifyOtp:     otpNew,
+		VerifyKey:     newEmail,
+		VerifyKeyHash: crypto.GetHash(newEmail),
+		VerifyType:    sql.NullInt32{Int32: int32(consts.EMAIL), Valid: true},
+		VerifyPurpose: consts.VERIFY_PURPOSE_CHANGE_EMAIL,
+	})
+	if err != nil {
+		return response.ErrInvalidOtp, err
+	}
+
+	// 5. send otp to the new address
+	if err = sendto.SendTextEmailOtp([]string{newEmail}, os.Getenv("SENDER_EMAIL"), otpNew); err != nil {
+		return response.ErrSendEmailOtp, err
+	}
+	return response.CodeSuccess, nil
+}
+
+// ConfirmChangeEmail checks the OTP and updates user_base and user_info in one transaction,
+// the login of a phone or provider account stays the same
+func (s *sUserLogin) ConfirmChangeEmail(ctx context.Context, in *model.ConfirmChangeEmailInput) (codeResult int, out model.ChangeEmailOutput, err error) {
+	// 1. lock check
+	policyKey := strconv.FormatUint(in.UserId, 10)
+	if err = otppolicy.ChangeEmail.CheckVerify(ctx, policyKey, in.ClientIp); err != nil {
+		return otpPolicyCode(err, response.ErrInvalidOtp), out, err
+	}
+
+	// 2. load pending change and check otp
+	keyPending := getChangeEmailKey(in.UserId)
+	var pending pendingEmailChange
+	if err = cache.GetCache(ctx, keyPending, &pending); err != nil {
+		return response.ErrCodeOtpNotExists, out, fmt.Errorf("no pending email change or OTP is expired")
+	}
+	if !otpMatch(pending.Otp, in.VerifyCode) {
+		codeResult, err = otpFailed(ctx, otppolicy.ChangeEmail, policyKey, in.ClientIp, response.ErrInvalidOtp, fmt.Errorf("OTP not match"))
+		return codeResult, out, err
+	}
+	verifyParams := database.GetValidOTPParams{VerifyKeyHash: crypto.GetHash(pending.NewEmail), VerifyPurpose: consts.VERIFY_PURPOSE_CHANGE_EMAIL}
+	infoOTP, err := s.r.GetValidOTP(ctx, verifyParams)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !otpMatch(infoOTP.VerifyOtp, in.VerifyCode)) {
+		return response.ErrCodeOtpNotExists, out, fmt.Errorf("change email OTP not found")
+	} else if err != nil {
+		return response.ErrInvalidOtp, out, err
+	}
+
+	// 3. update both tables atomically
+	tx, err := global.Mdbc.BeginTx(ctx, nil)
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	defer tx.Rollback()
+	qtx := s.r.WithTx(tx)
+	infoUser, err := qtx.GetUser(ctx, in.UserId)
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+
+	// only an email account logs in with its email, a phone or provider account keeps its
+	// login and changes the contact email alone
+	newAccount := infoUser.UserAccount
+	if isEmailAccount(infoUser.UserAccount) {
+		newAccount = pending.NewEmail
+		err = qtx.UpdateUserAccount(ctx, database.UpdateUserAccountParams{
+			UserAccount: newAccount,
+			UserID:      int32(in.UserId),
+		})
+		if err != nil {
+			// unique key: someone registered the address in the meantime
+			return response.ErrCodeUserHassExits, out, err
+		}
+	}
+	err = qtx.UpdateUserInfoEmail(ctx, database.UpdateUserInfoEmailParams{
+		UserAccount: newAccount,
+		UserEmail:   sql.NullString{String: pending.NewEmail, Valid: true},
+		UserID:      in.UserId,
+	})
+	if err != nil {
+		return response.ErrCodeUserHassExits, out, err
+	}
+	if err = qtx.UpdateUserVerificationStatus(ctx, database.UpdateUserVerificationStatusParams(verifyParams)); err != nil {
+		return response.CodeFail, out, err
+	}
+	if newAccount != infoUser.UserAccount {
+		// the old address is free now, its verified REGISTER record must not create an account
+		err = qtx.DeleteUserVerification(ctx, database.DeleteUserVerificationParams{
+			VerifyKeyHash: crypto.GetHash(infoUser.UserAccount),
+			VerifyPurpose: consts.VERIFY_PURPOSE_REGISTER,
+		})
+		if err != nil {
+			return response.CodeFail, out, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return response.CodeFail, out, err
+	}
+
+	// 4. cleanup and refresh the user-info cached in every session
+	global.Rdb.Del(ctx, keyPending)
+	otppolicy.ChangeEmail.Reset(ctx, policyKey)
+	if err := s.refreshSessionUserInfo(ctx, in.UserId); err != nil {
+		global.Logger.Error("refresh session user info failed", zap.Uint64("user_id", in.UserId), zap.Error(err))
+	}
+
+	out.UserAccount = newAccount
+	return response.CodeSuccess, out, nil
+}
+
+// isEmailAccount tells an email login from a phone number (E.164) or an OIDC "provider:sub" account
+func isEmailAccount(account string) bool {
+	addr, err := mail.ParseAddress(account)
+	return err == nil && addr.Address == account
+}
+
+// refreshSessionUserInfo rewrites the user_info JSON cached under each subToken
+func (s *sUserLogin) refreshSessionUserInfo(ctx context.Context, userId uint64) error {
+	infoUser, err := s.r.GetUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	infoUserJson, err := json.Marshal(infoUser)
+	if err != nil {
+		return err
+	}
+	return session.RefreshUserInfo(ctx, userId, infoUserJson)
+}
@@ -0,0 +1,122 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/phone"
+	"go_ecommerce/internal/utils/profile"
+	"go_ecommerce/pkg/response"
+
+	"go.uber.org/zap"
+)
+
+type sUserInfo struct {
+	r        *database.Queries
+	login    *sUserLogin
+	roles    service.IUserRole
+	products service.IProductManagement
+}
+
+func NewUserInfoImpl(r *database.Queries, roles service.IUserRole, products service.IProductManagement) *sUserInfo {
+	return &sUserInfo{
+		r:        r,
+		login:    NewUserLoginImpl(r),
+		roles:    roles,
+		products: products,
+	}
+}
+
+func (s *sUserInfo) GetInfoByUserId(ctx context.Context, userId uint64) (codeResult int, out model.UserInfoOutput, err error) {
+	user, err := s.r.GetUser(ctx, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return response.ErrCodeUserNotFound, out, err
+	}
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	return response.CodeSuccess, toUserInfoOutput(user), nil
+}
+
+// UpdateUserInfo applies a partial profile update and refreshes the user-info cached in every session
+func (s *sUserInfo) UpdateUserInfo(ctx context.Context, in *model.UpdateUserInfoInput) (codeResult int, out model.UserInfoOutput, err error) {
+	// 1. validate before touching the row
+	params, err := s.profileParams(ctx, in)
+	if errors.Is(err, sql.ErrNoRows) {
+		return response.ErrCodeUserNotFound, out, err
+	}
+	if err != nil {
+		return response.ErrCodeParamInvalid, out, err
+	}
+
+	// 2. write, user_email is owned by the change-email flow and not touched here
+	if _, err = s.r.EditUserByUserId(ctx, params); err != nil {
+		return response.CodeFail, out, err
+	}
+	if err = s.login.refreshSessionUserInfo(ctx, in.UserId); err != nil {
+		global.Logger.Error("refresh session user info failed", zap.Uint64("user_id", in.UserId), zap.Error(err))
+	}
+	return s.GetInfoByUserId(ctx, in.UserId)
+}
+
+// profileParams merges the validated input into the current profile
+func (s *sUserInfo) profileParams(ctx context.Context, in *model.UpdateUserInfoInput) (params database.EditUserByUserIdParams, err error) {
+	user, err := s.r.GetUser(ctx, in.UserId)
+	if err != nil {
+		return params, err
+	}
+	params = database.EditUserByUserIdParams{
+		UserNickname: user.UserNickname,
+		UserAvatar:   user.UserAvatar,
+		UserMobile:   user.UserMobile,
+		UserGender:   user.UserGender,
+		UserBirthday: user.UserBirthday,
+		UserID:       user.UserID,
+	}
+
+	if in.UserNickname != nil {
+		nickname, err := profile.Nickname(*in.UserNickname)
+		if err != nil {
+			return params, err
+		}
+		params.UserNickname = sql.NullString{String: nickname, Valid: true}
+	}
+	if in.UserAvatar != nil {
+		avatar, err := profile.Avatar(*in.UserAvatar)
+		if err != nil {
+			return params, err
+		}
+		params.UserAvatar = sql.NullString{String: avatar, Valid: avatar != ""}
+	}
+	if in.UserGender != nil {
+		gender, err := profile.Gender(*in.UserGender)
+		if err != nil {
+			return params, err
+		}
+		params.UserGender = sql.NullInt16{Int16: gender, Valid: true}
+	}
+	if in.UserBirthday != nil {
+		birthday, err := profile.Birthday(*in.UserBirthday, time.Now())
+		if err != nil {
+			return params, err
+		}
+		params.UserBirthday = sql.NullTime{Time: birthday, Valid: !birthday.IsZero()}
+	}
+	if in.UserMobile != nil {
+		params.UserMobile = sql.NullString{}
+		if *in.UserMobile != "" {
+			mobile, err := phone.Normalize(*in.UserMobile)
+			if err != nil {
+				return params, err
+			}
+			params.UserMobile = sql.NullString{String: mobile, Valid: true}
+		}
+	}
+	return params, nil
+}
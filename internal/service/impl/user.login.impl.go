@@ -2,9 +2,10 @@ package impl
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
 	"os"
 	"strconv"
 	"strings"
@@ -17,13 +18,26 @@ import (
 	"go_ecommerce/internal/model"
 	"go_ecommerce/internal/utils"
 	"go_ecommerce/internal/utils/auth"
+	"go_ecommerce/internal/utils/cache"
+	usercontext "go_ecommerce/internal/utils/context"
 	"go_ecommerce/internal/utils/crypto"
+	"go_ecommerce/internal/utils/loginaudit"
+	"go_ecommerce/internal/utils/otppolicy"
+	"go_ecommerce/internal/utils/phone"
 	"go_ecommerce/internal/utils/random"
 	"go_ecommerce/internal/utils/sendto"
+	"go_ecommerce/internal/utils/session"
+	"go_ecommerce/internal/utils/totp"
 	"go_ecommerce/pkg/response"
 
-	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	accessTokenTTL  = 30 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
 )
 
 type sUserLogin struct {
@@ -43,93 +57,343 @@ func (s *sUserLogin) IsTwoFactorEnabled(ctx context.Context, userId int) (codeRe
 	return 200, true, nil
 }
 
-func (s *sUserLogin) SetupTwoFactorAuth(ctx context.Context, in *model.SetupTwoFactorAuthInput) (codeResult int, err error) {
+func (s *sUserLogin) SetupTwoFactorAuth(ctx context.Context, in *model.SetupTwoFactorAuthInput) (codeResult int, out model.SetupTwoFactorAuthOutput, err error) {
 	// Logic
 	// 1. Check isTwoFactorEnabled -> true return
 	isTwoFactorAuth, err := s.r.IsTwoFactorEnabled(ctx, in.UserId)
 	if err != nil {
-		return response.ErrCodeTwoFactorAuthSetupFailed, err
+		return response.ErrCodeTwoFactorAuthSetupFailed, out, err
 	}
 	if isTwoFactorAuth > 0 {
-		return response.ErrCodeTwoFactorAuthSetupFailed, fmt.Errorf("Two-factor authentication is already enabled")
+		return response.ErrCodeTwoFactorAuthSetupFailed, out, fmt.Errorf("Two-factor authentication is already enabled")
+	}
+	authType := twoFactorAuthType(in.TwoFactorAuthType)
+	out.TwoFactorAuthType = string(authType)
+	if authType != database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeAPP {
+		// resend cooldown, checked before the pending setup is replaced
+		if err = otppolicy.SetupTwoFactor.CheckSend(ctx, strconv.Itoa(int(in.UserId))); err != nil {
+			return otpPolicyCode(err, response.ErrCodeTwoFactorAuthSetupFailed), out, err
+		}
 	}
-	// 2. crate new type Authe
-	err = s.r.EnableTwoFactorTypeEmail(ctx, database.EnableTwoFactorTypeEmailParams{
+
+	// 2. remove pending (not yet verified) setup of the same type
+	err = s.r.RemoveTwoFactor(ctx, database.RemoveTwoFactorParams{
 		UserID:            in.UserId,
-		TwoFactorAuthType: database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeEMAIL,
-		TwoFactorEmail:    sql.NullString{String: in.TwoFactorEmail, Valid: true},
+		TwoFactorAuthType: authType,
 	})
 	if err != nil {
-		return response.ErrCodeTwoFactorAuthSetupFailed, err
+		return response.ErrCodeTwoFactorAuthSetupFailed, out, err
 	}
 
-	// 3. send otp to in.TwoFactorEmail
-	keyUserTwoFator := crypto.GetHash("2fa:" + strconv.Itoa(int(in.UserId)))
-	go global.Rdb.Set(ctx, keyUserTwoFator, "123456", time.Duration(consts.TIME_OTP_REGISTER)*time.Minute).Err()
-	// if err != nil {
-	// 	return response.ErrCodeTwoFactorAuthSetupFailed, err
-	// }
-	return response.CodeSuccess, nil
+	switch authType {
+	case database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeAPP:
+		// 3. generate TOTP secret, user scans QR code in authenticator app
+		infoUser, err := s.r.GetUser(ctx, uint64(in.UserId))
+		if err != nil {
+			return response.ErrCodeTwoFactorAuthSetupFailed, out, err
+		}
+		key, err := totp.GenerateKey(consts.TOTP_ISSUER, infoUser.UserAccount)
+		if err != nil {
+			return response.ErrCodeTwoFactorAuthSetupFailed, out, err
+		}
+		err = s.r.EnableTwoFactorTypeApp(ctx, database.EnableTwoFactorTypeAppParams{
+			UserID:              in.UserId,
+			TwoFactorAuthType:   authType,
+			TwoFactorAuthSecret: key.Secret,
+		})
+		if err != nil {
+			return response.ErrCodeTwoFactorAuthSetupFailed, out, err
+		}
+		out.Secret = key.Secret
+		out.OtpAuthURL = key.URL
+		out.QRCode = key.QRCode
+		return response.CodeSuccess, out, nil
+
+	case database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeEMAIL:
+		// 3. crate new type Authe
+		err = s.r.EnableTwoFactorTypeEmail(ctx, database.EnableTwoFactorTypeEmailParams{
+			UserID:            in.UserId,
+			TwoFactorAuthType: authType,
+			TwoFactorEmail:    sql.NullString{String: in.TwoFactorEmail, Valid: true},
+		})
+		if err != nil {
+			return response.ErrCodeTwoFactorAuthSetupFailed, out, err
+		}
+
+		// 4. send otp to in.TwoFactorEmail
+		otpNew := strconv.Itoa(random.GenerateSixDigiOtp())
+		keyUserTwoFator := getTwoFactorSetupKey(in.UserId, authType)
+		err = global.Rdb.SetEx(ctx, keyUserTwoFator, otpNew, time.Duration(consts.TIME_2FA_OTP_REGISTER)*time.Minute).Err()
+		if err != nil {
+			return response.ErrCodeTwoFactorAuthSetupFailed, out, err
+		}
+		go sendto.SendTextEmailOtp([]string{in.TwoFactorEmail}, os.Getenv("SENDER_EMAIL"), otpNew)
+		return response.CodeSuccess, out, nil
+
+	case database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeSMS:
+		// 3. crate new type Authe with the phone in E.164
+		phoneNumber, err := phone.Normalize(in.TwoFactorPhone)
+		if err != nil {
+			return response.ErrCodeTwoFactorAuthSetupFailed, out, err
+		}
+		err = s.r.EnableTwoFactorTypeSms(ctx, database.EnableTwoFactorTypeSmsParams{
+			UserID:            in.UserId,
+			TwoFactorAuthType: authType,
+			TwoFactorPhone:    sql.NullString{String: phoneNumber, Valid: true},
+		})
+		if err != nil {
+			return response.ErrCodeTwoFactorAuthSetupFailed, out, err
+		}
+
+		// 4. send otp to the phone
+		otpNew := strconv.Itoa(random.GenerateSixDigiOtp())
+		keyUserTwoFator := getTwoFactorSetupKey(in.UserId, authType)
+		err = global.Rdb.SetEx(ctx, keyUserTwoFator, otpNew, time.Duration(consts.TIME_2FA_OTP_REGISTER)*time.Minute).Err()
+		if err != nil {
+			return response.ErrCodeTwoFactorAuthSetupFailed, out, err
+		}
+		go sendto.SendTextSmsOtp(context.Background(), phoneNumber, otpNew)
+		return response.CodeSuccess, out, nil
+	}
+	return response.ErrCodeTwoFactorAuthSetupFailed, out, fmt.Errorf("two-factor type %s is not supported", authType)
 }
 
 // Verify Two Factor Authentication
-func (s *sUserLogin) VerifyTwoFactorAuth(ctx context.Context, in *model.TwoFactorVerificationInput) (codeResult int, err error) {
+func (s *sUserLogin) VerifyTwoFactorAuth(ctx context.Context, in *model.TwoFactorVerificationInput) (codeResult int, out model.TwoFactorVerificationOutput, err error) {
 	// 1. Check isTwoFactorEnabled
 	isTwoFatorAuth, err := s.r.IsTwoFactorEnabled(ctx, in.UserId)
 	if err != nil {
-		return response.ErrCodeTwoFactorAuthVerifyFailed, err
+		return response.ErrCodeTwoFactorAuthVerifyFailed, out, err
 	}
 	if isTwoFatorAuth > 0 {
-		return response.ErrCodeTwoFactorAuthVerifyFailed, fmt.Errorf("Two-factor authentication is not enabled")
+		return response.ErrCodeTwoFactorAuthVerifyFailed, out, fmt.Errorf("Two-factor authentication is already enabled")
 	}
-
-	// 2. Check Otp in redis avaible
-	keyUserTwoFator := crypto.GetHash("2fa:" + strconv.Itoa(int(in.UserId)))
-	otpVerifyAuth, err := global.Rdb.Get(ctx, keyUserTwoFator).Result()
-	if err == redis.Nil {
-		return response.ErrCodeTwoFactorAuthVerifyFailed, fmt.Errorf("Key %s does not exists", keyUserTwoFator)
-	} else if err != nil {
-		return response.ErrCodeTwoFactorAuthVerifyFailed, err
+	authType := twoFactorAuthType(in.TwoFactorAuthType)
+	policyKey := strconv.Itoa(int(in.UserId))
+	if err = otppolicy.SetupTwoFactor.CheckVerify(ctx, policyKey, in.ClientIp); err != nil {
+		return otpPolicyCode(err, response.ErrCodeTwoFactorAuthVerifyFailed), out, err
 	}
-	// 3. check otp
-	if otpVerifyAuth != in.TwoFactorCode {
-		return response.ErrCodeTwoFactorAuthVerifyFailed, fmt.Errorf("OTP does not match")
+
+	keyUserTwoFator := getTwoFactorSetupKey(in.UserId, authType)
+	switch authType {
+	case database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeAPP:
+		// 2. first code from authenticator app confirms the enrollment
+		method, err := s.r.GetTwoFactorMethodByIDAndType(ctx, database.GetTwoFactorMethodByIDAndTypeParams{
+			UserID:            in.UserId,
+			TwoFactorAuthType: authType,
+		})
+		if err != nil {
+			return response.ErrCodeTwoFactorAuthVerifyFailed, out, fmt.Errorf("two-factor setup not found")
+		}
+		if err = s.checkTotpCode(ctx, in.UserId, method.TwoFactorAuthSecret, in.TwoFactorCode); err != nil {
+			codeResult, err = otpFailed(ctx, otppolicy.SetupTwoFactor, policyKey, in.ClientIp, response.ErrCodeTwoFactorCodeInvalid, err)
+			return codeResult, out, err
+		}
+	default:
+		// 2. Check Otp in redis avaible
+		otpVerifyAuth, err := global.Rdb.Get(ctx, keyUserTwoFator).Result()
+		if err == redis.Nil {
+			return response.ErrCodeTwoFactorAuthVerifyFailed, out, fmt.Errorf("Key %s does not exists", keyUserTwoFator)
+		} else if err != nil {
+			return response.ErrCodeTwoFactorAuthVerifyFailed, out, err
+		}
+		// 3. check otp
+		if !otpMatch(otpVerifyAuth, in.TwoFactorCode) {
+			codeResult, err = otpFailed(ctx, otppolicy.SetupTwoFactor, policyKey, in.ClientIp, response.ErrCodeTwoFactorCodeInvalid, fmt.Errorf("OTP does not match"))
+			return codeResult, out, err
+		}
 	}
+	otppolicy.SetupTwoFactor.Reset(ctx, policyKey)
 
-	// 4. udpoate status
-	err = s.r.UpdateTwoFactorStatus(ctx, database.UpdateTwoFactorStatusParams{
+	// 4. udpoate status, the APP method and its recovery codes are enabled together
+	tx, err := global.Mdbc.BeginTx(ctx, nil)
+	if err != nil {
+		return response.ErrCodeTwoFactorAuthVerifyFailed, out, err
+	}
+	defer tx.Rollback()
+	qtx := s.r.WithTx(tx)
+	activated, err := qtx.UpdateTwoFactorStatus(ctx, database.UpdateTwoFactorStatusParams{
 		UserID:            in.UserId,
-		TwoFactorAuthType: database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeEMAIL,
+		TwoFactorAuthType: authType,
 	})
 	if err != nil {
-		return response.ErrCodeTwoFactorAuthVerifyFailed, err
+		return response.ErrCodeTwoFactorAuthVerifyFailed, out, err
+	}
+	if activated == 0 {
+		return response.ErrCodeTwoFactorAuthVerifyFailed, out, fmt.Errorf("two-factor setup of type %s not found", authType)
+	}
+
+	if authType == database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeAPP {
+		// 5. recovery codes in case the phone is lost
+		out.RecoveryCodes, err = issueRecoveryCodes(ctx, qtx, in.UserId)
+		if err != nil {
+			return response.ErrCodeTwoFactorRecoveryFailed, out, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return response.ErrCodeTwoFactorAuthVerifyFailed, out, err
+	}
+	if authType == database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeAPP {
+		return response.CodeSuccess, out, nil
 	}
 	// 5. remove otp
 	_, err = global.Rdb.Del(ctx, keyUserTwoFator).Result()
 	if err != nil {
-		return response.ErrCodeTwoFactorAuthVerifyFailed, err
+		return response.ErrCodeTwoFactorAuthVerifyFailed, out, err
 	}
-	return 200, nil
+	return response.CodeSuccess, out, nil
 }
 
-// ---- END TWO FACTOR AUTHEN ----
+// RegenerateRecoveryCodes replaces all recovery codes, requires a valid authenticator or recovery code
+func (s *sUserLogin) RegenerateRecoveryCodes(ctx context.Context, in *model.RegenerateRecoveryCodesInput) (codeResult int, out model.TwoFactorVerificationOutput, err error) {
+	policyKey := strconv.Itoa(int(in.UserId))
+	if err = otppolicy.LoginTwoFactor.CheckVerify(ctx, policyKey, in.ClientIp); err != nil {
+		return otpPolicyCode(err, response.ErrCodeTwoFactorCodeInvalid), out, err
+	}
+	if err = s.verifyAuthenticatorCode(ctx, in.UserId, in.TwoFactorCode); err != nil {
+		codeResult, err = otpFailed(ctx, otppolicy.LoginTwoFactor, policyKey, in.ClientIp, response.ErrCodeTwoFactorCodeInvalid, err)
+		return codeResult, out, err
+	}
+	otppolicy.LoginTwoFactor.Reset(ctx, policyKey)
+	tx, err := global.Mdbc.BeginTx(ctx, nil)
+	if err != nil {
+		return response.ErrCodeTwoFactorRecoveryFailed, out, err
+	}
+	defer tx.Rollback()
+	out.RecoveryCodes, err = issueRecoveryCodes(ctx, s.r.WithTx(tx), in.UserId)
+	if err != nil {
+		return response.ErrCodeTwoFactorRecoveryFailed, out, err
+	}
+	if err = tx.Commit(); err != nil {
+		return response.ErrCodeTwoFactorRecoveryFailed, out, err
+	}
+	return response.CodeSuccess, out, nil
+}
 
-func (s *sUserLogin) Login(ctx context.Context, in *model.LoginInput) (codeResult int, out model.LoginOutput, err error) {
+// verifyAuthenticatorCode accepts a TOTP code of the active APP method or an unused recovery code
+func (s *sUserLogin) verifyAuthenticatorCode(ctx context.Context, userId uint32, code string) error {
+	method, err := s.r.GetActiveTwoFactorMethod(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("two-factor authentication is not enabled")
+	}
+	if method.TwoFactorAuthType != database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeAPP {
+		return fmt.Errorf("authenticator app is not enabled")
+	}
+	if totp.Validate(code, method.TwoFactorAuthSecret, time.Now()) {
+		return s.checkTotpCode(ctx, userId, method.TwoFactorAuthSecret, code)
+	}
+	return s.useRecoveryCode(ctx, userId, code)
+}
 
-	userBase, err := s.r.GetOneUserInfo(ctx, in.UserAccount)
-	log.Printf("userBase UserPassword %v\n", userBase.UserPassword)
-	log.Printf("userBase UserSalt %v\n", userBase.UserSalt)
-	log.Printf("userBase User PASS FROTEND %v\n", in.UserPassword)
+// checkTotpCode validates an authenticator code (with clock-skew tolerance) and rejects replays of the same code
+func (s *sUserLogin) checkTotpCode(ctx context.Context, userId uint32, secret string, code string) error {
+	if !totp.Validate(code, secret, time.Now()) {
+		return fmt.Errorf("authenticator code is invalid")
+	}
+	keyUsedCode := crypto.GetHash("2fa:totp:used:" + strconv.Itoa(int(userId)) + ":" + code)
+	ok, err := global.Rdb.SetNX(ctx, keyUsedCode, 1, totp.ReplayWindow).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("authenticator code has already been used")
+	}
+	return nil
+}
 
-	computedHash := crypto.HashPassword(in.UserPassword, userBase.UserSalt)
-	log.Printf("Computed Hash for testing: %s", computedHash)
+// issueRecoveryCodes replaces the user's recovery codes, only hashes are stored.
+// q should be bound to a transaction so old codes are not lost when an insert fails
+func issueRecoveryCodes(ctx context.Context, q *database.Queries, userId uint32) ([]string, error) {
+	codes, err := random.GenerateRecoveryCodes(consts.TWO_FACTOR_RECOVERY_CODES)
+	if err != nil {
+		return nil, err
+	}
+	if err = q.DeleteRecoveryCodes(ctx, userId); err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		err = q.InsertRecoveryCode(ctx, database.InsertRecoveryCodeParams{
+			UserID:           userId,
+			RecoveryCodeHash: crypto.GetHash(normalizeRecoveryCode(code)),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
 
+// useRecoveryCode marks a recovery code as used, each code works only once
+func (s *sUserLogin) useRecoveryCode(ctx context.Context, userId uint32, code string) error {
+	result, err := s.r.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+		UserID:           userId,
+		RecoveryCodeHash: crypto.GetHash(normalizeRecoveryCode(code)),
+	})
 	if err != nil {
-		return response.ErrCodeAuthFailed, out, err
+		return err
 	}
-	// 2. check password?
-	if !crypto.MatchingPassword(userBase.UserPassword, in.UserPassword, userBase.UserSalt) {
-		return response.ErrCodeAuthFailed, out, fmt.Errorf("does not match password")
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("authenticator or recovery code is invalid")
+	}
+	return nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// getTwoFactorSetupKey is the redis key of the setup OTP, scoped by type so an
+// EMAIL code can't confirm an SMS setup
+func getTwoFactorSetupKey(userId uint32, authType database.PreGoAccUserTwoFactor9999TwoFactorAuthType) string {
+	return crypto.GetHash("2fa:" + strconv.Itoa(int(userId)) + ":" + string(authType))
+}
+
+func twoFactorAuthType(t string) database.PreGoAccUserTwoFactor9999TwoFactorAuthType {
+	if t == "" {
+		return database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeEMAIL
+	}
+	return database.PreGoAccUserTwoFactor9999TwoFactorAuthType(strings.ToUpper(t))
+}
+
+// otpPolicyCode maps otppolicy errors to their response codes
+func otpPolicyCode(err error, fallback int) int {
+	switch {
+	case errors.Is(err, otppolicy.ErrLocked):
+		return response.ErrCodeOtpLocked
+	case errors.Is(err, otppolicy.ErrTooSoon):
+		return response.ErrCodeOtpTooSoon
+	}
+	return fallback
+}
+
+// otpMatch compares a code in constant time, the time taken does not tell how many digits are right
+func otpMatch(expected string, given string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(given)) == 1
+}
+
+// otpFailed counts a wrong code; when it triggers a lock the lock is reported instead of the mismatch
+func otpFailed(ctx context.Context, policy *otppolicy.Policy, key string, ip string, codeResult int, cause error) (int, error) {
+	if err := policy.Fail(ctx, key, ip); err != nil {
+		if errors.Is(err, otppolicy.ErrLocked) {
+			return response.ErrCodeOtpLocked, err
+		}
+		global.Logger.Error("otp policy record failure", zap.String("scope", policy.Scope), zap.Error(err))
+	}
+	return codeResult, cause
+}
+
+// ---- END TWO FACTOR AUTHEN ----
+
+func (s *sUserLogin) Login(ctx context.Context, in *model.LoginInput) (codeResult int, out model.LoginOutput, err error) {
+	userBase, codeResult, err := s.checkPassword(ctx, in)
+	if err != nil {
+		s.auditLogin(ctx, userBase.UserID, in.UserAccount, loginaudit.MethodPassword, loginOutcome(codeResult), in.LoginClient)
+		return codeResult, out, err
 	}
 
 	// 3. check two-factor authentication
@@ -139,46 +403,229 @@ func (s *sUserLogin) Login(ctx context.Context, in *model.LoginInput) (codeResul
 		return response.ErrCodeAuthFailed, out, fmt.Errorf("does not match password")
 	}
 	if isTwoFactorEnable > 0 {
+		codeResult, out, err = s.startLoginChallenge(ctx, userBase, false)
+		if err == nil {
+			s.auditLogin(ctx, userBase.UserID, userBase.UserAccount, loginaudit.MethodPassword, loginaudit.OutcomeChallenge, in.LoginClient)
+		}
+		return codeResult, out, err
+	}
 
-		// otpNew := random.GenerateSixDigiOtp()
-		// if __DEV__ == "TEST_USER" {
-		// 	otpNew = 123456
-		// }
-		// sen otp to in.TwoFactorEmail
-		keyUserLoginTwoFactor := crypto.GetHash("2fa:otp:" + strconv.Itoa(int(userBase.UserID)))
-		err = global.Rdb.SetEx(ctx, keyUserLoginTwoFactor, "111111", time.Duration(consts.TIME_OTP_REGISTER)*time.Minute).Err()
-		if err != nil {
-			return response.ErrCodeAuthFailed, out, fmt.Errorf("set otp redis faiuled")
+	return s.completeLogin(ctx, userBase, loginaudit.MethodPassword, in.LoginClient, usercontext.AuthLevelPassword, false)
+}
+
+// checkPassword authenticates the account with its password, only activated accounts may log in
+func (s *sUserLogin) checkPassword(ctx context.Context, in *model.LoginInput) (userBase database.GetOneUserInfoRow, codeResult int, err error) {
+	// phone numbers are stored in E.164, accept any local format
+	if in.UserAccount, err = normalizeAccount(in.UserAccount); err != nil {
+		return userBase, response.ErrCodeAuthFailed, err
+	}
+
+	userBase, err = s.r.GetOneUserInfo(ctx, in.UserAccount)
+	if err != nil {
+		return userBase, response.ErrCodeAuthFailed, err
+	}
+	// 2. check password?
+	match, needsRehash := crypto.MatchingPassword(userBase.UserPassword, in.UserPassword, userBase.UserSalt)
+	if !match {
+		return userBase, response.ErrCodeAuthFailed, fmt.Errorf("does not match password")
+	}
+	if needsRehash {
+		// legacy SHA-256 or outdated parameters: upgrade silently, login must not fail because of it
+		if newHash, err := s.rehashPassword(ctx, userBase.UserID, in.UserPassword); err != nil {
+			global.Logger.Error("rehash password failed", zap.Int32("user_id", userBase.UserID), zap.Error(err))
+		} else {
+			userBase.UserPassword = newHash
 		}
-		// send otp via twofactorEmail
-		// get email 2fa
-		infoUserTwoFactor, err := s.r.GetTwoFactorMethodByIDAndType(ctx, database.GetTwoFactorMethodByIDAndTypeParams{
-			UserID:            uint32(userBase.UserID),
-			TwoFactorAuthType: database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeEMAIL,
-		})
-		if err != nil {
-			return response.ErrCodeAuthFailed, out, fmt.Errorf("get two factor method failed")
+	}
+	// the state is only revealed to the owner of the password
+	if codeResult, err = s.checkUserState(ctx, uint64(userBase.UserID)); err != nil {
+		return userBase, codeResult, err
+	}
+	return userBase, response.CodeSuccess, nil
+}
+
+// checkUserState rejects locked and not yet activated accounts
+func (s *sUserLogin) checkUserState(ctx context.Context, userId uint64) (codeResult int, err error) {
+	infoUser, err := s.r.GetUser(ctx, userId)
+	if err != nil {
+		return response.ErrCodeAuthFailed, err
+	}
+	switch infoUser.UserState {
+	case consts.UserStateActivated:
+		return response.CodeSuccess, nil
+	case consts.UserStateLocked:
+		return response.ErrCodeUserLocked, fmt.Errorf("account is locked")
+	}
+	return response.ErrCodeUserNotActivated, fmt.Errorf("account is not activated")
+}
+
+// startLoginChallenge stores a 2FA challenge and sends the code, tokens are issued by VerifyLoginTwoFactor
+func (s *sUserLogin) startLoginChallenge(ctx context.Context, userBase database.GetOneUserInfoRow, admin bool) (codeResult int, out model.LoginOutput, err error) {
+	method, err := s.r.GetActiveTwoFactorMethod(ctx, uint32(userBase.UserID))
+	if err != nil {
+		return response.ErrCodeAuthFailed, out, fmt.Errorf("get two factor method failed")
+	}
+	// resend cooldown before anything is stored, a rejected login must not leave a challenge behind
+	if method.TwoFactorAuthType != database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeAPP {
+		if err = otppolicy.LoginTwoFactor.CheckSend(ctx, strconv.Itoa(int(userBase.UserID))); err != nil {
+			return otpPolicyCode(err, response.ErrCodeAuthFailed), out, err
 		}
-		// go sendto.SendEmailToJavaByAPI()
-		log.Println("send OTP 2FA to Email::", infoUserTwoFactor.TwoFactorEmail)
-		go sendto.SendTextEmailOtp([]string{infoUserTwoFactor.TwoFactorEmail.String}, os.Getenv("SENDER_EMAIL"), "111111")
+	}
 
-		out.Message = "send OTP 2FA to Email, pls het OTP by Email.."
+	// short-lived, single-use challenge
+	challengeToken := uuid.New().String()
+	challengeJson, err := json.Marshal(loginChallenge{
+		UserId:            userBase.UserID,
+		UserAccount:       userBase.UserAccount,
+		TwoFactorAuthType: string(method.TwoFactorAuthType),
+		Admin:             admin,
+	})
+	if err != nil {
+		return response.ErrCodeAuthFailed, out, err
+	}
+	err = global.Rdb.SetEx(ctx, getLoginChallengeKey(challengeToken), challengeJson, time.Duration(consts.TIME_2FA_LOGIN_CHALLENGE)*time.Minute).Err()
+	if err != nil {
+		return response.ErrCodeAuthFailed, out, fmt.Errorf("set challenge redis failed")
+	}
+	out.ChallengeToken = challengeToken
+	out.TwoFactorAuthType = string(method.TwoFactorAuthType)
+	out.PasskeyAvailable = s.hasPasskeys(ctx, userBase.UserID)
+
+	if method.TwoFactorAuthType == database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeAPP {
+		// code is generated by the authenticator app, nothing to send
+		out.Message = "enter the code from your authenticator app or a recovery code"
 		return response.CodeSuccess, out, nil
 	}
 
+	// sen otp to in.TwoFactorEmail / TwoFactorPhone
+	otpNew := strconv.Itoa(random.GenerateSixDigiOtp())
+	keyUserLoginTwoFactor := crypto.GetHash("2fa:otp:" + strconv.Itoa(int(userBase.UserID)))
+	err = global.Rdb.SetEx(ctx, keyUserLoginTwoFactor, otpNew, time.Duration(consts.TIME_2FA_LOGIN_CHALLENGE)*time.Minute).Err()
+	if err != nil {
+		return response.ErrCodeAuthFailed, out, fmt.Errorf("set otp redis faiuled")
+	}
+	if method.TwoFactorAuthType == database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeSMS {
+		// send otp via twoFactorPhone, the request context is gone once we return
+		go sendto.SendTextSmsOtp(context.Background(), method.TwoFactorPhone.String, otpNew)
+		out.Message = "send OTP 2FA to phone, pls get OTP by SMS.."
+		return response.CodeSuccess, out, nil
+	}
+	// send otp via twofactorEmail
+	go sendto.SendTextEmailOtp([]string{method.TwoFactorEmail.String}, os.Getenv("SENDER_EMAIL"), otpNew)
+
+	out.Message = "send OTP 2FA to Email, pls het OTP by Email.."
+	return response.CodeSuccess, out, nil
+}
+
+// rehashPassword stores the password with the current hasher
+func (s *sUserLogin) rehashPassword(ctx context.Context, userId int32, password string) (string, error) {
+	newHash, err := crypto.HashPassword(password)
+	if err != nil {
+		return "", err
+	}
+	err = s.r.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		UserPassword: newHash,
+		UserSalt:     "", // salt is encoded in the hash
+		UserID:       userId,
+	})
+	if err != nil {
+		return "", err
+	}
+	return newHash, nil
+}
+
+// loginChallenge is stored in redis between Login and VerifyLoginTwoFactor
+type loginChallenge struct {
+	UserId            int32  `json:"user_id"`
+	UserAccount       string `json:"user_account"`
+	TwoFactorAuthType string `json:"two_factor_auth_type"`
+	Admin             bool   `json:"admin"` // started by AdminLogin, the session is an admin session
+}
+
+func getLoginChallengeKey(challengeToken string) string {
+	return crypto.GetHash("2fa:challenge:" + challengeToken)
+}
+
+// VerifyLoginTwoFactor completes a login started by Login when 2FA is enabled
+func (s *sUserLogin) VerifyLoginTwoFactor(ctx context.Context, in *model.LoginTwoFactorInput) (codeResult int, out model.LoginOutput, err error) {
+	// 1. load challenge
+	keyChallenge := getLoginChallengeKey(in.ChallengeToken)
+	var challenge loginChallenge
+	if err = cache.GetCache(ctx, keyChallenge, &challenge); err != nil {
+		return response.ErrCodeTwoFactorChallengeInvalid, out, fmt.Errorf("challenge is invalid or expired")
+	}
+	userId := uint32(challenge.UserId)
+	policyKey := strconv.Itoa(int(userId))
+	method := loginaudit.MethodTwoFactor
+	if challenge.Admin {
+		method = loginaudit.MethodAdmin
+	}
+	if err = otppolicy.LoginTwoFactor.CheckVerify(ctx, policyKey, in.ClientIp); err != nil {
+		return otpPolicyCode(err, response.ErrCodeTwoFactorCodeInvalid), out, err
+	}
+
+	// 2. check code
+	switch database.PreGoAccUserTwoFactor9999TwoFactorAuthType(challenge.TwoFactorAuthType) {
+	case database.PreGoAccUserTwoFactor9999TwoFactorAuthTypeAPP:
+		if err = s.verifyAuthenticatorCode(ctx, userId, in.TwoFactorCode); err != nil {
+			s.auditLogin(ctx, challenge.UserId, challenge.UserAccount, method, loginaudit.OutcomeFailed, in.LoginClient)
+			codeResult, err = otpFailed(ctx, otppolicy.LoginTwoFactor, policyKey, in.ClientIp, response.ErrCodeTwoFactorCodeInvalid, err)
+			return codeResult, out, err
+		}
+	default:
+		keyUserLoginTwoFactor := crypto.GetHash("2fa:otp:" + strconv.Itoa(int(userId)))
+		otpFound, err := global.Rdb.Get(ctx, keyUserLoginTwoFactor).Result()
+		if err == redis.Nil {
+			return response.ErrCodeTwoFactorChallengeInvalid, out, fmt.Errorf("OTP is expired")
+		} else if err != nil {
+			return response.ErrCodeTwoFactorCodeInvalid, out, err
+		}
+		if !otpMatch(otpFound, in.TwoFactorCode) {
+			s.auditLogin(ctx, challenge.UserId, challenge.UserAccount, method, loginaudit.OutcomeFailed, in.LoginClient)
+			codeResult, err = otpFailed(ctx, otppolicy.LoginTwoFactor, policyKey, in.ClientIp, response.ErrCodeTwoFactorCodeInvalid, fmt.Errorf("OTP does not match"))
+			return codeResult, out, err
+		}
+		// consume otp
+		if err = global.Rdb.Del(ctx, keyUserLoginTwoFactor).Err(); err != nil {
+			return response.ErrCodeTwoFactorCodeInvalid, out, err
+		}
+	}
+	otppolicy.LoginTwoFactor.Reset(ctx, policyKey)
+
+	// 3. consume challenge, only one request may win
+	deleted, err := global.Rdb.Del(ctx, keyChallenge).Result()
+	if err != nil {
+		return response.ErrCodeTwoFactorChallengeInvalid, out, err
+	}
+	if deleted == 0 {
+		return response.ErrCodeTwoFactorChallengeInvalid, out, fmt.Errorf("challenge has already been used")
+	}
+
+	// 4. issue tokens, the account may have been locked meanwhile
+	userBase, err := s.r.GetOneUserInfo(ctx, challenge.UserAccount)
+	if err != nil {
+		return response.ErrCodeAuthFailed, out, err
+	}
+	if codeResult, err = s.checkUserState(ctx, uint64(userBase.UserID)); err != nil {
+		s.auditLogin(ctx, userBase.UserID, userBase.UserAccount, method, loginOutcome(codeResult), in.LoginClient)
+		return codeResult, out, err
+	}
+	return s.completeLogin(ctx, userBase, method, in.LoginClient, usercontext.AuthLevelTwoFactor, challenge.Admin)
+}
+
+// issueLoginTokens creates the subToken session and the access/refresh token pair
+func (s *sUserLogin) issueLoginTokens(ctx context.Context, userId int32, userAccount string, userPassword string, client model.LoginClient, authLevel int, admin bool) (codeResult int, out model.LoginOutput, err error) {
 	// 4. update password time
 	go s.r.LoginUserBase(ctx, database.LoginUserBaseParams{
-		UserLoginIp:  sql.NullString{String: "127.0.0.1", Valid: true},
-		UserAccount:  in.UserAccount,
-		UserPassword: in.UserPassword, // khong can
+		UserLoginIp:  sql.NullString{String: client.ClientIp, Valid: client.ClientIp != ""},
+		UserAccount:  userAccount,
+		UserPassword: userPassword,
 	})
 
 	// 5. Create UUID User
-	subToken := utils.GenerateCliTokenUUID(int(userBase.UserID))
-	log.Printf("Generated subToken: %s", subToken)
+	subToken := utils.GenerateCliTokenUUID(int(userId))
 	// 6. get user_info table
-	infoUser, err := s.r.GetUser(ctx, uint64(userBase.UserID))
+	infoUser, err := s.r.GetUser(ctx, uint64(userId))
 	if err != nil {
 		return response.ErrCodeAuthFailed, out, err
 	}
@@ -187,13 +634,22 @@ func (s *sUserLogin) Login(ctx context.Context, in *model.LoginInput) (codeResul
 	if err != nil {
 		return response.ErrCodeAuthFailed, out, fmt.Errorf("convert to json failed: %v", err)
 	}
-	const accessTokenTTL = 30 * time.Minute
-	const refreshTokenTTL = 7 * 24 * time.Hour
 	// 7. give infoUserJson to redis with key = subToken
 	err = global.Rdb.Set(ctx, subToken, infoUserJson, refreshTokenTTL).Err()
 	if err != nil {
-		log.Printf("err redis subToken: %v", err)
+		global.Logger.Error("set session user info failed", zap.Int64("user_id", int64(userId)), zap.Error(err))
+		return response.ErrCodeAuthFailed, out, err
+	}
 
+	// add to the user's session index (logout-all, session list)
+	err = session.Register(ctx, uint64(userId), subToken, session.Info{
+		Device:    client.DeviceName,
+		ClientIp:  client.ClientIp,
+		UserAgent: client.UserAgent,
+		AuthLevel: authLevel,
+		Admin:     admin,
+	}, refreshTokenTTL)
+	if err != nil {
 		return response.ErrCodeAuthFailed, out, err
 	}
 
@@ -202,69 +658,61 @@ func (s *sUserLogin) Login(ctx context.Context, in *model.LoginInput) (codeResul
 	if err != nil {
 		return response.ErrCodeAuthFailed, out, err
 	}
-	// Tạo refreshToken
-	refreshToken, err := auth.CreateToken(subToken, refreshTokenTTL.String())
+	// Tạo refreshToken, the session is the token family
+	refreshToken, jti, err := auth.CreateRefreshToken(subToken, refreshTokenTTL.String())
 	if err != nil {
 		return response.ErrCodeAuthFailed, out, err
 	}
 	out.RefreshToken = refreshToken
 
-	err = global.Rdb.Set(ctx, subToken+"_refresh", refreshToken, refreshTokenTTL).Err()
+	err = session.SetRefreshToken(ctx, subToken, jti, refreshTokenTTL)
 	if err != nil {
-		log.Printf("err redis subToken__refresh: %v", err)
+		global.Logger.Error("set refresh token failed", zap.Int64("user_id", int64(userId)), zap.Error(err))
 		return response.ErrCodeAuthFailed, out, err
 	}
 	return response.CodeSuccess, out, nil
 }
-func (s *sUserLogin) RefreshToken(ctx context.Context, in *model.RefreshTokenInput) (codeResult int, out model.LoginOutput, err error) {
-
-	// Phần xử lý logic tương tự
-	parsedToken, err := jwt.Parse(in.RefreshToken, func(token *jwt.Token) (interface{}, error) {
-		return []byte(global.Config.JWT.API_SECRET_KEY), nil
-	})
-	log.Println("parsedToken:", parsedToken)
 
-	if err != nil || !parsedToken.Valid {
-		return response.CodeFail, out, err
+// RefreshToken rotates the refresh token of a session (token family).
+// Presenting an already rotated token revokes the whole family
+func (s *sUserLogin) RefreshToken(ctx context.Context, in *model.RefreshTokenInput) (codeResult int, out model.LoginOutput, err error) {
+	// 1. verify signature, expiry and that it is a refresh token
+	claims, err := auth.VerifyRefreshToken(in.RefreshToken)
+	if err != nil {
+		return response.ErrInvalidToken, out, err
 	}
+	subToken := claims.Subject
 
-	claims, ok := parsedToken.Claims.(jwt.MapClaims)
-	if !ok || claims["sub"] == nil {
-		return response.CodeFail, out, err
+	// 2. the session payload (user info) identifies the user of the family
+	var infoUser usercontext.InfoUserUUID
+	if err = cache.GetCache(ctx, subToken, &infoUser); err != nil {
+		return response.ErrInvalidToken, out, session.ErrRefreshTokenInvalid
 	}
 
-	subToken := claims["sub"].(string)
-	log.Println("subToken 231:", subToken)
-	savedRefreshToken, err := global.Rdb.Get(ctx, subToken+"_refresh").Result()
-	if err != nil || savedRefreshToken != in.RefreshToken {
-
-		log.Println("in.RefreshToken:", in.RefreshToken)
-		log.Println("savedRefreshToken:", savedRefreshToken)
-
-		return response.CodeFail, out, err
-	}
-	const accessTokenTTL = 30 * time.Minute
-	const refreshTokenTTL = 7 * 24 * time.Hour
-	// Tạo accessToken và refreshToken mới
-	newAccessToken, err := auth.CreateToken(subToken, accessTokenTTL.String())
-	log.Println("newAccessToken:", newAccessToken)
+	// 3. rotate: new refresh token replaces the presented one
+	newRefreshToken, newJti, err := auth.CreateRefreshToken(subToken, refreshTokenTTL.String())
 	if err != nil {
 		return response.CodeFail, out, err
 	}
-
-	err = global.Rdb.Set(ctx, subToken, newAccessToken, accessTokenTTL).Err()
-	if err != nil {
-		return response.CodeFail, out, err
+	err = session.RotateRefreshToken(ctx, infoUser.UserId, subToken, claims.Id, newJti, refreshTokenTTL)
+	if errors.Is(err, session.ErrRefreshTokenReused) {
+		// token theft or replay: kill the whole family
+		global.Logger.Warn("security event: refresh token reuse detected, revoking session",
+			zap.Uint64("user_id", infoUser.UserId),
+			zap.String("session_id", session.ID(subToken)),
+			zap.String("jti", claims.Id),
+		)
+		if errRevoke := session.Revoke(ctx, infoUser.UserId, subToken); errRevoke != nil {
+			global.Logger.Error("revoke session failed", zap.Error(errRevoke))
+		}
+		return response.ErrCodeRefreshTokenReused, out, err
 	}
-
-	newRefreshToken, err := auth.CreateToken(subToken, refreshTokenTTL.String())
-	log.Println("newRefreshToken:", newRefreshToken)
-
 	if err != nil {
-		return response.CodeFail, out, err
+		return response.ErrInvalidToken, out, err
 	}
 
-	err = global.Rdb.Set(ctx, subToken+"_refresh", newRefreshToken, refreshTokenTTL).Err()
+	// 4. new access token for the same session
+	newAccessToken, err := auth.CreateToken(subToken, accessTokenTTL.String())
 	if err != nil {
 		return response.CodeFail, out, err
 	}
@@ -275,12 +723,16 @@ func (s *sUserLogin) RefreshToken(ctx context.Context, in *model.RefreshTokenInp
 }
 
 func (s *sUserLogin) Register(ctx context.Context, in *model.RegisterInput) (codeResult int, err error) {
-	//1. hash email
-	fmt.Printf("Verify key %s\n", in.VerifyKey)
-	fmt.Printf("Verify type %d\n", in.VerifyType)
+	//1. hash email or phone (E.164)
+	if in.VerifyType != consts.EMAIL && in.VerifyType != consts.MOBILE {
+		return response.ErrCodeParamInvalid, fmt.Errorf("verify type %d is not supported", in.VerifyType)
+	}
+	in.VerifyKey, err = normalizeAccount(in.VerifyKey)
+	if err != nil {
+		return response.ErrCodeParamInvalid, err
+	}
 
-	hashKey := crypto.GetHash(strings.ToLower(in.VerifyKey))
-	fmt.Printf("hasKey %s\n", hashKey)
+	hashKey := crypto.GetHash(in.VerifyKey)
 
 	//2. check email if existing user in database
 	userFound, err := s.r.CheckUserBaseExists(ctx, in.VerifyKey)
@@ -291,25 +743,13 @@ func (s *sUserLogin) Register(ctx context.Context, in *model.RegisterInput) (cod
 		return response.ErrCodeUserHassExits, fmt.Errorf("user already registered")
 	}
 
-	//3. Create OTP
+	//3. Create OTP, resend cooldown and lock are handled by the policy
 	userKey := utils.GetUserKey(hashKey)
-	otpFound, err := global.Rdb.Get(ctx, userKey).Result()
-
-	switch {
-	case err == redis.Nil:
-		fmt.Println("Key does not exist")
-	case err != nil:
-		fmt.Println("Get fail")
-		return response.ErrInvalidOtp, err
-	case otpFound != "":
-		return response.ErrInvalidOtp, fmt.Errorf("")
+	if err = otppolicy.Register.CheckSend(ctx, hashKey); err != nil {
+		return otpPolicyCode(err, response.ErrInvalidOtp), err
 	}
 	//4. Generate OTP
 	otpNew := random.GenerateSixDigiOtp()
-	if in.VerifyPurpose == "TEST_USER" {
-		otpNew = 123456
-	}
-	fmt.Printf("Otp is %d\n", otpNew)
 	//5. Save OTP in redis with expiration time
 	err = global.Rdb.SetEx(ctx, userKey, strconv.Itoa(otpNew), time.Duration(consts.TIME_OTP_REGISTER)*time.Minute).Err()
 
@@ -317,97 +757,133 @@ func (s *sUserLogin) Register(ctx context.Context, in *model.RegisterInput) (cod
 		return response.ErrInvalidOtp, err
 	}
 
-	//6. Send email otp
+	//6. Send otp by email or sms
 	switch in.VerifyType {
 	case consts.EMAIL:
 		err = sendto.SendTextEmailOtp([]string{in.VerifyKey}, os.Getenv("SENDER_EMAIL"), strconv.Itoa(otpNew))
 		if err != nil {
 			return response.ErrSendEmailOtp, err
 		}
-		//7. Save otp to MYSQL
-		result, err := s.r.InsertOTPVerify(ctx, database.InsertOTPVerifyParams{
-			VerifyOtp:     strconv.Itoa(otpNew),
-			VerifyKey:     in.VerifyKey,
-			VerifyKeyHash: hashKey,
-			VerifyType:    sql.NullInt32{Int32: 1, Valid: true},
-		})
-		if err != nil {
-			return response.ErrSendEmailOtp, err
-		}
-		//8 getLastID
-		lastIdVerifyUser, err := result.LastInsertId()
-
+	case consts.MOBILE:
+		err = sendto.SendTextSmsOtp(ctx, in.VerifyKey, strconv.Itoa(otpNew))
 		if err != nil {
-			return response.ErrSendEmailOtp, err
+			return response.ErrSendSmsOtp, err
 		}
-		log.Println("lastIdVerifyUser", lastIdVerifyUser)
+	}
+	//7. Save otp to MYSQL
+	result, err := s.r.InsertOTPVerify(ctx, database.InsertOTPVerifyParams{
+		VerifyOtp:     strconv.Itoa(otpNew),
+		VerifyKey:     in.VerifyKey,
+		VerifyKeyHash: hashKey,
+		VerifyType:    sql.NullInt32{Int32: int32(in.VerifyType), Valid: true},
+		VerifyPurpose: consts.VERIFY_PURPOSE_REGISTER,
+	})
+	if err != nil {
+		return response.ErrInvalidOtp, err
+	}
+	//8 getLastID
+	lastIdVerifyUser, err := result.LastInsertId()
 
-		return response.CodeSuccess, nil
+	if err != nil {
+		return response.ErrInvalidOtp, err
+	}
+	global.Logger.Debug("register OTP sent", zap.Int64("verify_id", lastIdVerifyUser))
 
-	case consts.MOBILE:
-		return response.CodeSuccess, nil
+	return response.CodeSuccess, nil
+}
 
+// normalizeAccount returns the stored form of an account: E.164 for phone numbers, lower case for emails
+func normalizeAccount(account string) (string, error) {
+	account = strings.TrimSpace(account)
+	if phone.IsPhone(account) {
+		return phone.Normalize(account)
 	}
-	return response.CodeFail, nil
+	return strings.ToLower(account), nil
 }
-func (s *sUserLogin) VerifyOTP(ctx context.Context, in *model.VerifyInput) (out model.VerifyOTPOutput, err error) {
+func (s *sUserLogin) VerifyOTP(ctx context.Context, in *model.VerifyInput) (codeResult int, out model.VerifyOTPOutput, err error) {
+
+	verifyKey, err := normalizeAccount(in.VerifyKey)
+	if err != nil {
+		return response.ErrCodeParamInvalid, out, err
+	}
+	hashKey := crypto.GetHash(verifyKey)
 
-	hashKey := crypto.GetHash(strings.ToLower(in.VerifyKey))
+	// Sai nhiều lần thì khoá theo otppolicy.Register
+	if err = otppolicy.Register.CheckVerify(ctx, hashKey, in.ClientIp); err != nil {
+		return otpPolicyCode(err, response.ErrInvalidOtp), out, err
+	}
 
 	//Get otp
 	otpFound, err := global.Rdb.Get(ctx, utils.GetUserKey((hashKey))).Result()
 
 	if err != nil {
-		return out, err
+		return response.ErrInvalidOtp, out, err
 	}
 
-	if in.VerifyCode != otpFound {
-		// Nếu sai 3 lần 1 phủt chưa xử lý
-		return out, fmt.Errorf("OTP not match")
+	if !otpMatch(otpFound, in.VerifyCode) {
+		codeResult, err = otpFailed(ctx, otppolicy.Register, hashKey, in.ClientIp, response.ErrInvalidOtp, fmt.Errorf("OTP not match"))
+		return codeResult, out, err
 	}
-	infoOTP, err := s.r.GetInfoOTP(ctx, hashKey)
+	otppolicy.Register.Reset(ctx, hashKey)
+	verifyParams := database.GetInfoOTPParams{VerifyKeyHash: hashKey, VerifyPurpose: consts.VERIFY_PURPOSE_REGISTER}
+	infoOTP, err := s.r.GetInfoOTP(ctx, verifyParams)
 
 	if err != nil {
-		return out, err
+		return response.ErrInvalidOtp, out, err
 	}
 
-	err = s.r.UpdateUserVerificationStatus(ctx, hashKey)
+	err = s.r.UpdateUserVerificationStatus(ctx, database.UpdateUserVerificationStatusParams(verifyParams))
 	if err != nil {
-		return out, err
+		return response.ErrInvalidOtp, out, err
 	}
 
 	out.Token = infoOTP.VerifyKeyHash
 	out.Message = "Succes"
-	return out, err
+	return response.CodeSuccess, out, err
 }
 func (s *sUserLogin) UpdatePasswordRegister(ctx context.Context, token string, password string) (userId int, err error) {
 	// 1. token is already verified : user_verify table
-	infoOTP, err := s.r.GetInfoOTP(ctx, token)
+	// a reset-password OTP must not be usable to create an account
+	infoOTP, err := s.r.GetInfoOTP(ctx, database.GetInfoOTPParams{
+		VerifyKeyHash: token,
+		VerifyPurpose: consts.VERIFY_PURPOSE_REGISTER,
+	})
 	if err != nil {
 		return response.ErrCodeUserOtpNotExists, err
 	}
-	// 1 check isVerified OK
-	if infoOTP.IsVerified.Int32 == 0 {
+	// 1 check isVerified OK, 2 = already used to create an account
+	if infoOTP.IsVerified.Int32 != 1 {
 		return response.ErrCodeUserOtpNotExists, fmt.Errorf("user OTP not verified")
 	}
 	// 2. check token is exists in user_base
 	//update user_base table
-	log.Println("infoOTP::", infoOTP)
-	log.Println("pass register", password)
 	userBase := database.AddUserBaseParams{}
 	userBase.UserAccount = infoOTP.VerifyKey
-	userSalt, err := crypto.GenerateSalt(16)
+	userBase.UserPassword, err = crypto.HashPassword(password)
 	if err != nil {
 		return response.ErrCodeUserOtpNotExists, err
 	}
-	userBase.UserSalt = userSalt
-	userBase.UserPassword = crypto.HashPassword(password, userSalt)
-	log.Printf("pass UserPassword %v\n", userBase.UserPassword)
-	log.Printf("pass UserSalt %v\n", userBase.UserSalt)
+
+	// consume the verify record and create the account together, a token creates one account
+	tx, err := global.Mdbc.BeginTx(ctx, nil)
+	if err != nil {
+		return response.ErrCodeUserOtpNotExists, err
+	}
+	defer tx.Rollback()
+	qtx := s.r.WithTx(tx)
+	consumed, err := qtx.ConsumeUserVerification(ctx, database.ConsumeUserVerificationParams{
+		VerifyKeyHash: token,
+		VerifyPurpose: consts.VERIFY_PURPOSE_REGISTER,
+	})
+	if err != nil {
+		return response.ErrCodeUserOtpNotExists, err
+	}
+	if consumed == 0 {
+		return response.ErrCodeUserOtpNotExists, fmt.Errorf("user OTP has already been used")
+	}
 
 	// add userBase to user_base table
-	newUserBase, err := s.r.AddUserBase(ctx, userBase)
-	log.Println("newUserBase::", newUserBase, userBase)
+	newUserBase, err := qtx.AddUserBase(ctx, userBase)
 	if err != nil {
 		return response.ErrCodeUserOtpNotExists, err
 	}
@@ -415,17 +891,23 @@ func (s *sUserLogin) UpdatePasswordRegister(ctx context.Context, token string, p
 	if err != nil {
 		return response.ErrCodeUserOtpNotExists, err
 	}
-	// add user_id to user_info table
-	newUserInfo, err := s.r.AddUserHaveUserId(ctx, database.AddUserHaveUserIdParams{
+	// add user_id to user_info table, the verify key is either the email or the phone number
+	userEmail := sql.NullString{String: infoOTP.VerifyKey, Valid: true}
+	userMobile := sql.NullString{String: "", Valid: true}
+	if infoOTP.VerifyType.Int32 == int32(consts.MOBILE) {
+		userEmail = sql.NullString{}
+		userMobile = sql.NullString{String: infoOTP.VerifyKey, Valid: true}
+	}
+	newUserInfo, err := qtx.AddUserHaveUserId(ctx, database.AddUserHaveUserIdParams{
 		UserID:               uint64(user_id),
 		UserAccount:          infoOTP.VerifyKey,
 		UserNickname:         sql.NullString{String: infoOTP.VerifyKey, Valid: true},
 		UserAvatar:           sql.NullString{String: "", Valid: true},
-		UserState:            1,
-		UserMobile:           sql.NullString{String: "", Valid: true},
+		UserState:            consts.UserStateActivated,
+		UserMobile:           userMobile,
 		UserGender:           sql.NullInt16{Int16: 0, Valid: true},
 		UserBirthday:         sql.NullTime{Time: time.Time{}, Valid: false},
-		UserEmail:            sql.NullString{String: infoOTP.VerifyKey, Valid: true},
+		UserEmail:            userEmail,
 		UserIsAuthentication: 1,
 	})
 	if err != nil {
@@ -435,5 +917,8 @@ func (s *sUserLogin) UpdatePasswordRegister(ctx context.Context, token string, p
 	if err != nil {
 		return response.ErrCodeUserOtpNotExists, err
 	}
+	if err = tx.Commit(); err != nil {
+		return response.ErrCodeUserOtpNotExists, err
+	}
 	return int(user_id), nil
 }
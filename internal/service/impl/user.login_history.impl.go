@@ -0,0 +1,140 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/utils/loginaudit"
+	"go_ecommerce/internal/utils/sendto"
+	"go_ecommerce/internal/utils/session"
+	"go_ecommerce/pkg/response"
+
+	"go.uber.org/zap"
+)
+
+const (
+	loginHistoryDefaultLimit = 20
+	loginHistoryMaxLimit     = 100
+)
+
+// auditLogin stores a login attempt. The first successful login from an unknown
+// device or country is reported to the account email. Auditing never fails a login
+func (s *sUserLogin) auditLogin(ctx context.Context, userId int32, account string, method string, outcome string, client model.LoginClient) {
+	userID := sql.NullInt32{Int32: userId, Valid: userId > 0}
+	newDevice := outcome == loginaudit.OutcomeSuccess && userID.Valid && s.isNewDevice(ctx, userID, client)
+	err := s.r.AddLoginHistory(ctx, database.AddLoginHistoryParams{
+		UserID:                 userID,
+		LoginAccount:           account,
+		LoginMethod:            method,
+		LoginOutcome:           outcome,
+		LoginIp:                client.ClientIp,
+		LoginUserAgent:         loginaudit.TruncateUserAgent(client.UserAgent),
+		LoginDeviceFingerprint: client.Fingerprint,
+		LoginCountry:           client.Country,
+	})
+	if err != nil {
+		global.Logger.Error("add login history failed", zap.Int32("user_id", userId), zap.String("outcome", outcome), zap.Error(err))
+	}
+	if newDevice {
+		s.sendNewDeviceAlert(ctx, userId, client)
+	}
+}
+
+// isNewDevice: no earlier successful login from this device or country. The very
+// first login of an account is expected and not reported
+func (s *sUserLogin) isNewDevice(ctx context.Context, userID sql.NullInt32, client model.LoginClient) bool {
+	total, err := s.r.CountUserLogins(ctx, userID)
+	if err != nil || total == 0 {
+		return false
+	}
+	known, err := s.r.CountUserLoginsFromDevice(ctx, database.CountUserLoginsFromDeviceParams{
+		UserID:                 userID,
+		LoginDeviceFingerprint: client.Fingerprint,
+	})
+	if err == nil && known == 0 {
+		return true
+	}
+	if client.Country == "" {
+		return false
+	}
+	known, err = s.r.CountUserLoginsFromCountry(ctx, database.CountUserLoginsFromCountryParams{
+		UserID:       userID,
+		LoginCountry: client.Country,
+	})
+	return err == nil && known == 0
+}
+
+func (s *sUserLogin) sendNewDeviceAlert(ctx context.Context, userId int32, client model.LoginClient) {
+	infoUser, err := s.r.GetUser(ctx, uint64(userId))
+	if err != nil {
+		global.Logger.Error("new device alert: get user failed", zap.Int32("user_id", userId), zap.Error(err))
+		return
+	}
+	// phone-only accounts have no address to alert
+	if !infoUser.UserEmail.Valid || infoUser.UserEmail.String == "" {
+		return
+	}
+	// the request context is gone once we return
+	go sendto.SendEmail(context.Background(), loginaudit.NewDeviceAlert(infoUser.UserEmail.String, client, time.Now()))
+}
+
+// loginOutcome is the outcome of a rejected password check
+func loginOutcome(codeResult int) string {
+	switch codeResult {
+	case response.ErrCodeUserLocked:
+		return loginaudit.OutcomeLocked
+	case response.ErrCodeUserNotActivated:
+		return loginaudit.OutcomeNotActivated
+	}
+	return loginaudit.OutcomeFailed
+}
+
+// completeLogin issues the tokens, records the successful login and keeps an account scheduled for deletion
+func (s *sUserLogin) completeLogin(ctx context.Context, userBase database.GetOneUserInfoRow, method string, client model.LoginClient, authLevel int, admin bool) (codeResult int, out model.LoginOutput, err error) {
+	codeResult, out, err = s.issueLoginTokens(ctx, userBase.UserID, userBase.UserAccount, userBase.UserPassword, client, authLevel, admin)
+	if err != nil {
+		return codeResult, out, err
+	}
+	s.auditLogin(ctx, userBase.UserID, userBase.UserAccount, method, loginaudit.OutcomeSuccess, client)
+	s.cancelAccountDeletion(ctx, uint64(userBase.UserID))
+	return codeResult, out, nil
+}
+
+// LoginHistory lists the login attempts of the user, newest first
+func (s *sUserLogin) LoginHistory(ctx context.Context, in *model.LoginHistoryInput) (codeResult int, out model.LoginHistoryOutput, err error) {
+	out.Page, out.Limit = in.Page, in.Limit
+	if out.Page < 1 {
+		out.Page = 1
+	}
+	if out.Limit < 1 {
+		out.Limit = loginHistoryDefaultLimit
+	} else if out.Limit > loginHistoryMaxLimit {
+		out.Limit = loginHistoryMaxLimit
+	}
+	rows, err := s.r.ListUserLoginHistory(ctx, database.ListUserLoginHistoryParams{
+		UserID: sql.NullInt32{Int32: int32(in.UserId), Valid: true},
+		Limit:  int32(out.Limit),
+		Offset: int32((out.Page - 1) * out.Limit),
+	})
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	out.Entries = make([]model.LoginHistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		out.Entries = append(out.Entries, model.LoginHistoryEntry{
+			Method:        row.LoginMethod,
+			Outcome:       row.LoginOutcome,
+			ClientIp:      row.LoginIp,
+			Device:        session.DeviceFromUserAgent(row.LoginUserAgent),
+			UserAgent:     row.LoginUserAgent,
+			Country:       row.LoginCountry,
+			CurrentDevice: in.Fingerprint != "" && row.LoginDeviceFingerprint == in.Fingerprint,
+			CreatedAt:     row.LoginCreatedAt.Time,
+		})
+	}
+	return response.CodeSuccess, out, nil
+}
@@ -0,0 +1,214 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/consts"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	usercontext "go_ecommerce/internal/utils/context"
+	"go_ecommerce/internal/utils/crypto"
+	"go_ecommerce/internal/utils/loginaudit"
+	"go_ecommerce/internal/utils/oidc"
+	"go_ecommerce/pkg/response"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const oidcDefaultStateTTL = 10 * time.Minute
+
+func oidcStateTTL() time.Duration {
+	if ttl := global.Config.OIDC.StateTTLSeconds; ttl > 0 {
+		return time.Duration(ttl) * time.Second
+	}
+	return oidcDefaultStateTTL
+}
+
+// OIDCLoginStart returns the provider URL, state/nonce/verifier stay on the server
+func (s *sUserLogin) OIDCLoginStart(ctx context.Context, provider string) (codeResult int, out model.OIDCStartOutput, err error) {
+	p, err := oidc.Get(provider)
+	if err != nil {
+		return response.ErrCodeOIDCProviderUnknown, out, err
+	}
+	out.AuthURL, out.State, err = oidc.StartFlow(ctx, p, oidcStateTTL())
+	if err != nil {
+		return response.ErrCodeOIDCLoginFailed, out, err
+	}
+	return response.CodeSuccess, out, nil
+}
+
+// OIDCLoginCallback completes the provider login and issues our own tokens.
+// 2FA of the account still applies, the provider only replaces the password
+func (s *sUserLogin) OIDCLoginCallback(ctx context.Context, in *model.OIDCCallbackInput) (codeResult int, out model.LoginOutput, err error) {
+	// 1. the state is single use and bound to the provider it was started for
+	flow, err := oidc.TakeFlow(ctx, in.State)
+	if err != nil {
+		return response.ErrCodeOIDCStateInvalid, out, err
+	}
+	if flow.Provider != in.Provider {
+		return response.ErrCodeOIDCStateInvalid, out, oidc.ErrInvalidState
+	}
+	p, err := oidc.Get(flow.Provider)
+	if err != nil {
+		return response.ErrCodeOIDCProviderUnknown, out, err
+	}
+
+	method := loginaudit.MethodOIDC(p.Name)
+
+	// 2. code + verifier -> id token, checked against the provider keys and our nonce
+	rawIDToken, err := p.Exchange(ctx, in.Code, flow.CodeVerifier)
+	if err != nil {
+		s.auditLogin(ctx, 0, "", method, loginaudit.OutcomeFailed, in.LoginClient)
+		return response.ErrCodeOIDCLoginFailed, out, err
+	}
+	claims, err := p.VerifyIDToken(ctx, rawIDToken, flow.Nonce)
+	if err != nil {
+		s.auditLogin(ctx, 0, "", method, loginaudit.OutcomeFailed, in.LoginClient)
+		return response.ErrCodeOIDCLoginFailed, out, err
+	}
+
+	// 3. find, link or create the account
+	userId, err := s.oidcAccount(ctx, p.Name, claims)
+	if err != nil {
+		return response.ErrCodeOIDCLoginFailed, out, err
+	}
+	if codeResult, err = s.checkUserState(ctx, uint64(userId)); err != nil {
+		s.auditLogin(ctx, userId, claims.Email, method, loginOutcome(codeResult), in.LoginClient)
+		return codeResult, out, err
+	}
+	infoUser, err := s.r.GetUser(ctx, uint64(userId))
+	if err != nil {
+		return response.ErrCodeOIDCLoginFailed, out, err
+	}
+	userBase, err := s.r.GetOneUserInfo(ctx, infoUser.UserAccount)
+	if err != nil {
+		return response.ErrCodeOIDCLoginFailed, out, err
+	}
+
+	// 4. same as the password login from here
+	isTwoFactorEnable, err := s.r.IsTwoFactorEnabled(ctx, uint32(userBase.UserID))
+	if err != nil {
+		return response.ErrCodeAuthFailed, out, err
+	}
+	if isTwoFactorEnable > 0 {
+		codeResult, out, err = s.startLoginChallenge(ctx, userBase, false)
+		if err == nil {
+			s.auditLogin(ctx, userBase.UserID, userBase.UserAccount, method, loginaudit.OutcomeChallenge, in.LoginClient)
+		}
+		return codeResult, out, err
+	}
+	return s.completeLogin(ctx, userBase, method, in.LoginClient, usercontext.AuthLevelPassword, false)
+}
+
+// oidcAccount returns the user of the identity. An unknown identity is linked to the
+// account with the same verified email, otherwise a new account is created
+func (s *sUserLogin) oidcAccount(ctx context.Context, provider string, claims *oidc.Claims) (userId int32, err error) {
+	identity, err := s.r.GetUserIdentity(ctx, database.GetUserIdentityParams{
+		IdentityProvider: provider,
+		IdentitySubject:  claims.Subject,
+	})
+	if err == nil {
+		if err := s.r.TouchUserIdentity(ctx, identity.IdentityID); err != nil {
+			global.Logger.Error("touch user identity failed", zap.Uint32("identity_id", identity.IdentityID), zap.Error(err))
+		}
+		return int32(identity.UserID), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	// an unverified email must not take over the account that owns it
+	account := ""
+	if claims.EmailVerified && claims.Email != "" {
+		if account, err = normalizeAccount(claims.Email); err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := global.Mdbc.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := s.r.WithTx(tx)
+
+	if account != "" {
+		userBase, err := qtx.GetOneUserInfo(ctx, account)
+		if err == nil {
+			userId = userBase.UserID
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return 0, err
+		}
+	} else {
+		account = provider + ":" + claims.Subject
+	}
+
+	if userId == 0 {
+		if userId, err = s.oidcCreateUser(ctx, qtx, account, claims); err != nil {
+			return 0, err
+		}
+	}
+	err = qtx.AddUserIdentity(ctx, database.AddUserIdentityParams{
+		UserID:           uint32(userId),
+		IdentityProvider: provider,
+		IdentitySubject:  claims.Subject,
+		IdentityEmail:    sql.NullString{String: claims.Email, Valid: claims.Email != ""},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return userId, nil
+}
+
+// oidcCreateUser adds user_base and user_info, the password is random: the account logs in
+// with the provider until the user sets one through /user/password/forgot
+func (s *sUserLogin) oidcCreateUser(ctx context.Context, qtx *database.Queries, account string, claims *oidc.Claims) (int32, error) {
+	passwordHash, err := crypto.HashPassword(uuid.New().String())
+	if err != nil {
+		return 0, err
+	}
+	newUserBase, err := qtx.AddUserBase(ctx, database.AddUserBaseParams{
+		UserAccount:  account,
+		UserPassword: passwordHash,
+	})
+	if err != nil {
+		return 0, err
+	}
+	userId, err := newUserBase.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	nickname := claims.Name
+	if nickname == "" {
+		nickname = account
+	}
+	userEmail := sql.NullString{}
+	if claims.EmailVerified && claims.Email != "" {
+		userEmail = sql.NullString{String: account, Valid: true}
+	}
+	_, err = qtx.AddUserHaveUserId(ctx, database.AddUserHaveUserIdParams{
+		UserID:               uint64(userId),
+		UserAccount:          account,
+		UserNickname:         sql.NullString{String: nickname, Valid: true},
+		UserAvatar:           sql.NullString{String: claims.Picture, Valid: true},
+		UserState:            consts.UserStateActivated,
+		UserMobile:           sql.NullString{String: "", Valid: true},
+		UserGender:           sql.NullInt16{Int16: 0, Valid: true},
+		UserBirthday:         sql.NullTime{Time: time.Time{}, Valid: false},
+		UserEmail:            userEmail,
+		UserIsAuthentication: 1,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("add user info: %w", err)
+	}
+	return int32(userId), nil
+}
@@ -0,0 +1,497 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/utils/cache"
+	usercontext "go_ecommerce/internal/utils/context"
+	"go_ecommerce/internal/utils/loginaudit"
+	"go_ecommerce/internal/utils/session"
+	"go_ecommerce/internal/utils/webauthn"
+	"go_ecommerce/pkg/response"
+
+	"go.uber.org/zap"
+)
+
+const (
+	passkeyMaxPerUser     = 10
+	passkeyMaxNameLength  = 64
+	passkeyDefaultTimeout = 5 * time.Minute
+)
+
+// ---- PASSKEYS (WebAuthn) ----
+
+// BeginPasskeyRegistration returns the options for navigator.credentials.create.
+// A passkey logs in without the second factor, so once 2FA is on only a verified session may add one
+func (s *sUserLogin) BeginPasskeyRegistration(ctx context.Context, in *model.PasskeyRegisterInput) (codeResult int, out model.PasskeyOptionsOutput, err error) {
+	rp, err := webauthn.GetRelyingParty()
+	if err != nil {
+		return response.ErrCodePasskeyDisabled, out, err
+	}
+	if codeResult, err = s.checkPasskeyChangeAllowed(ctx, in.UserId, in.SubToken); err != nil {
+		return codeResult, out, err
+	}
+	passkeys, err := s.r.ListUserPasskeys(ctx, uint32(in.UserId))
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	if len(passkeys) >= passkeyMaxPerUser {
+		return response.ErrCodePasskeyLimit, out, fmt.Errorf("remove a passkey first, at most %d passkeys", passkeyMaxPerUser)
+	}
+	infoUser, err := s.r.GetUser(ctx, in.UserId)
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	displayName := infoUser.UserAccount
+	if infoUser.UserNickname.Valid && infoUser.UserNickname.String != "" {
+		displayName = infoUser.UserNickname.String
+	}
+
+	challenge, err := webauthn.StartCeremony(ctx, webauthn.Ceremony{
+		Purpose:   webauthn.PurposeRegister,
+		UserId:    in.UserId,
+		SessionId: session.ID(in.SubToken),
+	}, passkeyTimeout(rp))
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	// the same authenticator must not be registered twice
+	out.PublicKey = rp.CreationOptions(webauthn.User{
+		ID:          webauthn.UserHandle(in.UserId),
+		Name:        infoUser.UserAccount,
+		DisplayName: displayName,
+	}, challenge, passkeyDescriptors(passkeys))
+	return response.CodeSuccess, out, nil
+}
+
+// FinishPasskeyRegistration verifies the new credential and stores it with the user
+func (s *sUserLogin) FinishPasskeyRegistration(ctx context.Context, in *model.PasskeyRegisterInput) (codeResult int, out model.PasskeyOutput, err error) {
+	rp, err := webauthn.GetRelyingParty()
+	if err != nil {
+		return response.ErrCodePasskeyDisabled, out, err
+	}
+	name, err := passkeyName(in.Name, in.UserAgent)
+	if err != nil {
+		return response.ErrCodeParamInvalid, out, err
+	}
+	var resp webauthn.AttestationResponse
+	if err = json.Unmarshal(in.Credential, &resp); err != nil {
+		return response.ErrCodeParamInvalid, out, fmt.Errorf("credential is not a PublicKeyCredential")
+	}
+
+	// 1. the challenge in the signed client data selects the ceremony, it must be ours
+	clientData, _, err := webauthn.ParseClientData(resp.Response.ClientDataJSON)
+	if err != nil {
+		return response.ErrCodePasskeyVerifyFailed, out, err
+	}
+	c, err := webauthn.TakeCeremony(ctx, clientData.Challenge, webauthn.PurposeRegister)
+	if err != nil {
+		return response.ErrCodePasskeyChallengeInvalid, out, err
+	}
+	if c.UserId != in.UserId || c.SessionId != session.ID(in.SubToken) {
+		return response.ErrCodePasskeyChallengeInvalid, out, webauthn.ErrInvalidChallenge
+	}
+
+	// 2. user verification is not required here, a key without PIN can still be a second factor
+	cred, err := rp.VerifyRegistration(&resp, clientData.Challenge, false)
+	if err != nil {
+		return response.ErrCodePasskeyVerifyFailed, out, err
+	}
+	if _, err = s.r.GetPasskeyByCredentialId(ctx, cred.ID); err == nil {
+		return response.ErrCodePasskeyVerifyFailed, out, fmt.Errorf("passkey is already registered")
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return response.CodeFail, out, err
+	}
+	count, err := s.r.CountUserPasskeys(ctx, uint32(in.UserId))
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	if count >= passkeyMaxPerUser {
+		return response.ErrCodePasskeyLimit, out, fmt.Errorf("remove a passkey first, at most %d passkeys", passkeyMaxPerUser)
+	}
+
+	// 3. store
+	result, err := s.r.AddUserPasskey(ctx, database.AddUserPasskeyParams{
+		UserID:                uint32(in.UserId),
+		PasskeyCredentialID:   cred.ID,
+		PasskeyPublicKey:      cred.PublicKey,
+		PasskeyAlgorithm:      int32(cred.Alg),
+		PasskeySignCount:      cred.SignCount,
+		PasskeyAaguid:         cred.AAGUID,
+		PasskeyTransports:     strings.Join(cred.Transports, ","),
+		PasskeyName:           name,
+		PasskeyBackupEligible: cred.BackupEligible,
+	})
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	passkeyId, err := result.LastInsertId()
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	global.Logger.Info("passkey registered", zap.Uint64("user_id", in.UserId), zap.Int64("passkey_id", passkeyId))
+	return response.CodeSuccess, model.PasskeyOutput{
+		PasskeyId:  uint32(passkeyId),
+		Name:       name,
+		Synced:     cred.BackupEligible,
+		Transports: nonNilStrings(cred.Transports),
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+func (s *sUserLogin) ListPasskeys(ctx context.Context, userId uint64) (codeResult int, out []model.PasskeyOutput, err error) {
+	rows, err := s.r.ListUserPasskeys(ctx, uint32(userId))
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	out = make([]model.PasskeyOutput, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toPasskeyOutput(row))
+	}
+	return response.CodeSuccess, out, nil
+}
+
+// RemovePasskey deletes one passkey of the user, e.g. a lost phone
+func (s *sUserLogin) RemovePasskey(ctx context.Context, in *model.PasskeyInput) (codeResult int, err error) {
+	rows, err := s.r.RemoveUserPasskey(ctx, database.RemoveUserPasskeyParams{
+		PasskeyID: in.PasskeyId,
+		UserID:    uint32(in.UserId),
+	})
+	if err != nil {
+		return response.CodeFail, err
+	}
+	if rows == 0 {
+		return response.ErrCodePasskeyNotFound, fmt.Errorf("passkey not found")
+	}
+	global.Logger.Info("passkey removed", zap.Uint64("user_id", in.UserId), zap.Uint32("passkey_id", in.PasskeyId))
+	return response.CodeSuccess, nil
+}
+
+// BeginPasskeyLogin returns the options for navigator.credentials.get. With a challenge token
+// the passkey is the second factor of a password login, otherwise it is the only factor
+func (s *sUserLogin) BeginPasskeyLogin(ctx context.Context, in *model.PasskeyLoginStartInput) (codeResult int, out model.PasskeyOptionsOutput, err error) {
+	rp, err := webauthn.GetRelyingParty()
+	if err != nil {
+		return response.ErrCodePasskeyDisabled, out, err
+	}
+	c := webauthn.Ceremony{Purpose: webauthn.PurposeLogin}
+	userVerification := webauthn.UserVerificationRequired
+	var allow []webauthn.CredentialDescriptor
+
+	switch {
+	case in.ChallengeToken != "":
+		var challenge loginChallenge
+		if err = cache.GetCache(ctx, getLoginChallengeKey(in.ChallengeToken), &challenge); err != nil {
+			return response.ErrCodeTwoFactorChallengeInvalid, out, fmt.Errorf("challenge is invalid or expired")
+		}
+		passkeys, err := s.r.ListUserPasskeys(ctx, uint32(challenge.UserId))
+		if err != nil {
+			return response.CodeFail, out, err
+		}
+		if len(passkeys) == 0 {
+			return response.ErrCodePasskeyNotFound, out, fmt.Errorf("no passkey is registered")
+		}
+		// the password was the first factor, presence is enough
+		c = webauthn.Ceremony{Purpose: webauthn.PurposeSecondFactor, UserId: uint64(challenge.UserId), LoginChallenge: in.ChallengeToken}
+		userVerification = webauthn.UserVerificationPreferred
+		allow = passkeyDescriptors(passkeys)
+	case in.UserAccount != "":
+		// unknown accounts get the same answer as accounts without passkeys
+		account, err := normalizeAccount(in.UserAccount)
+		if err != nil {
+			break
+		}
+		userBase, err := s.r.GetOneUserInfo(ctx, account)
+		if err != nil {
+			break
+		}
+		passkeys, err := s.r.ListUserPasskeys(ctx, uint32(userBase.UserID))
+		if err != nil {
+			return response.CodeFail, out, err
+		}
+		allow = passkeyDescriptors(passkeys)
+	}
+
+	challenge, err := webauthn.StartCeremony(ctx, c, passkeyTimeout(rp))
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	out.PublicKey = rp.RequestOptions(challenge, allow, userVerification)
+	return response.CodeSuccess, out, nil
+}
+
+// FinishPasskeyLogin verifies the assertion and issues tokens. A passkey with user
+// verification is two factors in one, the session gets AuthLevelTwoFactor
+func (s *sUserLogin) FinishPasskeyLogin(ctx context.Context, in *model.PasskeyLoginInput) (codeResult int, out model.LoginOutput, err error) {
+	rp, err := webauthn.GetRelyingParty()
+	if err != nil {
+		return response.ErrCodePasskeyDisabled, out, err
+	}
+	var resp webauthn.AssertionResponse
+	if err = json.Unmarshal(in.Credential, &resp); err != nil {
+		return response.ErrCodeParamInvalid, out, fmt.Errorf("credential is not a PublicKeyCredential")
+	}
+	clientData, _, err := webauthn.ParseClientData(resp.Response.ClientDataJSON)
+	if err != nil {
+		return response.ErrCodePasskeyVerifyFailed, out, err
+	}
+	c, err := webauthn.TakeCeremony(ctx, clientData.Challenge, webauthn.PurposeLogin, webauthn.PurposeSecondFactor)
+	if err != nil {
+		return response.ErrCodePasskeyChallengeInvalid, out, err
+	}
+
+	// 1. check the assertion against the stored credential
+	method := loginaudit.MethodPasskey
+	passkey, codeResult, err := s.verifyPasskeyAssertion(ctx, rp, &resp, c, clientData.Challenge, c.Purpose == webauthn.PurposeLogin)
+	if err != nil {
+		s.auditLogin(ctx, int32(passkey.UserID), "", method, loginaudit.OutcomeFailed, in.LoginClient)
+		return codeResult, out, err
+	}
+
+	// 2. second factor: the password login challenge is consumed, only one request may win
+	authLevel, admin := usercontext.AuthLevelTwoFactor, false
+	if c.Purpose == webauthn.PurposeSecondFactor {
+		keyChallenge := getLoginChallengeKey(c.LoginChallenge)
+		var challenge loginChallenge
+		if err = cache.GetCache(ctx, keyChallenge, &challenge); err != nil || uint32(challenge.UserId) != passkey.UserID {
+			return response.ErrCodeTwoFactorChallengeInvalid, out, fmt.Errorf("challenge is invalid or expired")
+		}
+		deleted, err := global.Rdb.Del(ctx, keyChallenge).Result()
+		if err != nil {
+			return response.ErrCodeTwoFactorChallengeInvalid, out, err
+		}
+		if deleted == 0 {
+			return response.ErrCodeTwoFactorChallengeInvalid, out, fmt.Errorf("challenge has already been used")
+		}
+		if admin = challenge.Admin; admin {
+			method = loginaudit.MethodAdmin
+		}
+	}
+
+	// 3. issue tokens, same as the password login from here
+	infoUser, err := s.r.GetUser(ctx, uint64(passkey.UserID))
+	if err != nil {
+		return response.ErrCodeAuthFailed, out, err
+	}
+	userBase, err := s.r.GetOneUserInfo(ctx, infoUser.UserAccount)
+	if err != nil {
+		return response.ErrCodeAuthFailed, out, err
+	}
+	if codeResult, err = s.checkUserState(ctx, uint64(userBase.UserID)); err != nil {
+		s.auditLogin(ctx, userBase.UserID, userBase.UserAccount, method, loginOutcome(codeResult), in.LoginClient)
+		return codeResult, out, err
+	}
+	return s.completeLogin(ctx, userBase, method, in.LoginClient, authLevel, admin)
+}
+
+// BeginPasskeyStepUp asks for a passkey of the user to raise the level of the current session
+func (s *sUserLogin) BeginPasskeyStepUp(ctx context.Context, in *model.SessionInput) (codeResult int, out model.PasskeyOptionsOutput, err error) {
+	rp, err := webauthn.GetRelyingParty()
+	if err != nil {
+		return response.ErrCodePasskeyDisabled, out, err
+	}
+	passkeys, err := s.r.ListUserPasskeys(ctx, uint32(in.UserId))
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	if len(passkeys) == 0 {
+		return response.ErrCodePasskeyNotFound, out, fmt.Errorf("no passkey is registered")
+	}
+	challenge, err := webauthn.StartCeremony(ctx, webauthn.Ceremony{
+		Purpose:   webauthn.PurposeStepUp,
+		UserId:    in.UserId,
+		SessionId: session.ID(in.SubToken),
+	}, passkeyTimeout(rp))
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	out.PublicKey = rp.RequestOptions(challenge, passkeyDescriptors(passkeys), webauthn.UserVerificationPreferred)
+	return response.CodeSuccess, out, nil
+}
+
+// FinishPasskeyStepUp verifies the assertion, the session counts as second factor verified afterwards
+func (s *sUserLogin) FinishPasskeyStepUp(ctx context.Context, in *model.PasskeyStepUpInput) (codeResult int, err error) {
+	rp, err := webauthn.GetRelyingParty()
+	if err != nil {
+		return response.ErrCodePasskeyDisabled, err
+	}
+	var resp webauthn.AssertionResponse
+	if err = json.Unmarshal(in.Credential, &resp); err != nil {
+		return response.ErrCodeParamInvalid, fmt.Errorf("credential is not a PublicKeyCredential")
+	}
+	clientData, _, err := webauthn.ParseClientData(resp.Response.ClientDataJSON)
+	if err != nil {
+		return response.ErrCodePasskeyVerifyFailed, err
+	}
+	c, err := webauthn.TakeCeremony(ctx, clientData.Challenge, webauthn.PurposeStepUp)
+	if err != nil {
+		return response.ErrCodePasskeyChallengeInvalid, err
+	}
+	if c.UserId != in.UserId || c.SessionId != session.ID(in.SubToken) {
+		return response.ErrCodePasskeyChallengeInvalid, webauthn.ErrInvalidChallenge
+	}
+	if _, codeResult, err = s.verifyPasskeyAssertion(ctx, rp, &resp, c, clientData.Challenge, false); err != nil {
+		return codeResult, err
+	}
+	found, err := session.SetAuthLevel(ctx, in.SubToken, usercontext.AuthLevelTwoFactor)
+	if err != nil {
+		return response.ErrCodeSessionFailed, err
+	}
+	if !found {
+		return response.ErrCodeSessionNotFound, fmt.Errorf("session not found")
+	}
+	return response.CodeSuccess, nil
+}
+
+// verifyPasskeyAssertion finds the credential of the assertion, checks it belongs to the
+// user of the ceremony and moves its sign count forward. A counter that did not increase
+// means a cloned authenticator or a replay
+func (s *sUserLogin) verifyPasskeyAssertion(ctx context.Context, rp *webauthn.RelyingParty, resp *webauthn.AssertionResponse, c webauthn.Ceremony, challenge string, requireUserVerification bool) (passkey database.PreGoAccUserPasskey9999, codeResult int, err error) {
+	credentialId, err := webauthn.DecodeID(resp.RawID)
+	if err != nil {
+		return passkey, response.ErrCodePasskeyVerifyFailed, err
+	}
+	passkey, err = s.r.GetPasskeyByCredentialId(ctx, credentialId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return passkey, response.ErrCodePasskeyVerifyFailed, fmt.Errorf("%w: unknown credential", webauthn.ErrVerification)
+	}
+	if err != nil {
+		return passkey, response.CodeFail, err
+	}
+	if c.UserId != 0 && uint64(passkey.UserID) != c.UserId {
+		return passkey, response.ErrCodePasskeyVerifyFailed, fmt.Errorf("%w: credential belongs to another account", webauthn.ErrVerification)
+	}
+	// discoverable credentials return the user handle given at registration
+	if resp.Response.UserHandle != "" {
+		userHandle, err := webauthn.DecodeID(resp.Response.UserHandle)
+		if err != nil || string(userHandle) != string(webauthn.UserHandle(uint64(passkey.UserID))) {
+			return passkey, response.ErrCodePasskeyVerifyFailed, fmt.Errorf("%w: user handle does not match", webauthn.ErrVerification)
+		}
+	}
+
+	authData, err := rp.VerifyAssertion(resp, challenge, passkey.PasskeyPublicKey, passkey.PasskeySignCount, requireUserVerification)
+	if errors.Is(err, webauthn.ErrSignCount) {
+		global.Logger.Warn("security event: passkey sign count did not increase",
+			zap.Uint32("user_id", passkey.UserID),
+			zap.Uint32("passkey_id", passkey.PasskeyID),
+			zap.Uint32("stored", passkey.PasskeySignCount),
+			zap.Uint32("presented", authData.SignCount),
+		)
+		return passkey, response.ErrCodePasskeyVerifyFailed, err
+	}
+	if err != nil {
+		return passkey, response.ErrCodePasskeyVerifyFailed, err
+	}
+	rows, err := s.r.UpdatePasskeySignCount(ctx, database.UpdatePasskeySignCountParams{
+		PasskeySignCount:   authData.SignCount,
+		PasskeyID:          passkey.PasskeyID,
+		PasskeySignCount_2: authData.SignCount,
+	})
+	if err != nil {
+		return passkey, response.CodeFail, err
+	}
+	// authenticators without a counter always send 0, only a real counter can lose the race
+	if rows == 0 && authData.SignCount != 0 {
+		return passkey, response.ErrCodePasskeyVerifyFailed, webauthn.ErrSignCount
+	}
+	return passkey, response.CodeSuccess, nil
+}
+
+// checkPasskeyChangeAllowed: with 2FA enabled the session must have verified its second factor
+func (s *sUserLogin) checkPasskeyChangeAllowed(ctx context.Context, userId uint64, subToken string) (codeResult int, err error) {
+	isTwoFactorEnable, err := s.r.IsTwoFactorEnabled(ctx, uint32(userId))
+	if err != nil {
+		return response.CodeFail, err
+	}
+	if isTwoFactorEnable == 0 {
+		return response.CodeSuccess, nil
+	}
+	info, found, err := session.Get(ctx, subToken)
+	if err != nil {
+		return response.ErrCodeSessionFailed, err
+	}
+	if !found || info.AuthLevel < usercontext.AuthLevelTwoFactor {
+		return response.ErrCodeStepUpRequired, fmt.Errorf("verify this session with your second factor first")
+	}
+	return response.CodeSuccess, nil
+}
+
+// hasPasskeys tells a login waiting for its second factor that a passkey can complete it
+func (s *sUserLogin) hasPasskeys(ctx context.Context, userId int32) bool {
+	if _, err := webauthn.GetRelyingParty(); err != nil {
+		return false
+	}
+	count, err := s.r.CountUserPasskeys(ctx, uint32(userId))
+	return err == nil && count > 0
+}
+
+func passkeyTimeout(rp *webauthn.RelyingParty) time.Duration {
+	if rp.Timeout > 0 {
+		return rp.Timeout
+	}
+	return passkeyDefaultTimeout
+}
+
+// passkeyName: the given name or the device of the registering browser
+func passkeyName(name string, userAgent string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		name = session.DeviceFromUserAgent(userAgent)
+	}
+	if utf8.RuneCountInString(name) > passkeyMaxNameLength {
+		return "", fmt.Errorf("name is at most %d characters", passkeyMaxNameLength)
+	}
+	return name, nil
+}
+
+func passkeyDescriptors(passkeys []database.PreGoAccUserPasskey9999) []webauthn.CredentialDescriptor {
+	allow := make([]webauthn.CredentialDescriptor, 0, len(passkeys))
+	for _, p := range passkeys {
+		allow = append(allow, webauthn.CredentialDescriptor{
+			Type:       "public-key",
+			ID:         webauthn.EncodeID(p.PasskeyCredentialID),
+			Transports: splitTransports(p.PasskeyTransports),
+		})
+	}
+	return allow
+}
+
+func toPasskeyOutput(row database.PreGoAccUserPasskey9999) model.PasskeyOutput {
+	out := model.PasskeyOutput{
+		PasskeyId:  row.PasskeyID,
+		Name:       row.PasskeyName,
+		Synced:     row.PasskeyBackupEligible,
+		Transports: nonNilStrings(splitTransports(row.PasskeyTransports)),
+		CreatedAt:  row.PasskeyCreatedAt.Time,
+	}
+	if row.PasskeyLastUsedAt.Valid {
+		out.LastUsedAt = &row.PasskeyLastUsedAt.Time
+	}
+	return out
+}
+
+func splitTransports(transports string) []string {
+	if transports == "" {
+		return nil
+	}
+	return strings.Split(transports, ",")
+}
+
+func nonNilStrings(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// ---- END PASSKEYS ----
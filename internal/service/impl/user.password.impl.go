@@ -0,0 +1,209 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/consts"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/utils"
+	"go_ecommerce/internal/utils/crypto"
+	"go_ecommerce/internal/utils/otppolicy"
+	"go_ecommerce/internal/utils/random"
+	"go_ecommerce/internal/utils/sendto"
+	"go_ecommerce/internal/utils/session"
+	"go_ecommerce/pkg/response"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// ForgotPassword sends a reset-password OTP to the registered email or phone.
+// Unknown accounts get the same answer so the endpoint can't be used to enumerate users.
+func (s *sUserLogin) ForgotPassword(ctx context.Context, in *model.ForgotPasswordInput) (codeResult int, err error) {
+	account, err := normalizeAccount(in.UserAccount)
+	if err != nil {
+		return response.ErrCodeParamInvalid, err
+	}
+	isPhone := strings.HasPrefix(account, "+")
+	hashKey := crypto.GetHash(account)
+
+	// 1. check account
+	userFound, err := s.r.CheckUserBaseExists(ctx, account)
+	if err != nil {
+		return response.ErrCodeAuthFailed, err
+	}
+	if userFound == 0 {
+		return response.CodeSuccess, nil
+	}
+
+	// 2. resend cooldown / lock, answered like an unknown account
+	if err = otppolicy.ResetPassword.CheckSend(ctx, hashKey); err != nil {
+		global.Logger.Info("forgot password OTP not sent", zap.String("key_hash", hashKey), zap.Error(err))
+		return response.CodeSuccess, nil
+	}
+
+	// 3. save otp in redis and the verify record with its purpose
+	verifyType := consts.EMAIL
+	if isPhone {
+		verifyType = consts.MOBILE
+	}
+	otpNew := strconv.Itoa(random.GenerateSixDigiOtp())
+	err = global.Rdb.SetEx(ctx, utils.GetUserPurposeKey(hashKey, consts.VERIFY_PURPOSE_RESET_PASSWORD), otpNew, time.Duration(consts.TIME_OTP_RESET_PASSWORD)*time.Minute).Err()
+	if err != nil {
+		return response.ErrInvalidOtp, err
+	}
+	_, err = s.r.InsertOTPVerify(ctx, database.InsertOTPVerifyParams{
+		VerifyOtp:     otpNew,
+		VerifyKey:     account,
+		VerifyKeyHash: hashKey,
+		VerifyType:    sql.NullInt32{Int32: int32(verifyType), Valid: true},
+		VerifyPurpose: consts.VERIFY_PURPOSE_RESET_PASSWORD,
+	})
+	if err != nil {
+		return response.ErrInvalidOtp, err
+	}
+
+	// 4. send otp
+	if isPhone {
+		if err = sendto.SendTextSmsOtp(ctx, account, otpNew); err != nil {
+			return response.ErrSendSmsOtp, err
+		}
+		return response.CodeSuccess, nil
+	}
+	if err = sendto.SendTextEmailOtp([]string{account}, os.Getenv("SENDER_EMAIL"), otpNew); err != nil {
+		return response.ErrSendEmailOtp, err
+	}
+	return response.CodeSuccess, nil
+}
+
+// ResetPassword checks the reset-password OTP, stores the new hash and revokes every session
+func (s *sUserLogin) ResetPassword(ctx context.Context, in *model.ResetPasswordInput) (codeResult int, err error) {
+	account, err := normalizeAccount(in.UserAccount)
+	if err != nil {
+		return response.ErrCodeParamInvalid, err
+	}
+	hashKey := crypto.GetHash(account)
+	if err = validatePassword(in.NewPassword); err != nil {
+		return response.ErrCodeParamInvalid, err
+	}
+
+	// 1. lock check
+	if err = otppolicy.ResetPassword.CheckVerify(ctx, hashKey, in.ClientIp); err != nil {
+		return otpPolicyCode(err, response.ErrInvalidOtp), err
+	}
+
+	// 2. check otp in redis
+	keyOtp := utils.GetUserPurposeKey(hashKey, consts.VERIFY_PURPOSE_RESET_PASSWORD)
+	otpFound, err := global.Rdb.Get(ctx, keyOtp).Result()
+	if err == redis.Nil {
+		return response.ErrCodeOtpNotExists, fmt.Errorf("OTP is expired")
+	} else if err != nil {
+		return response.ErrInvalidOtp, err
+	}
+	if !otpMatch(otpFound, in.VerifyCode) {
+		return otpFailed(ctx, otppolicy.ResetPassword, hashKey, in.ClientIp, response.ErrInvalidOtp, fmt.Errorf("OTP not match"))
+	}
+
+	// 3. the verify record must be a pending reset-password one
+	verifyParams := database.GetValidOTPParams{VerifyKeyHash: hashKey, VerifyPurpose: consts.VERIFY_PURPOSE_RESET_PASSWORD}
+	infoOTP, err := s.r.GetValidOTP(ctx, verifyParams)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !otpMatch(infoOTP.VerifyOtp, in.VerifyCode)) {
+		return response.ErrCodeOtpNotExists, fmt.Errorf("reset password OTP not found")
+	} else if err != nil {
+		return response.ErrInvalidOtp, err
+	}
+	userBase, err := s.r.GetOneUserInfo(ctx, infoOTP.VerifyKey)
+	if err != nil {
+		return response.ErrCodeAuthFailed, err
+	}
+	newHash, err := crypto.HashPassword(in.NewPassword)
+	if err != nil {
+		return response.CodeFail, err
+	}
+
+	// 4. consume the otp and update the password together, an OTP resets the password once
+	tx, err := global.Mdbc.BeginTx(ctx, nil)
+	if err != nil {
+		return response.CodeFail, err
+	}
+	defer tx.Rollback()
+	qtx := s.r.WithTx(tx)
+	consumed, err := qtx.UsePendingVerification(ctx, database.UsePendingVerificationParams(verifyParams))
+	if err != nil {
+		return response.CodeFail, err
+	}
+	if consumed == 0 {
+		return response.ErrCodeOtpNotExists, fmt.Errorf("reset password OTP has already been used")
+	}
+	err = qtx.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		UserPassword: newHash,
+		UserSalt:     "", // salt is encoded in the hash
+		UserID:       userBase.UserID,
+	})
+	if err != nil {
+		return response.CodeFail, err
+	}
+	if err = tx.Commit(); err != nil {
+		return response.CodeFail, err
+	}
+
+	// 5. the otp is gone from redis too
+	global.Rdb.Del(ctx, keyOtp)
+	otppolicy.ResetPassword.Reset(ctx, hashKey)
+
+	// 6. whoever knew the old password must be logged out
+	if err = session.RevokeAll(ctx, uint64(userBase.UserID)); err != nil {
+		global.Logger.Error("revoke sessions after reset password failed", zap.Int32("user_id", userBase.UserID), zap.Error(err))
+		return response.ErrCodeSessionFailed, err
+	}
+	s.markLogout(ctx, userBase.UserAccount)
+	return response.CodeSuccess, nil
+}
+
+// validatePassword is the minimal policy for new passwords
+func validatePassword(password string) error {
+	if len(password) < consts.PASSWORD_MIN_LENGTH {
+		return fmt.Errorf("password must be at least %d characters", consts.PASSWORD_MIN_LENGTH)
+	}
+	return nil
+}
+
+// ChangePassword sets a new password for a logged-in user, the other sessions are revoked
+func (s *sUserLogin) ChangePassword(ctx context.Context, in *model.ChangePasswordInput) (codeResult int, err error) {
+	if err = validatePassword(in.NewPassword); err != nil {
+		return response.ErrCodeParamInvalid, err
+	}
+	if in.NewPassword == in.OldPassword {
+		return response.ErrCodeParamInvalid, fmt.Errorf("new password must be different from the current one")
+	}
+
+	// 1. check current password
+	userBase, err := s.r.GetOneUserInfo(ctx, in.UserAccount)
+	if err != nil {
+		return response.ErrCodeAuthFailed, err
+	}
+	if match, _ := crypto.MatchingPassword(userBase.UserPassword, in.OldPassword, userBase.UserSalt); !match {
+		return response.ErrCodeAuthFailed, fmt.Errorf("current password is incorrect")
+	}
+
+	// 2. update password
+	if _, err = s.rehashPassword(ctx, userBase.UserID, in.NewPassword); err != nil {
+		return response.CodeFail, err
+	}
+
+	// 3. keep the current session, log out the others
+	if err = session.RevokeOthers(ctx, in.UserId, in.SubToken); err != nil {
+		global.Logger.Error("revoke sessions after change password failed", zap.Int32("user_id", userBase.UserID), zap.Error(err))
+		return response.ErrCodeSessionFailed, err
+	}
+	return response.CodeSuccess, nil
+}
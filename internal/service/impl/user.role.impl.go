@@ -0,0 +1,110 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/repo"
+	"go_ecommerce/internal/utils/rbac"
+	"go_ecommerce/internal/utils/session"
+	"go_ecommerce/pkg/response"
+)
+
+type sUserRole struct {
+	r     *database.Queries
+	roles repo.IRoleRepository
+}
+
+func NewUserRoleImpl(r *database.Queries, roles repo.IRoleRepository) *sUserRole {
+	return &sUserRole{
+		r:     r,
+		roles: roles,
+	}
+}
+
+// GetRoles returns the assigned roles plus the implicit customer role
+func (s *sUserRole) GetRoles(ctx context.Context, userId uint64) ([]string, error) {
+	assigned, err := s.roles.GetUserRoles(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	roles := []string{rbac.RoleCustomer}
+	for _, role := range assigned {
+		if role != rbac.RoleCustomer {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+func (s *sUserRole) ListUserRoles(ctx context.Context, userId uint64) (codeResult int, out model.UserRolesOutput, err error) {
+	if _, err = s.r.GetUser(ctx, userId); err != nil {
+		return userLookupCode(err), out, err
+	}
+	return s.rolesOutput(ctx, userId)
+}
+
+// AssignRole gives a role to the user, cached permissions of its sessions are dropped
+func (s *sUserRole) AssignRole(ctx context.Context, in *model.UserRoleInput) (codeResult int, out model.UserRolesOutput, err error) {
+	if !rbac.IsRole(in.Role) || in.Role == rbac.RoleCustomer {
+		return response.ErrCodeRoleInvalid, out, fmt.Errorf("role %q can't be assigned", in.Role)
+	}
+	user, err := s.r.GetUser(ctx, in.UserId)
+	if err != nil {
+		return userLookupCode(err), out, err
+	}
+	if err = s.roles.AssignRole(ctx, in.UserId, user.UserAccount, in.Role); err != nil {
+		return response.CodeFail, out, err
+	}
+	if err = session.ClearRoles(ctx, in.UserId); err != nil {
+		return response.CodeFail, out, err
+	}
+	return s.rolesOutput(ctx, in.UserId)
+}
+
+// RemoveRole takes a role away from the user, cached permissions of its sessions are dropped
+func (s *sUserRole) RemoveRole(ctx context.Context, in *model.UserRoleInput) (codeResult int, out model.UserRolesOutput, err error) {
+	if !rbac.IsRole(in.Role) || in.Role == rbac.RoleCustomer {
+		return response.ErrCodeRoleInvalid, out, fmt.Errorf("role %q can't be removed", in.Role)
+	}
+	if _, err = s.r.GetUser(ctx, in.UserId); err != nil {
+		return userLookupCode(err), out, err
+	}
+	if err = s.roles.RemoveRole(ctx, in.UserId, in.Role); err != nil {
+		return response.CodeFail, out, err
+	}
+	if err = session.ClearRoles(ctx, in.UserId); err != nil {
+		return response.CodeFail, out, err
+	}
+	return s.rolesOutput(ctx, in.UserId)
+}
+
+// RemoveAllRoles drops every role of the user, used when the account is removed
+func (s *sUserRole) RemoveAllRoles(ctx context.Context, userId uint64) error {
+	if err := s.roles.RemoveUser(ctx, userId); err != nil {
+		return err
+	}
+	return session.ClearRoles(ctx, userId)
+}
+
+func (s *sUserRole) rolesOutput(ctx context.Context, userId uint64) (codeResult int, out model.UserRolesOutput, err error) {
+	roles, err := s.GetRoles(ctx, userId)
+	if err != nil {
+		return response.CodeFail, out, err
+	}
+	out.UserId = userId
+	out.Roles = roles
+	out.Permissions = rbac.Permissions(roles)
+	return response.CodeSuccess, out, nil
+}
+
+func userLookupCode(err error) int {
+	if errors.Is(err, sql.ErrNoRows) {
+		return response.ErrCodeUserNotFound
+	}
+	return response.CodeFail
+}
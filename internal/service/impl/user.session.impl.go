@@ -0,0 +1,73 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/utils/session"
+	"go_ecommerce/pkg/response"
+
+	"go.uber.org/zap"
+)
+
+// Logout revokes the session of the current request
+func (s *sUserLogin) Logout(ctx context.Context, in *model.SessionInput) (codeResult int, err error) {
+	if err = session.Revoke(ctx, in.UserId, in.SubToken); err != nil {
+		return response.ErrCodeSessionFailed, err
+	}
+	s.markLogout(ctx, in.UserAccount)
+	return response.CodeSuccess, nil
+}
+
+// LogoutAll revokes every session of the user, including the current one
+func (s *sUserLogin) LogoutAll(ctx context.Context, in *model.SessionInput) (codeResult int, err error) {
+	if err = session.RevokeAll(ctx, in.UserId); err != nil {
+		return response.ErrCodeSessionFailed, err
+	}
+	s.markLogout(ctx, in.UserAccount)
+	return response.CodeSuccess, nil
+}
+
+// ListSessions returns the active sessions of the user
+func (s *sUserLogin) ListSessions(ctx context.Context, in *model.SessionInput) (codeResult int, out []model.SessionOutput, err error) {
+	sessions, err := session.List(ctx, in.UserId)
+	if err != nil {
+		return response.ErrCodeSessionFailed, out, err
+	}
+	currentId := session.ID(in.SubToken)
+	out = make([]model.SessionOutput, 0, len(sessions))
+	for _, item := range sessions {
+		out = append(out, model.SessionOutput{
+			SessionId:  item.SessionId,
+			Device:     item.Device,
+			ClientIp:   item.ClientIp,
+			UserAgent:  item.UserAgent,
+			CreatedAt:  time.Unix(item.CreatedAt, 0),
+			LastSeenAt: time.Unix(item.LastSeenAt, 0),
+			Current:    item.SessionId == currentId,
+		})
+	}
+	return response.CodeSuccess, out, nil
+}
+
+// RevokeSession revokes one session by its public ID, e.g. a shared tablet
+func (s *sUserLogin) RevokeSession(ctx context.Context, in *model.SessionInput) (codeResult int, err error) {
+	found, err := session.RevokeByID(ctx, in.UserId, in.SessionId)
+	if err != nil {
+		return response.ErrCodeSessionFailed, err
+	}
+	if !found {
+		return response.ErrCodeSessionNotFound, fmt.Errorf("session %s not found", in.SessionId)
+	}
+	return response.CodeSuccess, nil
+}
+
+// markLogout records user_logout_time, failure must not block the logout itself
+func (s *sUserLogin) markLogout(ctx context.Context, userAccount string) {
+	if err := s.r.LogoutUserBase(ctx, userAccount); err != nil {
+		global.Logger.Error("update logout time failed", zap.String("user_account", userAccount), zap.Error(err))
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"go_ecommerce/internal/model"
+)
+
+type (
+	IUserRole interface {
+		// GetRoles returns every role of the user, customer included
+		GetRoles(ctx context.Context, userId uint64) ([]string, error)
+		ListUserRoles(ctx context.Context, userId uint64) (codeResult int, out model.UserRolesOutput, err error)
+		AssignRole(ctx context.Context, in *model.UserRoleInput) (codeResult int, out model.UserRolesOutput, err error)
+		RemoveRole(ctx context.Context, in *model.UserRoleInput) (codeResult int, out model.UserRolesOutput, err error)
+		// RemoveAllRoles drops every assignment of a removed user
+		RemoveAllRoles(ctx context.Context, userId uint64) error
+	}
+)
+
+var (
+	localUserRole IUserRole
+)
+
+func UserRole() IUserRole {
+	if localUserRole == nil {
+		panic("implement localUserRole not found for interface IUserRole")
+	}
+	return localUserRole
+}
+
+func InitUserRole(i IUserRole) {
+	localUserRole = i
+}
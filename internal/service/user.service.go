@@ -9,25 +9,73 @@ type (
 	IUserLogin interface {
 		RefreshToken(ctx context.Context, in *model.RefreshTokenInput) (codeResult int, out model.LoginOutput, err error)
 		Login(ctx context.Context, in *model.LoginInput) (codeResult int, out model.LoginOutput, err error)
+		VerifyLoginTwoFactor(ctx context.Context, in *model.LoginTwoFactorInput) (codeResult int, out model.LoginOutput, err error)
 		Register(ctx context.Context, in *model.RegisterInput) (codeResult int, err error)
-		VerifyOTP(ctx context.Context, in *model.VerifyInput) (out model.VerifyOTPOutput, err error)
+		VerifyOTP(ctx context.Context, in *model.VerifyInput) (codeResult int, out model.VerifyOTPOutput, err error)
 		UpdatePasswordRegister(ctx context.Context, token string, password string) (userId int, err error)
+		// social login: authorization code + PKCE, creates or links the account
+		OIDCLoginStart(ctx context.Context, provider string) (codeResult int, out model.OIDCStartOutput, err error)
+		OIDCLoginCallback(ctx context.Context, in *model.OIDCCallbackInput) (codeResult int, out model.LoginOutput, err error)
+
+		// password recovery
+		ForgotPassword(ctx context.Context, in *model.ForgotPasswordInput) (codeResult int, err error)
+		ResetPassword(ctx context.Context, in *model.ResetPasswordInput) (codeResult int, err error)
+		ChangePassword(ctx context.Context, in *model.ChangePasswordInput) (codeResult int, err error)
+		// change login email, the new address is verified by OTP
+		RequestChangeEmail(ctx context.Context, in *model.ChangeEmailInput) (codeResult int, err error)
+		ConfirmChangeEmail(ctx context.Context, in *model.ConfirmChangeEmailInput) (codeResult int, out model.ChangeEmailOutput, err error)
+
+		// sessions
+		Logout(ctx context.Context, in *model.SessionInput) (codeResult int, err error)
+		LogoutAll(ctx context.Context, in *model.SessionInput) (codeResult int, err error)
+		ListSessions(ctx context.Context, in *model.SessionInput) (codeResult int, out []model.SessionOutput, err error)
+		RevokeSession(ctx context.Context, in *model.SessionInput) (codeResult int, err error)
+		// login attempts of the user, failed ones included
+		LoginHistory(ctx context.Context, in *model.LoginHistoryInput) (codeResult int, out model.LoginHistoryOutput, err error)
+
+		// passkeys (WebAuthn): registration, primary login or second factor, and step-up of the current session
+		BeginPasskeyRegistration(ctx context.Context, in *model.PasskeyRegisterInput) (codeResult int, out model.PasskeyOptionsOutput, err error)
+		FinishPasskeyRegistration(ctx context.Context, in *model.PasskeyRegisterInput) (codeResult int, out model.PasskeyOutput, err error)
+		ListPasskeys(ctx context.Context, userId uint64) (codeResult int, out []model.PasskeyOutput, err error)
+		RemovePasskey(ctx context.Context, in *model.PasskeyInput) (codeResult int, err error)
+		BeginPasskeyLogin(ctx context.Context, in *model.PasskeyLoginStartInput) (codeResult int, out model.PasskeyOptionsOutput, err error)
+		FinishPasskeyLogin(ctx context.Context, in *model.PasskeyLoginInput) (codeResult int, out model.LoginOutput, err error)
+		BeginPasskeyStepUp(ctx context.Context, in *model.SessionInput) (codeResult int, out model.PasskeyOptionsOutput, err error)
+		FinishPasskeyStepUp(ctx context.Context, in *model.PasskeyStepUpInput) (codeResult int, err error)
 
 		// two-factor authentication
 		IsTwoFactorEnabled(ctx context.Context, userId int) (codeResult int, rs bool, err error)
 		// setup authentication
-		SetupTwoFactorAuth(ctx context.Context, in *model.SetupTwoFactorAuthInput) (codeResult int, err error)
+		SetupTwoFactorAuth(ctx context.Context, in *model.SetupTwoFactorAuthInput) (codeResult int, out model.SetupTwoFactorAuthOutput, err error)
 
 		// Verify Two Factor Authentication
-		VerifyTwoFactorAuth(ctx context.Context, in *model.TwoFactorVerificationInput) (codeResult int, err error)
+		VerifyTwoFactorAuth(ctx context.Context, in *model.TwoFactorVerificationInput) (codeResult int, out model.TwoFactorVerificationOutput, err error)
+		// Regenerate recovery codes for authenticator app
+		RegenerateRecoveryCodes(ctx context.Context, in *model.RegenerateRecoveryCodesInput) (codeResult int, out model.TwoFactorVerificationOutput, err error)
 	}
 	IUserInfo interface {
-		GetInfoByUserId(ctx context.Context) error
-		GetAllUser(ctx context.Context) error
+		// profile of the logged in user, listing users is IUserAdmin.FindUsers
+		GetInfoByUserId(ctx context.Context, userId uint64) (codeResult int, out model.UserInfoOutput, err error)
+		UpdateUserInfo(ctx context.Context, in *model.UpdateUserInfoInput) (codeResult int, out model.UserInfoOutput, err error)
+		// personal data export, built in the background and downloaded as a ZIP
+		RequestExport(ctx context.Context, userId uint64) (codeResult int, out model.DataExportOutput, err error)
+		GetExport(ctx context.Context, userId uint64) (codeResult int, out model.DataExportOutput, err error)
+		DownloadExport(ctx context.Context, userId uint64) (codeResult int, archive []byte, err error)
+		// account deletion: scheduled after a grace period, then PurgeDeletedAccounts anonymizes it
+		DeleteAccount(ctx context.Context, in *model.DeleteAccountInput) (codeResult int, out model.DeleteAccountOutput, err error)
+		PurgeDeletedAccounts(ctx context.Context) (purged int, err error)
 	}
 	IUserAdmin interface {
-		RemoveUser(ctx context.Context) error
-		FindOneUser(ctx context.Context) error
+		// admin login, two-factor is mandatory; completed by IUserLogin.VerifyLoginTwoFactor
+		Login(ctx context.Context, in *model.LoginInput) (codeResult int, out model.LoginOutput, err error)
+		RegisterUser(ctx context.Context, in *model.AdminRegisterUserInput) (codeResult int, out model.UserInfoOutput, err error)
+		FindUsers(ctx context.Context, in *model.FindUsersInput) (codeResult int, out model.UserListOutput, err error)
+		FindOneUser(ctx context.Context, userId uint64) (codeResult int, out model.UserInfoOutput, err error)
+		// user_state transitions, locking revokes every session of the user
+		ActivateUser(ctx context.Context, in *model.AdminUserInput) (codeResult int, out model.UserInfoOutput, err error)
+		LockUser(ctx context.Context, in *model.AdminUserInput) (codeResult int, out model.UserInfoOutput, err error)
+		UnlockUser(ctx context.Context, in *model.AdminUserInput) (codeResult int, out model.UserInfoOutput, err error)
+		RemoveUser(ctx context.Context, in *model.AdminUserInput) (codeResult int, err error)
 	}
 )
 
@@ -0,0 +1,167 @@
+package accountdata
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go_ecommerce/global"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	StatusPending = "pending"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+
+	// a pending job left by a crashed instance unblocks after this
+	pendingTTL = 30 * time.Minute
+)
+
+var (
+	ErrTooSoon  = errors.New("a data export was requested recently")
+	ErrNotReady = errors.New("no data export is ready for download")
+)
+
+// Job is the state of the last export of a user
+type Job struct {
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	ReadyAt     *time.Time `json:"ready_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // the archive is deleted after this
+	Size        int        `json:"size,omitempty"`       // bytes
+}
+
+// Section is one file of the archive, <Name>.json
+type Section struct {
+	Name string
+	Data interface{}
+}
+
+type manifest struct {
+	UserId      uint64    `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+func getJobKey(userId uint64) string {
+	return fmt.Sprintf("export:job:%d", userId)
+}
+
+func getFileKey(userId uint64) string {
+	return fmt.Sprintf("export:file:%d", userId)
+}
+
+func getCooldownKey(userId uint64) string {
+	return fmt.Sprintf("export:cooldown:%d", userId)
+}
+
+// Start records a pending job, ErrTooSoon while the cooldown of the previous request runs
+func Start(ctx context.Context, userId uint64, cooldown time.Duration) (Job, error) {
+	job := Job{Status: StatusPending, RequestedAt: time.Now().UTC()}
+	ok, err := global.Rdb.SetNX(ctx, getCooldownKey(userId), job.RequestedAt.Unix(), cooldown).Result()
+	if err != nil {
+		return job, err
+	}
+	if !ok {
+		return job, ErrTooSoon
+	}
+	return job, save(ctx, userId, job, pendingTTL)
+}
+
+// Finish stores the archive, it can be downloaded until ttl runs out
+func Finish(ctx context.Context, userId uint64, job Job, archive []byte, ttl time.Duration) (Job, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl)
+	job.Status, job.ReadyAt, job.ExpiresAt, job.Size = StatusReady, &now, &expiresAt, len(archive)
+	jobJson, err := json.Marshal(job)
+	if err != nil {
+		return job, err
+	}
+	_, err = global.Rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, getFileKey(userId), archive, ttl)
+		pipe.Set(ctx, getJobKey(userId), jobJson, ttl)
+		return nil
+	})
+	return job, err
+}
+
+// Fail marks the job failed and lets the user ask again right away
+func Fail(ctx context.Context, userId uint64, job Job) error {
+	job.Status = StatusFailed
+	global.Rdb.Del(ctx, getCooldownKey(userId))
+	return save(ctx, userId, job, pendingTTL)
+}
+
+// Get returns the last job, found is false when there is none or it expired
+func Get(ctx context.Context, userId uint64) (job Job, found bool, err error) {
+	rs, err := global.Rdb.Get(ctx, getJobKey(userId)).Bytes()
+	if err == redis.Nil {
+		return job, false, nil
+	}
+	if err != nil {
+		return job, false, err
+	}
+	if err = json.Unmarshal(rs, &job); err != nil {
+		return job, false, err
+	}
+	return job, true, nil
+}
+
+// Archive returns the ZIP of the last ready job
+func Archive(ctx context.Context, userId uint64) ([]byte, error) {
+	rs, err := global.Rdb.Get(ctx, getFileKey(userId)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotReady
+	}
+	return rs, err
+}
+
+// Remove drops the job and the archive, e.g. when the account is deleted
+func Remove(ctx context.Context, userId uint64) error {
+	return global.Rdb.Del(ctx, getJobKey(userId), getFileKey(userId), getCooldownKey(userId)).Err()
+}
+
+// Build writes each section as indented JSON into a ZIP, manifest.json lists the files
+func Build(userId uint64, sections []Section, generatedAt time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	m := manifest{UserId: userId, GeneratedAt: generatedAt.UTC()}
+	for _, section := range sections {
+		name := section.Name + ".json"
+		if err := writeJSON(zw, name, section.Data, generatedAt); err != nil {
+			return nil, err
+		}
+		m.Files = append(m.Files, name)
+	}
+	if err := writeJSON(zw, "manifest.json", m, generatedAt); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSON(zw *zip.Writer, name string, data interface{}, modified time.Time) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+func save(ctx context.Context, userId uint64, job Job, ttl time.Duration) error {
+	jobJson, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return global.Rdb.Set(ctx, getJobKey(userId), jobJson, ttl).Err()
+}
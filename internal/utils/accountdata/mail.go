@@ -0,0 +1,32 @@
+package accountdata
+
+import (
+	"fmt"
+	"time"
+
+	"go_ecommerce/internal/utils/sendto"
+)
+
+const mailTimeLayout = "2006-01-02 15:04 MST"
+
+// ExportReadyMail tells the user the archive can be downloaded, the archive itself is never mailed
+func ExportReadyMail(to string, expiresAt time.Time) *sendto.Mail {
+	return &sendto.Mail{
+		To:      []string{to},
+		Subject: "Your GN Farm data export is ready",
+		Body: fmt.Sprintf("The copy of your data you asked for is ready. Sign in and download it from your account settings before %s.\n\n"+
+			"If you did not ask for it, change your password.", expiresAt.UTC().Format(mailTimeLayout)),
+	}
+}
+
+// DeletionScheduledMail confirms a deletion request and how to cancel it
+func DeletionScheduledMail(to string, scheduledAt time.Time) *sendto.Mail {
+	return &sendto.Mail{
+		To:      []string{to},
+		Subject: "Your GN Farm account will be deleted",
+		Body: fmt.Sprintf("Your account is scheduled for deletion on %s and you have been signed out everywhere.\n\n"+
+			"To keep your account, simply sign in again before that date. After it, your personal data is erased; "+
+			"order records are kept without your name or contact details, as required for accounting.",
+			scheduledAt.UTC().Format(mailTimeLayout)),
+	}
+}
@@ -0,0 +1,137 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"go_ecommerce/internal/utils/rbac"
+)
+
+// A key looks like gnk_1a2b3c4d.<secret>: the prefix is stored in clear and used
+// for the lookup, only the SHA-256 of the secret is stored.
+
+var (
+	ErrInvalidKey   = errors.New("api key is invalid, expired or revoked")
+	ErrIPNotAllowed = errors.New("api key is not allowed from this address")
+)
+
+const (
+	prefixTag    = "gnk_"
+	PrefixLength = len(prefixTag) + 8
+	secretBytes  = 32
+)
+
+// scopes a key may be given, a key never gets more than its owner's roles grant
+var scopes = []string{
+	rbac.PermProductRead,
+	rbac.PermProductCreate,
+	rbac.PermProductUpdate,
+	rbac.PermProductPublish,
+	rbac.PermInventoryWrite,
+}
+
+// Scopes lists the scopes a key may be given
+func Scopes() []string {
+	return append([]string(nil), scopes...)
+}
+
+// IsScope reports whether scope can be given to a key
+func IsScope(scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Generate returns a new key and its parts, the key is shown to the owner once
+func Generate() (prefix string, secret string, key string, err error) {
+	b := make([]byte, 4+secretBytes)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	prefix = prefixTag + hex.EncodeToString(b[:4])
+	secret = base64.RawURLEncoding.EncodeToString(b[4:])
+	return prefix, secret, prefix + "." + secret, nil
+}
+
+// Parse splits a key into prefix and secret
+func Parse(key string) (prefix string, secret string, ok bool) {
+	if len(key) <= PrefixLength+1 || !strings.HasPrefix(key, prefixTag) || key[PrefixLength] != '.' {
+		return "", "", false
+	}
+	return key[:PrefixLength], key[PrefixLength+1:], true
+}
+
+// HashSecret is the stored form of the secret
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// MatchSecret compares the secret with the stored hash in constant time
+func MatchSecret(hash string, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(HashSecret(secret))) == 1
+}
+
+// NormalizeAllowedIPs validates IPs / CIDRs and returns the stored, comma separated form
+func NormalizeAllowedIPs(entries []string) (string, error) {
+	out := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return "", fmt.Errorf("invalid CIDR %q", entry)
+			}
+			out = append(out, ipNet.String())
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return "", fmt.Errorf("invalid IP %q", entry)
+		}
+		out = append(out, ip.String())
+	}
+	return strings.Join(out, ","), nil
+}
+
+// AllowsIP reports whether ip is in the stored allowlist, an empty list allows any address
+func AllowsIP(allowed string, ip string) bool {
+	if allowed == "" {
+		return true
+	}
+	clientIP := net.ParseIP(ip)
+	if clientIP == nil {
+		return false
+	}
+	for _, entry := range strings.Split(allowed, ",") {
+		if strings.Contains(entry, "/") {
+			if _, ipNet, err := net.ParseCIDR(entry); err == nil && ipNet.Contains(clientIP) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(clientIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitList reads a stored comma separated list
+func SplitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
@@ -16,3 +16,15 @@ func ExtractBearerToken(c *gin.Context) (string, bool) {
 	}
 	return "", false
 }
+
+// ExtractApiKey reads a shop API key: X-API-Key header or Authorization: ApiKey <key>
+func ExtractApiKey(c *gin.Context) (string, bool) {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key, true
+	}
+	authHeader := c.GetHeader("Authorization")
+	if strings.HasPrefix(authHeader, "ApiKey ") {
+		return strings.TrimPrefix(authHeader, "ApiKey "), true
+	}
+	return "", false
+}
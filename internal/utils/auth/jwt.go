@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"errors"
+	"fmt"
 	"go_ecommerce/global"
 	"time"
 
@@ -12,9 +14,43 @@ type PayloadClaims struct {
 	jwt.StandardClaims
 }
 
+// refresh tokens carry this audience so they can't be used as access tokens
+const refreshTokenAudience = "refresh"
+
+// GenTokenJWT signs with the active key of the key set, its id goes in the kid header
 func GenTokenJWT(payload jwt.Claims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-	return token.SignedString([]byte(global.Config.JWT.API_SECRET_KEY))
+	key, err := currentKeySet().Active()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.method(), payload)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.Private)
+}
+
+// verificationKey picks the key for a token by its kid, the algorithm must be the key's own
+func verificationKey(jwtToken *jwt.Token) (interface{}, error) {
+	if _, ok := jwtToken.Method.(*jwt.SigningMethodHMAC); ok {
+		// tokens issued before the switch to asymmetric keys
+		if global.Config.JWT.ACCEPT_LEGACY_HS256 && global.Config.JWT.API_SECRET_KEY != "" {
+			return []byte(global.Config.JWT.API_SECRET_KEY), nil
+		}
+		return nil, errors.New("HS256 tokens are no longer accepted")
+	}
+	kid, _ := jwtToken.Header["kid"].(string)
+	now := time.Now()
+	key, ok := currentKeySet().Lookup(kid, now)
+	if !ok {
+		return nil, ErrUnknownKid
+	}
+	if jwtToken.Method.Alg() != key.Alg {
+		return nil, fmt.Errorf("key %s does not sign with %s", kid, jwtToken.Method.Alg())
+	}
+	// a retired key must not have signed anything after its retirement
+	if claims, ok := jwtToken.Claims.(*jwt.StandardClaims); ok && !key.RetiredAt.IsZero() && claims.IssuedAt > key.RetiredAt.Unix() {
+		return nil, fmt.Errorf("key %s is retired", kid)
+	}
+	return key.Public, nil
 }
 func CreateToken(uuidToken string, duration string) (string, error) {
 	// Set thời gian hết hạn theo tham số duration
@@ -35,10 +71,29 @@ func CreateToken(uuidToken string, duration string) (string, error) {
 	})
 }
 
-func ParseJwtTokenSubject(token string) (*jwt.StandardClaims, error) {
-	tokenClaims, err := jwt.ParseWithClaims(token, &jwt.StandardClaims{}, func(jwtToken *jwt.Token) (interface{}, error) {
-		return []byte(global.Config.JWT.API_SECRET_KEY), nil
+// CreateRefreshToken returns the refresh token and its id (jti), the id identifies it in its token family
+func CreateRefreshToken(uuidToken string, duration string) (token string, jti string, err error) {
+	expiration, err := time.ParseDuration(duration)
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	jti = uuid.New().String()
+	token, err = GenTokenJWT(&PayloadClaims{
+		StandardClaims: jwt.StandardClaims{
+			Audience:  refreshTokenAudience,
+			Id:        jti,
+			ExpiresAt: now.Add(expiration).Unix(),
+			IssuedAt:  now.Unix(),
+			Issuer:    "go_ecommerce_sq",
+			Subject:   uuidToken,
+		},
 	})
+	return token, jti, err
+}
+
+func ParseJwtTokenSubject(token string) (*jwt.StandardClaims, error) {
+	tokenClaims, err := jwt.ParseWithClaims(token, &jwt.StandardClaims{}, verificationKey)
 
 	if tokenClaims != nil {
 		if claims, ok := tokenClaims.Claims.(*jwt.StandardClaims); ok && tokenClaims.Valid {
@@ -59,5 +114,23 @@ func VerifyTokenSubject(token string) (*jwt.StandardClaims, error) {
 	if err = claims.Valid(); err != nil {
 		return nil, err
 	}
+	if claims.Audience == refreshTokenAudience {
+		return nil, errors.New("refresh token cannot be used as access token")
+	}
+	return claims, nil
+}
+
+// VerifyRefreshToken validates a token created by CreateRefreshToken
+func VerifyRefreshToken(token string) (*jwt.StandardClaims, error) {
+	claims, err := ParseJwtTokenSubject(token)
+	if err != nil {
+		return nil, err
+	}
+	if err = claims.Valid(); err != nil {
+		return nil, err
+	}
+	if claims.Audience != refreshTokenAudience || claims.Id == "" || claims.Subject == "" {
+		return nil, errors.New("token is not a refresh token")
+	}
 	return claims, nil
 }
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Tokens are signed with the active key of the key set, the key id goes in
+// the "kid" header. Retired keys only verify: tokens they signed stay valid
+// until they expire, and they are published in the JWKS until then so other
+// services (farm-dashboard) can verify without holding a private key.
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no active signing key")
+	ErrUnknownKid   = errors.New("token is signed with an unknown key")
+)
+
+// SigningKey is one entry of the key set, Private is nil for verify-only keys
+type SigningKey struct {
+	Kid       string
+	Alg       string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+	RetiredAt time.Time // zero = in use
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Alg == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// KeySet holds the signing keys by kid
+type KeySet struct {
+	ActiveKid string
+	// longest lifetime of a token we issue, retired keys are dropped after RetiredAt + MaxTokenTTL
+	MaxTokenTTL time.Duration
+	keys        map[string]*SigningKey
+}
+
+func NewKeySet(activeKid string, maxTokenTTL time.Duration) *KeySet {
+	return &KeySet{ActiveKid: activeKid, MaxTokenTTL: maxTokenTTL, keys: map[string]*SigningKey{}}
+}
+
+// Add registers a key, the public key is derived from the private one when missing
+func (ks *KeySet) Add(k *SigningKey) error {
+	if k.Kid == "" {
+		return errors.New("signing key has no kid")
+	}
+	if k.Public == nil && k.Private != nil {
+		k.Public = k.Private.Public()
+	}
+	switch k.Public.(type) {
+	case *rsa.PublicKey:
+		if k.Alg != AlgRS256 {
+			return fmt.Errorf("key %s: RSA key can't be used with %s", k.Kid, k.Alg)
+		}
+	case ed25519.PublicKey:
+		if k.Alg != AlgEdDSA {
+			return fmt.Errorf("key %s: Ed25519 key can't be used with %s", k.Kid, k.Alg)
+		}
+	default:
+		return fmt.Errorf("key %s: unsupported public key type %T", k.Kid, k.Public)
+	}
+	ks.keys[k.Kid] = k
+	return nil
+}
+
+// Active returns the key new tokens are signed with
+func (ks *KeySet) Active() (*SigningKey, error) {
+	k, ok := ks.keys[ks.ActiveKid]
+	if !ok || k.Private == nil || !k.RetiredAt.IsZero() {
+		return nil, ErrNoSigningKey
+	}
+	return k, nil
+}
+
+// Lookup returns a key that may still verify tokens at now
+func (ks *KeySet) Lookup(kid string, now time.Time) (*SigningKey, bool) {
+	k, ok := ks.keys[kid]
+	if !ok || ks.expired(k, now) {
+		return nil, false
+	}
+	return k, true
+}
+
+func (ks *KeySet) expired(k *SigningKey, now time.Time) bool {
+	return !k.RetiredAt.IsZero() && now.After(k.RetiredAt.Add(ks.MaxTokenTTL))
+}
+
+// JWK is a public key in RFC 7517 form
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public keys that can still verify tokens, active key first
+func (ks *KeySet) JWKS(now time.Time) JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if k, ok := ks.keys[ks.ActiveKid]; ok {
+		set.Keys = append(set.Keys, toJWK(k))
+	}
+	for kid, k := range ks.keys {
+		if kid == ks.ActiveKid || ks.expired(k, now) {
+			continue
+		}
+		set.Keys = append(set.Keys, toJWK(k))
+	}
+	return set
+}
+
+func toJWK(k *SigningKey) JWK {
+	jwk := JWK{Use: "sig", Alg: k.Alg, Kid: k.Kid}
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// PublicKey converts a JWK back to a key, used by services verifying our tokens
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 JWK")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported JWK type %s", j.Kty)
+}
+
+var (
+	keySetMu sync.RWMutex
+	keySet   = NewKeySet("", 0)
+)
+
+// SetKeySet replaces the key set used to sign and verify tokens
+func SetKeySet(ks *KeySet) {
+	keySetMu.Lock()
+	defer keySetMu.Unlock()
+	keySet = ks
+}
+
+func currentKeySet() *KeySet {
+	keySetMu.RLock()
+	defer keySetMu.RUnlock()
+	return keySet
+}
+
+// JWKS is served on /.well-known/jwks.json
+func JWKS() JWKSet {
+	return currentKeySet().JWKS(time.Now())
+}
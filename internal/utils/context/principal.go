@@ -0,0 +1,69 @@
+package context
+
+import (
+	"context"
+	"errors"
+
+	"go_ecommerce/internal/utils/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authentication level of a session
+const (
+	AuthLevelPassword  = 1 // password (or any single factor)
+	AuthLevelTwoFactor = 2 // second factor verified at login
+)
+
+var ErrNoPrincipal = errors.New("request is not authenticated")
+
+// Principal is the caller of an authenticated request, resolved once by AuthenMiddleware
+type Principal struct {
+	UserId      uint64
+	UserAccount string
+	Roles       []string
+	ShopId      string // shop owned by the user, a user owns one shop
+	SessionId   string // public session id, see session.ID
+	SubToken    string // never returned to clients
+	AuthLevel   int
+	Admin       bool     // session opened by the admin login, always two-factor
+	ApiKeyId    uint32   // set when the request is authenticated by a shop API key instead of a session
+	Scopes      []string // scopes of the API key
+}
+
+// Can reports whether the caller holds the permission. An API key is limited to
+// its scopes and to what the roles of its owner still grant
+func (p *Principal) Can(permission string) bool {
+	if !rbac.Can(p.Roles, permission) {
+		return false
+	}
+	if p.ApiKeyId == 0 {
+		return true
+	}
+	for _, scope := range p.Scopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal attaches the principal to ctx
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// GetPrincipal returns the caller of the request, works with *gin.Context and the request context
+func GetPrincipal(ctx context.Context) (*Principal, error) {
+	// gin.Context only falls back to the request context for string keys
+	if gc, ok := ctx.(*gin.Context); ok && gc.Request != nil {
+		ctx = gc.Request.Context()
+	}
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	if !ok || p == nil {
+		return nil, ErrNoPrincipal
+	}
+	return p, nil
+}
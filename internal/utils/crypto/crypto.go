@@ -22,16 +22,3 @@ func GenerateSalt(length int) (string, error) {
 	}
 	return hex.EncodeToString(salt), nil
 }
-
-func HashPassword(password string, salt string) string {
-	// concatenate password and salt
-	saltedPassword := password + salt
-	// hash the combined string
-	hashPass := sha256.Sum256(([]byte(saltedPassword)))
-	return hex.EncodeToString(hashPass[:])
-}
-
-func MatchingPassword(storeHash string, password string, salt string) bool {
-	hashPassword := HashPassword(password, salt)
-	return storeHash == hashPassword
-}
@@ -0,0 +1,216 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidPasswordHash = errors.New("invalid password hash format")
+
+// PasswordHasher hashes passwords into a self-describing encoded string
+// (algorithm + parameters + salt + hash) stored in user_password
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(encoded string, password string) (bool, error)
+	// Supports reports whether the encoded hash was produced by this algorithm
+	Supports(encoded string) bool
+	// NeedsRehash reports whether the encoded hash uses weaker parameters than the hasher
+	NeedsRehash(encoded string) bool
+}
+
+// ---- argon2id ----
+
+type Argon2idHasher struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewArgon2idHasher uses the OWASP recommended minimum when a parameter is zero
+func NewArgon2idHasher(memory uint32, iterations uint32, parallelism uint8) *Argon2idHasher {
+	h := &Argon2idHasher{Memory: memory, Iterations: iterations, Parallelism: parallelism, SaltLength: 16, KeyLength: 32}
+	if h.Memory == 0 {
+		h.Memory = 64 * 1024
+	}
+	if h.Iterations == 0 {
+		h.Iterations = 3
+	}
+	if h.Parallelism == 0 {
+		h.Parallelism = 2
+	}
+	return h
+}
+
+// Hash returns $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(encoded string, password string) (bool, error) {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (h *Argon2idHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	p, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return p.Memory < h.Memory || p.Iterations < h.Iterations || p.Parallelism < h.Parallelism
+}
+
+func decodeArgon2id(encoded string) (p *Argon2idHasher, salt []byte, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	p = &Argon2idHasher{}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	// argon2.IDKey panics on t=0 or p=0, a corrupt row must not crash the request
+	if p.Iterations < 1 || p.Parallelism < 1 {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil || len(salt) == 0 {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	return p, salt, key, nil
+}
+
+// ---- bcrypt ----
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{Cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+func (h *BcryptHasher) Verify(encoded string, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h *BcryptHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.Cost
+}
+
+// ---- default hasher ----
+
+var (
+	defaultHasher PasswordHasher = NewArgon2idHasher(0, 0, 0)
+	knownHashers                 = []PasswordHasher{NewArgon2idHasher(0, 0, 0), NewBcryptHasher(0)}
+)
+
+// SetPasswordHasher changes the algorithm used for new hashes (argon2id by default)
+func SetPasswordHasher(h PasswordHasher) {
+	defaultHasher = h
+}
+
+// HashPassword hashes a password with the default hasher
+func HashPassword(password string) (string, error) {
+	return defaultHasher.Hash(password)
+}
+
+// MatchingPassword verifies a password against argon2id, bcrypt or legacy SHA-256+salt hashes.
+// needsRehash is true when the password matched but the stored hash should be upgraded
+func MatchingPassword(storeHash string, password string, salt string) (match bool, needsRehash bool) {
+	if isLegacyHash(storeHash) {
+		legacy := legacyHashPassword(password, salt)
+		match = subtle.ConstantTimeCompare([]byte(storeHash), []byte(legacy)) == 1
+		return match, match
+	}
+
+	hasher := findHasher(storeHash)
+	if hasher == nil {
+		return false, false
+	}
+	match, err := hasher.Verify(storeHash, password)
+	if err != nil || !match {
+		return false, false
+	}
+	return true, !defaultHasher.Supports(storeHash) || defaultHasher.NeedsRehash(storeHash)
+}
+
+func findHasher(encoded string) PasswordHasher {
+	if defaultHasher.Supports(encoded) {
+		return defaultHasher
+	}
+	for _, h := range knownHashers {
+		if h.Supports(encoded) {
+			return h
+		}
+	}
+	return nil
+}
+
+// legacy hashes are hex(sha256(password + salt)), 64 characters without a "$" prefix
+func isLegacyHash(encoded string) bool {
+	if len(encoded) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(encoded)
+	return err == nil
+}
+
+func legacyHashPassword(password string, salt string) string {
+	// concatenate password and salt
+	saltedPassword := password + salt
+	// hash the combined string
+	hashPass := sha256.Sum256(([]byte(saltedPassword)))
+	return hex.EncodeToString(hashPass[:])
+}
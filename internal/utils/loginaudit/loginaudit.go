@@ -0,0 +1,97 @@
+package loginaudit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/utils/sendto"
+	"go_ecommerce/internal/utils/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Login methods and outcomes stored in pre_go_acc_user_login_history_9999
+const (
+	MethodPassword  = "password"
+	MethodTwoFactor = "two_factor"
+	MethodAdmin     = "admin"
+	MethodPasskey   = "passkey"
+	methodOIDC      = "oidc:"
+
+	OutcomeSuccess      = "success"
+	OutcomeChallenge    = "challenge" // password accepted, second factor pending
+	OutcomeFailed       = "failed"
+	OutcomeLocked       = "locked"
+	OutcomeNotActivated = "not_activated"
+)
+
+const maxUserAgentLength = 512
+
+// MethodOIDC is the method of a login through the provider
+func MethodOIDC(provider string) string {
+	return methodOIDC + provider
+}
+
+// FillClient sets what we know about the client of a login request
+func FillClient(c *gin.Context, client *model.LoginClient) {
+	client.ClientIp = c.ClientIP()
+	client.UserAgent = c.Request.UserAgent()
+	client.Fingerprint = Fingerprint(client.UserAgent, c.GetHeader("Accept-Language"), c.GetHeader("X-Device-Id"))
+	if header := global.Config.Server.CountryHeader; header != "" {
+		client.Country = Country(c.GetHeader(header))
+	}
+}
+
+// Fingerprint identifies a device well enough to tell a new one: the app's
+// device id when it sends one, otherwise the user agent and primary language
+func Fingerprint(userAgent string, acceptLanguage string, deviceId string) string {
+	source := "device:" + strings.TrimSpace(deviceId)
+	if strings.TrimSpace(deviceId) == "" {
+		language := strings.ToLower(strings.TrimSpace(strings.Split(strings.Split(acceptLanguage, ",")[0], ";")[0]))
+		source = "ua:" + strings.TrimSpace(userAgent) + "|" + language
+	}
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// Country validates an ISO 3166 alpha-2 code, unknown and anonymizer codes give ""
+func Country(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 2 || code == "XX" || code == "T1" || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return ""
+	}
+	return code
+}
+
+// TruncateUserAgent fits the user agent in login_user_agent
+func TruncateUserAgent(userAgent string) string {
+	if len(userAgent) > maxUserAgentLength {
+		return userAgent[:maxUserAgentLength]
+	}
+	return userAgent
+}
+
+// NewDeviceAlert is the mail sent on the first login from an unknown device or country
+func NewDeviceAlert(to string, client model.LoginClient, at time.Time) *sendto.Mail {
+	device := client.DeviceName
+	if device == "" {
+		device = session.DeviceFromUserAgent(client.UserAgent)
+	}
+	country := client.Country
+	if country == "" {
+		country = "unknown"
+	}
+	details := fmt.Sprintf("Time: %s\nDevice: %s\nIP address: %s\nCountry: %s",
+		at.UTC().Format("2006-01-02 15:04 MST"), device, client.ClientIp, country)
+	return &sendto.Mail{
+		To:      []string{to},
+		Subject: "New sign-in to your GN Farm account",
+		Body: "Your account was just used to sign in from a new device or location.\n\n" + details +
+			"\n\nIf this was you, no action is needed. If not, change your password and sign out the other sessions in your account settings.",
+	}
+}
@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/crypto"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrInvalidState = errors.New("login state is invalid or expired")
+
+// Flow is a pending login, stored under its state until the callback
+type Flow struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+func getFlowKey(state string) string {
+	return "oidc:state:" + crypto.GetHash(state)
+}
+
+// StartFlow creates state, nonce and PKCE verifier and returns the URL to send the user to
+func StartFlow(ctx context.Context, p *Provider, ttl time.Duration) (authURL string, state string, err error) {
+	state = randomString(24)
+	flow := Flow{
+		Provider:     p.Name,
+		Nonce:        randomString(24),
+		CodeVerifier: NewCodeVerifier(),
+	}
+	flowJson, err := json.Marshal(flow)
+	if err != nil {
+		return "", "", err
+	}
+	if err = global.Rdb.Set(ctx, getFlowKey(state), flowJson, ttl).Err(); err != nil {
+		return "", "", err
+	}
+	return p.AuthCodeURL(state, flow.Nonce, CodeChallenge(flow.CodeVerifier)), state, nil
+}
+
+// TakeFlow returns the pending login of the state, a state can be used once
+func TakeFlow(ctx context.Context, state string) (Flow, error) {
+	var flow Flow
+	val, err := global.Rdb.GetDel(ctx, getFlowKey(state)).Result()
+	if err == redis.Nil {
+		return flow, ErrInvalidState
+	} else if err != nil {
+		return flow, err
+	}
+	if err = json.Unmarshal([]byte(val), &flow); err != nil {
+		return flow, ErrInvalidState
+	}
+	return flow, nil
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]*Provider{}
+)
+
+// Register makes the provider available by its name
+func Register(p *Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[p.Name] = p
+}
+
+// Get returns a registered provider
+func Get(name string) (*Provider, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
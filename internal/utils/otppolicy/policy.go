@@ -0,0 +1,157 @@
+package otppolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go_ecommerce/global"
+)
+
+var (
+	// ErrLocked: too many wrong codes, verification is blocked for a while
+	ErrLocked = errors.New("too many failed attempts, OTP is locked")
+	// ErrTooSoon: a new OTP was requested before the resend cooldown elapsed
+	ErrTooSoon = errors.New("OTP was sent recently, please wait before requesting a new one")
+)
+
+// Policy limits sending and verifying one kind of OTP.
+// Failed attempts are counted per verify key and per client IP; reaching
+// MaxAttempts locks the key, each further lock doubles the lock time.
+type Policy struct {
+	Scope            string
+	MaxAttempts      int           // wrong codes per key within AttemptWindow before lock
+	MaxAttemptsPerIp int           // wrong codes per IP within AttemptWindow (any key) before lock
+	AttemptWindow    time.Duration // window for counting wrong codes
+	ResendCooldown   time.Duration // minimum time between two OTPs for the same key
+	BaseLockout      time.Duration // first lock time
+	MaxLockout       time.Duration // upper bound of the exponential backoff
+}
+
+var (
+	Register = &Policy{
+		Scope: "register", MaxAttempts: 3, MaxAttemptsPerIp: 20, AttemptWindow: time.Minute,
+		ResendCooldown: time.Minute, BaseLockout: 5 * time.Minute, MaxLockout: 24 * time.Hour,
+	}
+	ResetPassword = &Policy{
+		Scope: "resetpwd", MaxAttempts: 3, MaxAttemptsPerIp: 20, AttemptWindow: 10 * time.Minute,
+		ResendCooldown: time.Minute, BaseLockout: 15 * time.Minute, MaxLockout: 24 * time.Hour,
+	}
+	ChangeEmail = &Policy{
+		Scope: "changeemail", MaxAttempts: 3, MaxAttemptsPerIp: 20, AttemptWindow: 10 * time.Minute,
+		ResendCooldown: time.Minute, BaseLockout: 15 * time.Minute, MaxLockout: 24 * time.Hour,
+	}
+	LoginTwoFactor = &Policy{
+		Scope: "login2fa", MaxAttempts: 5, MaxAttemptsPerIp: 30, AttemptWindow: 5 * time.Minute,
+		ResendCooldown: time.Minute, BaseLockout: 5 * time.Minute, MaxLockout: 24 * time.Hour,
+	}
+	SetupTwoFactor = &Policy{
+		Scope: "setup2fa", MaxAttempts: 5, MaxAttemptsPerIp: 30, AttemptWindow: 5 * time.Minute,
+		ResendCooldown: time.Minute, BaseLockout: 5 * time.Minute, MaxLockout: 24 * time.Hour,
+	}
+)
+
+func (p *Policy) key(kind string, id string) string {
+	return fmt.Sprintf("otp:%s:%s:%s", p.Scope, kind, id)
+}
+
+// CheckSend must be called before generating a new OTP for key, it starts the resend cooldown
+func (p *Policy) CheckSend(ctx context.Context, key string) error {
+	if err := p.checkLocked(ctx, p.key("lock", key)); err != nil {
+		return err
+	}
+	ok, err := global.Rdb.SetNX(ctx, p.key("cooldown", key), 1, p.ResendCooldown).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		ttl, _ := global.Rdb.TTL(ctx, p.key("cooldown", key)).Result()
+		return fmt.Errorf("%w (retry after %s)", ErrTooSoon, ttl.Round(time.Second))
+	}
+	return nil
+}
+
+// CheckVerify must be called before comparing a code, it rejects locked keys and IPs
+func (p *Policy) CheckVerify(ctx context.Context, key string, ip string) error {
+	if err := p.checkLocked(ctx, p.key("lock", key)); err != nil {
+		return err
+	}
+	if ip != "" {
+		return p.checkLocked(ctx, p.key("lock:ip", ip))
+	}
+	return nil
+}
+
+// Fail records a wrong code; returns ErrLocked when this attempt triggered a lock
+func (p *Policy) Fail(ctx context.Context, key string, ip string) error {
+	locked, err := p.count(ctx, key, "fail", "lock", p.MaxAttempts)
+	if err != nil || locked {
+		return p.lockError(err, locked)
+	}
+	if ip == "" {
+		return nil
+	}
+	locked, err = p.count(ctx, ip, "fail:ip", "lock:ip", p.MaxAttemptsPerIp)
+	return p.lockError(err, locked)
+}
+
+// Reset clears the failure counters after a successful verification
+func (p *Policy) Reset(ctx context.Context, key string) error {
+	return global.Rdb.Del(ctx, p.key("fail", key), p.key("cooldown", key), p.key("lockcount", key)).Err()
+}
+
+func (p *Policy) count(ctx context.Context, id string, failKind string, lockKind string, max int) (bool, error) {
+	failKey := p.key(failKind, id)
+	pipe := global.Rdb.TxPipeline()
+	incr := pipe.Incr(ctx, failKey)
+	pipe.ExpireNX(ctx, failKey, p.AttemptWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	if max <= 0 || incr.Val() < int64(max) {
+		return false, nil
+	}
+
+	// exponential backoff: base, 2*base, 4*base ... up to MaxLockout
+	lockCountKey := p.key(lockKind+"count", id)
+	n, err := global.Rdb.Incr(ctx, lockCountKey).Result()
+	if err != nil {
+		return false, err
+	}
+	global.Rdb.Expire(ctx, lockCountKey, p.MaxLockout)
+	lockout := p.BaseLockout
+	for i := int64(1); i < n && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > p.MaxLockout {
+		lockout = p.MaxLockout
+	}
+
+	pipe = global.Rdb.TxPipeline()
+	pipe.Set(ctx, p.key(lockKind, id), 1, lockout)
+	pipe.Del(ctx, failKey)
+	_, err = pipe.Exec(ctx)
+	return true, err
+}
+
+func (p *Policy) checkLocked(ctx context.Context, lockKey string) error {
+	ttl, err := global.Rdb.TTL(ctx, lockKey).Result()
+	if err != nil {
+		return err
+	}
+	if ttl > 0 {
+		return fmt.Errorf("%w (retry after %s)", ErrLocked, ttl.Round(time.Second))
+	}
+	return nil
+}
+
+func (p *Policy) lockError(err error, locked bool) error {
+	if err != nil {
+		return err
+	}
+	if locked {
+		return ErrLocked
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidPhone = errors.New("phone number is not a valid Vietnamese mobile number")
+
+// 11-digit prefixes renumbered in 2018, still printed on old SIM cards and business cards
+var legacyPrefixes = map[string]string{
+	"162": "32", "163": "33", "164": "34", "165": "35", "166": "36", "167": "37", "168": "38", "169": "39",
+	"120": "70", "121": "79", "122": "77", "126": "76", "128": "78",
+	"123": "83", "124": "84", "125": "85", "127": "81", "129": "82",
+	"186": "56", "188": "58", "199": "59",
+}
+
+// Normalize converts a Vietnamese mobile number to E.164 (+84xxxxxxxxx).
+// Accepts 0912 345 678, 0912.345.678, 84912345678, +84 912 345 678, 0084... and old 11-digit numbers.
+func Normalize(raw string) (string, error) {
+	s := strings.TrimSpace(raw)
+	plus := false
+	digits := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, c)
+		case c == '+' && !plus && len(digits) == 0:
+			plus = true
+		case c == ' ' || c == '.' || c == '-' || c == '(' || c == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+	n := string(digits)
+
+	// national significant number, without country code or trunk prefix
+	switch {
+	case plus && strings.HasPrefix(n, "84"):
+		n = n[2:]
+	case plus:
+		return "", ErrInvalidPhone
+	case strings.HasPrefix(n, "0084"):
+		n = n[4:]
+	case strings.HasPrefix(n, "84") && (len(n) == 11 || len(n) == 12):
+		n = n[2:]
+	case strings.HasPrefix(n, "0"):
+		n = n[1:]
+	}
+
+	if len(n) == 10 {
+		if p, ok := legacyPrefixes[n[:3]]; ok {
+			n = p + n[3:]
+		}
+	}
+	if len(n) != 9 || !strings.ContainsRune("35789", rune(n[0])) {
+		return "", ErrInvalidPhone
+	}
+	return "+84" + n, nil
+}
+
+// IsPhone reports whether s looks like a phone number rather than an email or username
+func IsPhone(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.Contains(s, "@") {
+		return false
+	}
+	return strings.Trim(s, "+0123456789 .-()") == ""
+}
@@ -0,0 +1,165 @@
+package producttype
+
+import (
+	"context"
+
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/repo"
+)
+
+// Names of the built-in product types
+const (
+	Mushroom  = "Mushroom"
+	Vegetable = "Vegetable"
+	Bonsai    = "Bonsai"
+)
+
+// produceSchema is shared by mushrooms and vegetables, both are sold by weight
+func produceSchema() Schema {
+	return Schema{
+		{Name: "weight", Kind: KindNumber, Required: true, Unit: "kg", Min: Bound(0.01), Max: Bound(99999999.99)},
+		{Name: "origin", Kind: KindString, MaxLength: 100, Searchable: true},
+		{Name: "freshness", Kind: KindString, Enum: []string{"fresh", "chilled", "frozen", "dried"}},
+		{Name: "package_type", Kind: KindString, MaxLength: 50},
+	}
+}
+
+// produceAttributes leaves out the columns that are not set
+func produceAttributes(weight float64, origin, freshness, packageType string) Attributes {
+	attrs := Attributes{}
+	if weight > 0 {
+		attrs["weight"] = weight
+	}
+	setString(attrs, "origin", origin)
+	setString(attrs, "freshness", freshness)
+	setString(attrs, "package_type", packageType)
+	return attrs
+}
+
+func setString(attrs Attributes, name, value string) {
+	if value != "" {
+		attrs[name] = value
+	}
+}
+
+func init() {
+	Register(&Type{
+		Name:   Mushroom,
+		Schema: produceSchema(),
+		Create: func(ctx context.Context, r repo.IProductRepository, productID string, shopID string, attrs Attributes) error {
+			return r.CreateMushroom(ctx, mushroomModel(productID, shopID, attrs))
+		},
+		Update: func(ctx context.Context, r repo.IProductRepository, productID string, shopID string, attrs Attributes) error {
+			return r.UpdateMushroom(ctx, mushroomModel(productID, shopID, attrs))
+		},
+		Load: func(ctx context.Context, r repo.IProductRepository, productIDs []string) (map[string]Attributes, error) {
+			mushrooms, err := r.FindMushroomsByIDs(ctx, productIDs)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]Attributes, len(mushrooms))
+			for _, m := range mushrooms {
+				byID[m.ID] = produceAttributes(m.Weight, m.Origin, m.Freshness, m.PackageType)
+			}
+			return byID, nil
+		},
+	})
+	Register(&Type{
+		Name:   Vegetable,
+		Schema: produceSchema(),
+		Create: func(ctx context.Context, r repo.IProductRepository, productID string, shopID string, attrs Attributes) error {
+			return r.CreateVegetable(ctx, vegetableModel(productID, shopID, attrs))
+		},
+		Update: func(ctx context.Context, r repo.IProductRepository, productID string, shopID string, attrs Attributes) error {
+			return r.UpdateVegetable(ctx, vegetableModel(productID, shopID, attrs))
+		},
+		Load: func(ctx context.Context, r repo.IProductRepository, productIDs []string) (map[string]Attributes, error) {
+			vegetables, err := r.FindVegetablesByIDs(ctx, productIDs)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]Attributes, len(vegetables))
+			for _, v := range vegetables {
+				byID[v.ID] = produceAttributes(v.Weight, v.Origin, v.Freshness, v.PackageType)
+			}
+			return byID, nil
+		},
+	})
+	Register(&Type{
+		Name: Bonsai,
+		Schema: Schema{
+			{Name: "age", Kind: KindInteger, Unit: "years", Min: Bound(1), Max: Bound(1000)},
+			{Name: "height", Kind: KindInteger, Unit: "cm", Min: Bound(1), Max: Bound(500)},
+			{Name: "style", Kind: KindString, Enum: []string{
+				"formal_upright", "informal_upright", "slanting", "cascade", "semi_cascade",
+				"windswept", "literati", "broom", "forest",
+			}},
+			{Name: "species", Kind: KindString, Required: true, MaxLength: 100, Searchable: true},
+			{Name: "pot_type", Kind: KindString, MaxLength: 50},
+		},
+		Create: func(ctx context.Context, r repo.IProductRepository, productID string, shopID string, attrs Attributes) error {
+			return r.CreateBonsai(ctx, bonsaiModel(productID, shopID, attrs))
+		},
+		Update: func(ctx context.Context, r repo.IProductRepository, productID string, shopID string, attrs Attributes) error {
+			return r.UpdateBonsai(ctx, bonsaiModel(productID, shopID, attrs))
+		},
+		Load: func(ctx context.Context, r repo.IProductRepository, productIDs []string) (map[string]Attributes, error) {
+			bonsais, err := r.FindBonsaisByIDs(ctx, productIDs)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]Attributes, len(bonsais))
+			for _, b := range bonsais {
+				attrs := Attributes{}
+				if b.Age > 0 {
+					attrs["age"] = b.Age
+				}
+				if b.Height > 0 {
+					attrs["height"] = b.Height
+				}
+				setString(attrs, "style", b.Style)
+				setString(attrs, "species", b.Species)
+				setString(attrs, "pot_type", b.PotType)
+				byID[b.ID] = attrs
+			}
+			return byID, nil
+		},
+	})
+}
+
+// the models below leave attributes that are not given at their zero value,
+// which the update queries treat as "keep the stored value"
+
+func mushroomModel(productID, shopID string, attrs Attributes) *model.MushroomModel {
+	return &model.MushroomModel{
+		ID:          productID,
+		ProductShop: shopID,
+		Weight:      attrs.Float("weight"),
+		Origin:      attrs.String("origin"),
+		Freshness:   attrs.String("freshness"),
+		PackageType: attrs.String("package_type"),
+	}
+}
+
+func vegetableModel(productID, shopID string, attrs Attributes) *model.VegetableModel {
+	return &model.VegetableModel{
+		ID:          productID,
+		ProductShop: shopID,
+		Weight:      attrs.Float("weight"),
+		Origin:      attrs.String("origin"),
+		Freshness:   attrs.String("freshness"),
+		PackageType: attrs.String("package_type"),
+	}
+}
+
+func bonsaiModel(productID, shopID string, attrs Attributes) *model.BonsaiModel {
+	return &model.BonsaiModel{
+		ID:          productID,
+		ProductShop: shopID,
+		Age:         attrs.Int("age"),
+		Height:      attrs.Int("height"),
+		Style:       attrs.String("style"),
+		Species:     attrs.String("species"),
+		PotType:     attrs.String("pot_type"),
+	}
+}
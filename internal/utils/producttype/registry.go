@@ -0,0 +1,95 @@
+package producttype
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go_ecommerce/internal/repo"
+)
+
+var ErrUnknownType = errors.New("invalid product type")
+
+// CreateFunc writes the type row of a new product. It runs in the transaction that
+// inserted the products row, r is bound to that transaction
+type CreateFunc func(ctx context.Context, r repo.IProductRepository, productID string, shopID string, attrs Attributes) error
+
+// UpdateFunc changes the attributes given in attrs, the others keep their stored value
+type UpdateFunc func(ctx context.Context, r repo.IProductRepository, productID string, shopID string, attrs Attributes) error
+
+// LoadFunc returns the attributes of many products by product ID, with one query
+type LoadFunc func(ctx context.Context, r repo.IProductRepository, productIDs []string) (map[string]Attributes, error)
+
+// Type is a kind of product with its own attributes, e.g. Mushroom
+type Type struct {
+	Name   string     `json:"name"` // stored in products.product_type
+	Schema Schema     `json:"attributes"`
+	Create CreateFunc `json:"-"`
+	Update UpdateFunc `json:"-"`
+	Load   LoadFunc   `json:"-"`
+}
+
+// Validate checks the attributes of a product of this type
+func (t *Type) Validate(raw map[string]interface{}) (Attributes, error) {
+	return t.Schema.Validate(t.Name, raw)
+}
+
+// ValidatePartial checks the attributes given to update a product of this type
+func (t *Type) ValidatePartial(raw map[string]interface{}) (Attributes, error) {
+	return t.Schema.ValidatePartial(t.Name, raw)
+}
+
+// SearchText joins the values of the searchable attributes, in the order of the schema
+func (t *Type) SearchText(attrs Attributes) string {
+	var words []string
+	for _, f := range t.Schema {
+		if v, ok := attrs[f.Name]; ok && f.Searchable {
+			words = append(words, fmt.Sprint(v))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+var (
+	typesMu sync.RWMutex
+	types   = map[string]*Type{}
+)
+
+// Register makes the product type available by its name, a new type needs no change in the product service
+func Register(t *Type) {
+	if t.Name == "" || t.Create == nil || t.Update == nil || t.Load == nil {
+		panic("producttype: Register needs a name and the Create, Update and Load funcs")
+	}
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	if _, dup := types[t.Name]; dup {
+		panic("producttype: Register called twice for " + t.Name)
+	}
+	types[t.Name] = t
+}
+
+// Get returns a registered product type
+func Get(name string) (*Type, error) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	t, ok := types[name]
+	if !ok {
+		return nil, ErrUnknownType
+	}
+	return t, nil
+}
+
+// List returns the registered product types sorted by name
+func List() []*Type {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	list := make([]*Type, 0, len(types))
+	for _, t := range types {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
@@ -0,0 +1,230 @@
+package producttype
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind of an attribute value
+const (
+	KindString  = "string"
+	KindNumber  = "number"  // float64
+	KindInteger = "integer" // int, a JSON number without fraction
+	KindBool    = "bool"
+)
+
+// Field describes one attribute of a product type
+type Field struct {
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"`
+	Required   bool     `json:"required"`
+	Enum       []string `json:"enum,omitempty"`       // allowed values of a string attribute
+	MaxLength  int      `json:"max_length,omitempty"` // in characters, the column size of a string attribute
+	Unit       string   `json:"unit,omitempty"`       // e.g. "kg", "cm", shown next to the value
+	Min        *float64 `json:"min,omitempty"`
+	Max        *float64 `json:"max,omitempty"`
+	Searchable bool     `json:"searchable,omitempty"` // the value is found by the product search, e.g. the origin
+}
+
+// Bound is a helper for Field.Min and Field.Max
+func Bound(v float64) *float64 {
+	return &v
+}
+
+type Schema []Field
+
+// Field returns the field with the name
+func (s Schema) Field(name string) (Field, bool) {
+	for _, f := range s {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// FieldError is the error of one attribute
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid attribute, not only the first one
+type ValidationError struct {
+	Type   string       `json:"type"`
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return "invalid " + e.Type + " attributes: " + strings.Join(msgs, "; ")
+}
+
+// Attributes are validated attribute values: string, float64, int or bool as declared by the schema
+type Attributes map[string]interface{}
+
+// Has reports whether the attribute was given
+func (a Attributes) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+func (a Attributes) String(name string) string {
+	v, _ := a[name].(string)
+	return v
+}
+
+func (a Attributes) Float(name string) float64 {
+	v, _ := a[name].(float64)
+	return v
+}
+
+func (a Attributes) Int(name string) int {
+	v, _ := a[name].(int)
+	return v
+}
+
+func (a Attributes) Bool(name string) bool {
+	v, _ := a[name].(bool)
+	return v
+}
+
+// Validate checks raw attributes, decoded from JSON or set by Go callers, against the
+// schema and converts them to the declared kinds. Unknown attributes are rejected
+func (s Schema) Validate(typeName string, raw map[string]interface{}) (Attributes, error) {
+	return s.validate(typeName, raw, false)
+}
+
+// ValidatePartial is Validate for an update, attributes that are not given keep their value
+func (s Schema) ValidatePartial(typeName string, raw map[string]interface{}) (Attributes, error) {
+	return s.validate(typeName, raw, true)
+}
+
+func (s Schema) validate(typeName string, raw map[string]interface{}, partial bool) (Attributes, error) {
+	attrs := Attributes{}
+	var errs []FieldError
+	for _, f := range s {
+		v, ok := raw[f.Name]
+		if !ok || v == nil {
+			if f.Required && !partial {
+				errs = append(errs, FieldError{Field: f.Name, Message: "is required"})
+			}
+			continue
+		}
+		value, msg := f.convert(v)
+		if msg != "" {
+			errs = append(errs, FieldError{Field: f.Name, Message: msg})
+			continue
+		}
+		attrs[f.Name] = value
+	}
+
+	// sorted, so the same input always gives the same error
+	var unknown []string
+	for name := range raw {
+		if _, ok := s.Field(name); !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, FieldError{Field: name, Message: "is not an attribute of " + typeName})
+	}
+
+	if len(errs) > 0 {
+		return nil, &ValidationError{Type: typeName, Fields: errs}
+	}
+	return attrs, nil
+}
+
+// convert returns the value in the kind of the field, or the error message
+func (f Field) convert(v interface{}) (interface{}, string) {
+	switch f.Kind {
+	case KindString:
+		s, ok := v.(string)
+		if !ok {
+			return nil, "must be a string"
+		}
+		s = strings.TrimSpace(s)
+		if s == "" && f.Required {
+			return nil, "is required"
+		}
+		if len(f.Enum) > 0 && s != "" && !contains(f.Enum, s) {
+			return nil, "must be one of " + strings.Join(f.Enum, ", ")
+		}
+		if f.MaxLength > 0 && utf8.RuneCountInString(s) > f.MaxLength {
+			return nil, fmt.Sprintf("must be at most %d characters", f.MaxLength)
+		}
+		return s, ""
+	case KindNumber, KindInteger:
+		n, ok := toFloat(v)
+		if !ok {
+			return nil, "must be a number"
+		}
+		if f.Kind == KindInteger && n != math.Trunc(n) {
+			return nil, "must be a whole number"
+		}
+		if f.Min != nil && n < *f.Min {
+			return nil, fmt.Sprintf("must be at least %s", f.format(*f.Min))
+		}
+		if f.Max != nil && n > *f.Max {
+			return nil, fmt.Sprintf("must be at most %s", f.format(*f.Max))
+		}
+		if f.Kind == KindInteger {
+			return int(n), ""
+		}
+		return n, ""
+	case KindBool:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, "must be true or false"
+		}
+		return b, ""
+	}
+	return nil, "has an unsupported kind " + f.Kind
+}
+
+// format prints a bound with the unit of the field
+func (f Field) format(v float64) string {
+	s := fmt.Sprintf("%g", v)
+	if f.Unit != "" {
+		s += " " + f.Unit
+	}
+	return s
+}
+
+// toFloat accepts the number types of encoding/json and of Go callers
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
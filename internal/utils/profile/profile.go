@@ -0,0 +1,88 @@
+package profile
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxNicknameLength = 50
+	// user_avatar is VARCHAR(255)
+	MaxAvatarLength = 255
+	BirthdayLayout  = "2006-01-02"
+	// nobody registers before they can read
+	minAge = 13
+	maxAge = 120
+)
+
+// user_gender: 0-Secret, 1-Male, 2-Female
+const (
+	GenderSecret int16 = iota
+	GenderMale
+	GenderFemale
+)
+
+var (
+	ErrInvalidNickname = errors.New("nickname must be 1 to 50 printable characters")
+	ErrInvalidAvatar   = errors.New("avatar must be an http(s) URL of at most 255 characters")
+	ErrInvalidGender   = errors.New("gender must be 0 (secret), 1 (male) or 2 (female)")
+	ErrInvalidBirthday = errors.New("birthday must be a YYYY-MM-DD date giving an age between 13 and 120")
+)
+
+// Nickname trims and collapses the spaces of a nickname, control characters are rejected
+func Nickname(raw string) (string, error) {
+	s := strings.Join(strings.Fields(raw), " ")
+	if s == "" || utf8.RuneCountInString(s) > MaxNicknameLength {
+		return "", ErrInvalidNickname
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return "", ErrInvalidNickname
+		}
+	}
+	return s, nil
+}
+
+// Avatar checks the avatar URL, an empty string removes the avatar
+func Avatar(raw string) (string, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return "", nil
+	}
+	if len(s) > MaxAvatarLength {
+		return "", ErrInvalidAvatar
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User != nil {
+		return "", ErrInvalidAvatar
+	}
+	return s, nil
+}
+
+func Gender(g int16) (int16, error) {
+	if g < GenderSecret || g > GenderFemale {
+		return 0, ErrInvalidGender
+	}
+	return g, nil
+}
+
+// Birthday parses a YYYY-MM-DD date, an empty string removes the birthday (zero time)
+func Birthday(raw string, now time.Time) (time.Time, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	d, err := time.Parse(BirthdayLayout, s)
+	if err != nil {
+		return time.Time{}, ErrInvalidBirthday
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if d.After(today.AddDate(-minAge, 0, 0)) || d.Before(today.AddDate(-maxAge, 0, 0)) {
+		return time.Time{}, ErrInvalidBirthday
+	}
+	return d, nil
+}
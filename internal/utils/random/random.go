@@ -1,6 +1,8 @@
 package random
 
 import (
+	crand "crypto/rand"
+	"math/big"
 	"math/rand"
 	"time"
 )
@@ -9,4 +11,24 @@ func GenerateSixDigiOtp()int  {
 	rng:= rand.New(rand.NewSource(time.Now().UnixNano()))
 	otp := 100000 + rng.Intn(900000) //100000 - 999999
 	return otp
-}
\ No newline at end of file
+}
+
+// recoveryAlphabet skips look-alike characters (0/o, 1/l/i)
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// GenerateRecoveryCodes returns n one-time recovery codes formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		buf := make([]byte, 10)
+		for j := range buf {
+			idx, err := crand.Int(crand.Reader, big.NewInt(int64(len(recoveryAlphabet))))
+			if err != nil {
+				return nil, err
+			}
+			buf[j] = recoveryAlphabet[idx.Int64()]
+		}
+		codes = append(codes, string(buf[:5])+"-"+string(buf[5:]))
+	}
+	return codes, nil
+}
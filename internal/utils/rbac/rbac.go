@@ -0,0 +1,94 @@
+package rbac
+
+// Roles are stored in go_db_role / go_user_roles, what a role may do is
+// defined here so a permission check never needs the database.
+
+const (
+	RoleCustomer  = "customer" // every account, implicit
+	RoleShopOwner = "shop_owner"
+	RoleShopStaff = "shop_staff"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+const (
+	PermProductRead     = "product:read" // products of the own shop, drafts included
+	PermProductCreate   = "product:create"
+	PermProductUpdate   = "product:update"
+	PermProductPublish  = "product:publish"
+	PermProductModerate = "product:moderate" // act on products of any shop
+	PermInventoryWrite  = "inventory:write"  // stock levels of the own shop
+	PermUserRead        = "user:read"
+	PermUserLock        = "user:lock"
+	PermUserDelete      = "user:delete"
+	PermRoleAssign      = "role:assign"
+	PermAdminAccess     = "admin:access" // may log in to the admin API
+)
+
+var rolePermissions = map[string][]string{
+	RoleCustomer:  {},
+	RoleShopOwner: {PermProductRead, PermProductCreate, PermProductUpdate, PermProductPublish, PermInventoryWrite},
+	RoleShopStaff: {PermProductRead, PermProductCreate, PermProductUpdate, PermInventoryWrite},
+	RoleModerator: {PermAdminAccess, PermProductPublish, PermProductModerate, PermUserRead, PermUserLock},
+	RoleAdmin: {
+		PermAdminAccess, PermProductRead, PermProductCreate, PermProductUpdate, PermProductPublish, PermProductModerate,
+		PermInventoryWrite, PermUserRead, PermUserLock, PermUserDelete, PermRoleAssign,
+	},
+}
+
+// Roles lists the known roles
+func Roles() []string {
+	return []string{RoleCustomer, RoleShopOwner, RoleShopStaff, RoleModerator, RoleAdmin}
+}
+
+// IsRole reports whether role is a known role
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Can reports whether one of the roles grants the permission
+func Can(roles []string, permission string) bool {
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Permissions lists the permissions granted by the roles, without duplicates
+func Permissions(roles []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if !seen[p] {
+				seen[p] = true
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+// CanManage reports whether the caller may lock, unlock or remove the target's account. Any
+// account without admin access may be, an account with admin access only by a caller who
+// assigns roles and holds every permission of the target: a moderator never acts on another
+// moderator or an admin
+func CanManage(caller []string, target []string) bool {
+	if !Can(target, PermAdminAccess) {
+		return true
+	}
+	if !Can(caller, PermRoleAssign) {
+		return false
+	}
+	for _, p := range Permissions(target) {
+		if !Can(caller, p) {
+			return false
+		}
+	}
+	return true
+}
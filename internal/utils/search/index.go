@@ -0,0 +1,252 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BM25 parameters, the usual values
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// how much a word that is not the query word counts, relative to an exact match
+const (
+	prefixWeight = 0.7 // the last query word while typing, e.g. "huo" for "huong"
+	typoWeight   = 0.5 // divided by the number of typos
+)
+
+// Field is a searchable field of the documents, a match in a field with a higher boost ranks higher
+type Field struct {
+	Name  string
+	Boost float64
+}
+
+// Document is what the index keeps of a product, the text of each field is folded and tokenized
+type Document struct {
+	ID     string
+	Tag    string            // narrows a search, e.g. the product type
+	Fields map[string]string // text per field name, fields the index does not know are ignored
+}
+
+// Hit is a document that matches every word of the query
+type Hit struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+type entry struct {
+	tag     string
+	lengths []int            // words per field
+	terms   map[string][]int // occurrences per field of each word of the document
+}
+
+// Index is an in-memory inverted index, safe for concurrent use
+type Index struct {
+	fields []Field
+
+	mu       sync.RWMutex
+	docs     map[string]*entry
+	postings map[string]map[string][]int // word -> document id -> occurrences per field
+	words    []int                       // words per field over all documents, for the average length
+}
+
+// NewIndex returns an empty index over the fields
+func NewIndex(fields ...Field) *Index {
+	ix := &Index{fields: fields}
+	ix.docs, ix.postings, ix.words = map[string]*entry{}, map[string]map[string][]int{}, make([]int, len(fields))
+	return ix
+}
+
+// Len is the number of documents
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Put adds the document or replaces the one with the same id
+func (ix *Index) Put(doc Document) {
+	e := ix.entry(doc)
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(doc.ID)
+	ix.add(doc.ID, e)
+}
+
+// Remove drops the document, an unknown id is ignored
+func (ix *Index) Remove(id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+}
+
+// Reset replaces every document of the index at once, searches see the old or the new documents
+func (ix *Index) Reset(docs []Document) {
+	next := NewIndex(ix.fields...)
+	for _, doc := range docs {
+		next.remove(doc.ID)
+		next.add(doc.ID, next.entry(doc))
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.docs, ix.postings, ix.words = next.docs, next.postings, next.words
+}
+
+func (ix *Index) entry(doc Document) *entry {
+	e := &entry{tag: doc.Tag, lengths: make([]int, len(ix.fields)), terms: map[string][]int{}}
+	for f, field := range ix.fields {
+		for _, term := range Tokenize(doc.Fields[field.Name]) {
+			counts, ok := e.terms[term]
+			if !ok {
+				counts = make([]int, len(ix.fields))
+				e.terms[term] = counts
+			}
+			counts[f]++
+			e.lengths[f]++
+		}
+	}
+	return e
+}
+
+func (ix *Index) add(id string, e *entry) {
+	ix.docs[id] = e
+	for term, counts := range e.terms {
+		docs, ok := ix.postings[term]
+		if !ok {
+			docs = map[string][]int{}
+			ix.postings[term] = docs
+		}
+		docs[id] = counts
+	}
+	for f, n := range e.lengths {
+		ix.words[f] += n
+	}
+}
+
+func (ix *Index) remove(id string) {
+	e, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	delete(ix.docs, id)
+	for term := range e.terms {
+		delete(ix.postings[term], id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	for f, n := range e.lengths {
+		ix.words[f] -= n
+	}
+}
+
+// Search returns the documents that match every word of the query, the best first, and the
+// number of matches. A word matches the same word, the word with a typo or two (see maxEdits)
+// and, for the last word of the query, a longer word it starts. An empty tag searches every document
+func (ix *Index) Search(query string, tag string, offset, limit int) ([]Hit, int) {
+	words := unique(Tokenize(query))
+	if len(words) == 0 {
+		return nil, 0
+	}
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	var scores map[string]float64
+	for i, word := range words {
+		best := ix.match(word, i == len(words)-1, tag)
+		if scores == nil {
+			scores = best
+			continue
+		}
+		for id, score := range scores {
+			if s, ok := best[id]; ok {
+				scores[id] = score + s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	total := len(hits)
+	if offset >= total {
+		return nil, total
+	}
+	end := offset + limit
+	if limit <= 0 || end > total {
+		end = total
+	}
+	return hits[offset:end], total
+}
+
+// match scores the documents for one query word, a document keeps its best matching word
+func (ix *Index) match(word string, last bool, tag string) map[string]float64 {
+	edits := maxEdits(word)
+	best := map[string]float64{}
+	for term, docs := range ix.postings {
+		weight := 0.0
+		switch {
+		case term == word:
+			weight = 1
+		case last && len(word) >= 2 && strings.HasPrefix(term, word):
+			weight = prefixWeight
+		case edits > 0:
+			if d := distance(word, term, edits); d <= edits {
+				weight = typoWeight / float64(d)
+			}
+		}
+		if weight == 0 {
+			continue
+		}
+		idf := math.Log(1 + (float64(len(ix.docs))-float64(len(docs))+0.5)/(float64(len(docs))+0.5))
+		for id, counts := range docs {
+			e := ix.docs[id]
+			if tag != "" && e.tag != tag {
+				continue
+			}
+			if score := weight * idf * ix.fieldScore(counts, e.lengths); score > best[id] {
+				best[id] = score
+			}
+		}
+	}
+	return best
+}
+
+// fieldScore adds up the BM25 term frequency of each field times its boost
+func (ix *Index) fieldScore(counts, lengths []int) float64 {
+	score := 0.0
+	for f, field := range ix.fields {
+		if counts[f] == 0 {
+			continue
+		}
+		avg := float64(ix.words[f]) / float64(len(ix.docs))
+		tf := float64(counts[f])
+		score += field.Boost * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(lengths[f])/avg))
+	}
+	return score
+}
+
+func unique(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	out := words[:0]
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			out = append(out, w)
+		}
+	}
+	return out
+}
@@ -0,0 +1,123 @@
+package sendto
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CapturedMail is a mail kept by the OutboxSender
+type CapturedMail struct {
+	Id          string         `json:"id"`
+	From        EmailAddress   `json:"from"`
+	To          []string       `json:"to"`
+	Cc          []string       `json:"cc,omitempty"`
+	Bcc         []string       `json:"bcc,omitempty"`
+	Subject     string         `json:"subject"`
+	Body        string         `json:"body"`
+	HTMLBody    string         `json:"html_body,omitempty"`
+	Attachments []AttachedFile `json:"attachments,omitempty"`
+	SentAt      time.Time      `json:"sent_at"`
+}
+
+type AttachedFile struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// OutboxSender keeps the last mails in memory and, when Dir is set, writes each one as a .eml file.
+// Nothing leaves the machine, for development and tests.
+type OutboxSender struct {
+	Dir   string
+	Limit int
+	mu    sync.Mutex
+	mails []CapturedMail
+}
+
+func NewOutboxSender(dir string, limit int) *OutboxSender {
+	if limit <= 0 {
+		limit = 100
+	}
+	return &OutboxSender{Dir: dir, Limit: limit}
+}
+
+func (o *OutboxSender) SendEmail(ctx context.Context, m *Mail) error {
+	captured := CapturedMail{
+		Id:       uuid.New().String(),
+		From:     m.From,
+		To:       m.To,
+		Cc:       m.Cc,
+		Bcc:      m.Bcc,
+		Subject:  m.Subject,
+		Body:     m.Body,
+		HTMLBody: m.HTMLBody,
+		SentAt:   time.Now(),
+	}
+	for _, a := range m.Attachments {
+		captured.Attachments = append(captured.Attachments, AttachedFile{Filename: a.Filename, ContentType: a.ContentType, Size: len(a.Content)})
+	}
+
+	if o.Dir != "" {
+		msg, err := BuildMIME(m)
+		if err != nil {
+			return err
+		}
+		if err = os.MkdirAll(o.Dir, 0o755); err != nil {
+			return err
+		}
+		name := captured.SentAt.Format("20060102T150405") + "_" + captured.Id + ".eml"
+		if err = os.WriteFile(filepath.Join(o.Dir, name), msg, 0o600); err != nil {
+			return err
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.mails = append(o.mails, captured)
+	if len(o.mails) > o.Limit {
+		o.mails = o.mails[len(o.mails)-o.Limit:]
+	}
+	return nil
+}
+
+// List returns the captured mails, newest first; to filters by any recipient
+func (o *OutboxSender) List(to string) []CapturedMail {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	out := make([]CapturedMail, 0, len(o.mails))
+	for i := len(o.mails) - 1; i >= 0; i-- {
+		if to == "" || hasRecipient(o.mails[i], to) {
+			out = append(out, o.mails[i])
+		}
+	}
+	return out
+}
+
+// Clear drops the captured mails, the .eml files are kept
+func (o *OutboxSender) Clear() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.mails = nil
+}
+
+// Outbox returns the configured sender when it is an outbox, nil otherwise
+func Outbox() *OutboxSender {
+	o, _ := emailSender.(*OutboxSender)
+	return o
+}
+
+func hasRecipient(m CapturedMail, addr string) bool {
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, a := range list {
+			if a == addr {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package sendto
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/sendgrid/sendgrid-go"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// SendGridSender sends through the SendGrid v3 API
+type SendGridSender struct {
+	client *sendgrid.Client
+}
+
+func NewSendGridSender(apiKey string) *SendGridSender {
+	return &SendGridSender{client: sendgrid.NewSendClient(apiKey)}
+}
+
+func (s *SendGridSender) SendEmail(ctx context.Context, m *Mail) error {
+	message := mail.NewV3Mail()
+	message.SetFrom(mail.NewEmail(m.From.Name, m.From.Address))
+	message.Subject = m.Subject
+
+	p := mail.NewPersonalization()
+	for _, addr := range m.To {
+		p.AddTos(mail.NewEmail("", addr))
+	}
+	for _, addr := range m.Cc {
+		p.AddCCs(mail.NewEmail("", addr))
+	}
+	for _, addr := range m.Bcc {
+		p.AddBCCs(mail.NewEmail("", addr))
+	}
+	message.AddPersonalizations(p)
+
+	// text/plain must come before text/html
+	if m.Body != "" {
+		message.AddContent(mail.NewContent("text/plain", m.Body))
+	}
+	if m.HTMLBody != "" {
+		message.AddContent(mail.NewContent("text/html", m.HTMLBody))
+	}
+	for _, a := range m.Attachments {
+		att := mail.NewAttachment()
+		att.SetContent(base64.StdEncoding.EncodeToString(a.Content))
+		att.SetType(a.ContentType)
+		att.SetFilename(a.Filename)
+		att.SetDisposition("attachment")
+		message.AddAttachment(att)
+	}
+
+	response, err := s.client.SendWithContext(ctx, message)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != 202 {
+		return fmt.Errorf("failed to send email, status code: %d, body: %s", response.StatusCode, response.Body)
+	}
+	return nil
+}
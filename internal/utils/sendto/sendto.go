@@ -1,13 +1,11 @@
 package sendto
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"go_ecommerce/global"
-	"log"
-	"os"
 
-	"github.com/sendgrid/sendgrid-go"
-	"github.com/sendgrid/sendgrid-go/helpers/mail"
 	"go.uber.org/zap"
 )
 
@@ -16,44 +14,68 @@ type EmailAddress struct {
 	Name    string `json:"name"`
 }
 
-type Mail struct {
-	From    EmailAddress
-	To      []string
-	Subject string
-	Body    string
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"-"`
 }
 
-func SendTextEmailOtp(to []string, from string, otp string) error {
-
+type Mail struct {
+	From        EmailAddress
+	To          []string
+	Cc          []string
+	Bcc         []string
+	Subject     string
+	Body        string // text/plain
+	HTMLBody    string // text/html, optional
+	Attachments []Attachment
+}
 
-	contentEmail := Mail{
-		From:    EmailAddress{Address: from, Name: "OTP Service"},
-		To:      to,
-		Subject: "OTP Verification",
-		Body:    fmt.Sprintf("Your OTP is %s. Please enter it to verify your account.", otp),
-	}
+// EmailSender delivers a Mail, implementations: SendGrid, SMTP, Outbox
+type EmailSender interface {
+	SendEmail(ctx context.Context, m *Mail) error
+}
 
-	// send using SendGrid
-	fromEmail := mail.NewEmail(contentEmail.From.Name, contentEmail.From.Address) // Sender email
-	toEmail := mail.NewEmail("Recipient", to[0])                                  // Assume sending to only one recipient
+var (
+	emailSender EmailSender = NewOutboxSender("", 100)
+	defaultFrom EmailAddress
+)
 
-	plainTextContent := fmt.Sprintf("Your OTP is %s. Please enter it to verify your account.", otp)
-	htmlContent := fmt.Sprintf("<strong>Your OTP is %s. Please enter it to verify your account.</strong>", otp)
+// SetEmailSender replaces the sender used by SendEmail and SendTextEmailOtp
+func SetEmailSender(s EmailSender) {
+	emailSender = s
+}
 
-	message := mail.NewSingleEmail(fromEmail, contentEmail.Subject, toEmail, plainTextContent, htmlContent)
-	client := sendgrid.NewSendClient(os.Getenv("SENDGRID_API_KEY"))
+// SetDefaultFrom is used when a mail has no From address
+func SetDefaultFrom(from EmailAddress) {
+	defaultFrom = from
+}
 
-	response, err := client.Send(message)
-	if err != nil {
-		global.Logger.Error("Email send failed with SendGrid::", zap.Error(err))
+// SendEmail validates the recipients and hands the mail to the configured sender
+func SendEmail(ctx context.Context, m *Mail) error {
+	if len(m.To)+len(m.Cc)+len(m.Bcc) == 0 {
+		return errors.New("mail has no recipient")
+	}
+	if m.From.Address == "" {
+		m.From.Address = defaultFrom.Address
+		if m.From.Name == "" {
+			m.From.Name = defaultFrom.Name
+		}
+	}
+	if err := emailSender.SendEmail(ctx, m); err != nil {
+		global.Logger.Error("Email send failed::", zap.Strings("to", m.To), zap.String("subject", m.Subject), zap.Error(err))
 		return err
 	}
+	return nil
+}
 
-	if response.StatusCode != 202 {
-		// Handle failed response from SendGrid
-		log.Printf("Failed to send email, Status Code: %d, Body: %s", response.StatusCode, response.Body)
-		return fmt.Errorf("failed to send email, status code: %d", response.StatusCode)
+func SendTextEmailOtp(to []string, from string, otp string) error {
+	contentEmail := Mail{
+		From:     EmailAddress{Address: from, Name: "OTP Service"},
+		To:       to,
+		Subject:  "OTP Verification",
+		Body:     fmt.Sprintf("Your OTP is %s. Please enter it to verify your account.", otp),
+		HTMLBody: fmt.Sprintf("<strong>Your OTP is %s. Please enter it to verify your account.</strong>", otp),
 	}
-
-	return nil
+	return SendEmail(context.Background(), &contentEmail)
 }
@@ -0,0 +1,111 @@
+package sendto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go_ecommerce/global"
+
+	"go.uber.org/zap"
+)
+
+// SMSSender delivers a text message to a phone number in E.164 format
+type SMSSender interface {
+	SendSMS(ctx context.Context, to string, message string) error
+}
+
+// FileSMSSender appends messages to a file, or writes them to stdout when Path is empty.
+// For development and tests, nothing leaves the machine.
+type FileSMSSender struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func NewFileSMSSender(path string) *FileSMSSender {
+	return &FileSMSSender{Path: path}
+}
+
+func (s *FileSMSSender) SendSMS(ctx context.Context, to string, message string) error {
+	line := fmt.Sprintf("%s\t%s\t%s\n", time.Now().Format(time.RFC3339), to, message)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Path == "" {
+		_, err := os.Stdout.WriteString("[SMS] " + line)
+		return err
+	}
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(line)
+	return err
+}
+
+// HTTPSMSSender posts {"from","to","text"} as JSON to an SMS gateway, authenticated with a bearer API key
+type HTTPSMSSender struct {
+	URL    string
+	APIKey string
+	From   string // brandname registered with the gateway
+	Client *http.Client
+}
+
+func NewHTTPSMSSender(url string, apiKey string, from string, timeout time.Duration) *HTTPSMSSender {
+	return &HTTPSMSSender{
+		URL:    url,
+		APIKey: apiKey,
+		From:   from,
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *HTTPSMSSender) SendSMS(ctx context.Context, to string, message string) error {
+	body, err := json.Marshal(map[string]string{
+		"from": s.From,
+		"to":   to,
+		"text": message,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("sms gateway returned status %d: %s", res.StatusCode, resBody)
+	}
+	return nil
+}
+
+var smsSender SMSSender = NewFileSMSSender("")
+
+// SetSMSSender replaces the sender used by SendTextSmsOtp
+func SetSMSSender(s SMSSender) {
+	smsSender = s
+}
+
+func SendTextSmsOtp(ctx context.Context, to string, otp string) error {
+	err := smsSender.SendSMS(ctx, to, fmt.Sprintf("GN Farm: ma OTP cua ban la %s. Khong chia se ma nay cho bat ky ai.", otp))
+	if err != nil {
+		global.Logger.Error("SMS send failed::", zap.String("to", to), zap.Error(err))
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package sendto
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPSender sends through a plain SMTP server. Port 465 uses implicit TLS,
+// other ports upgrade with STARTTLS when the server offers it.
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// dial and whole-conversation limit, a stalled server must not hang the request
+	Timeout time.Duration
+}
+
+func NewSMTPSender(host string, port int, username string, password string, timeout time.Duration) *SMTPSender {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &SMTPSender{Host: host, Port: port, Username: username, Password: password, Timeout: timeout}
+}
+
+func (s *SMTPSender) SendEmail(ctx context.Context, m *Mail) error {
+	msg, err := BuildMIME(m)
+	if err != nil {
+		return err
+	}
+	recipients := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	recipients = append(recipients, m.To...)
+	recipients = append(recipients, m.Cc...)
+	recipients = append(recipients, m.Bcc...)
+
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	// net/smtp has no context support, the deadline covers every command
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if s.Port != 465 {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err = c.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+				return err
+			}
+		}
+	}
+	if s.Username != "" {
+		if err = c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(m.From.Address); err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		if err = c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// dial opens the connection, TLS from the first byte on port 465
+func (s *SMTPSender) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	netDialer := &net.Dialer{Timeout: s.Timeout}
+	if s.Port == 465 {
+		dialer := &tls.Dialer{NetDialer: netDialer, Config: &tls.Config{ServerName: s.Host}}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	return netDialer.DialContext(ctx, "tcp", addr)
+}
@@ -0,0 +1,66 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go_ecommerce/global"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// A session (subToken) is a refresh token family: only the latest refresh
+// token of the family is valid, every rotated token id is remembered so a
+// replay can be detected and the whole family revoked.
+
+var (
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or session expired")
+)
+
+func getRefreshKey(subToken string) string {
+	return subToken + "_refresh"
+}
+
+func getUsedRefreshKey(jti string) string {
+	return "rt:used:" + jti
+}
+
+// SetRefreshToken stores the id (jti) of the current refresh token of the family
+func SetRefreshToken(ctx context.Context, subToken string, jti string, ttl time.Duration) error {
+	return global.Rdb.Set(ctx, getRefreshKey(subToken), jti, ttl).Err()
+}
+
+// RotateRefreshToken invalidates oldJti and makes newJti the current token of the family.
+// ErrRefreshTokenReused means oldJti was already rotated: the caller must revoke the family
+func RotateRefreshToken(ctx context.Context, userId uint64, subToken string, oldJti string, newJti string, ttl time.Duration) error {
+	// mark as used first so two concurrent refreshes with the same token cannot both win
+	first, err := global.Rdb.SetNX(ctx, getUsedRefreshKey(oldJti), subToken, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !first {
+		return ErrRefreshTokenReused
+	}
+
+	current, err := global.Rdb.Get(ctx, getRefreshKey(subToken)).Result()
+	if err == redis.Nil {
+		return ErrRefreshTokenInvalid
+	} else if err != nil {
+		return err
+	}
+	if current != oldJti {
+		// signed by us, same family, but not the latest one
+		return ErrRefreshTokenReused
+	}
+
+	// the user-info payload under subToken is kept, only its TTL is extended
+	pipe := global.Rdb.TxPipeline()
+	pipe.Set(ctx, getRefreshKey(subToken), newJti, ttl)
+	pipe.Expire(ctx, subToken, ttl)
+	pipe.Expire(ctx, getMetaKey(subToken), ttl)
+	pipe.ExpireGT(ctx, getUserSessionsKey(userId), ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,54 @@
+package session
+
+import (
+	"context"
+	"strings"
+
+	"go_ecommerce/global"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The roles of the user are cached in the session metadata so permission
+// checks don't hit MySQL on every request. Changing roles clears the cache
+// of every session of the user.
+
+const rolesField = "roles"
+
+// CachedRoles returns the cached roles of a session, found is false on a cache miss
+func CachedRoles(ctx context.Context, subToken string) (roles []string, found bool, err error) {
+	val, err := global.Rdb.HGet(ctx, getMetaKey(subToken), rolesField).Result()
+	if err == redis.Nil {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	if val == "" {
+		return []string{}, true, nil
+	}
+	return strings.Split(val, ","), true, nil
+}
+
+// CacheRoles stores the roles of a session, sessions without metadata are not cached
+func CacheRoles(ctx context.Context, subToken string, roles []string) error {
+	// HSet on a missing key would create a hash without TTL
+	hasMeta, err := global.Rdb.Exists(ctx, getMetaKey(subToken)).Result()
+	if err != nil || hasMeta == 0 {
+		return err
+	}
+	return global.Rdb.HSet(ctx, getMetaKey(subToken), rolesField, strings.Join(roles, ",")).Err()
+}
+
+// ClearRoles drops the cached roles of every session of the user
+func ClearRoles(ctx context.Context, userId uint64) error {
+	subTokens, err := global.Rdb.SMembers(ctx, getUserSessionsKey(userId)).Result()
+	if err != nil {
+		return err
+	}
+	pipe := global.Rdb.Pipeline()
+	for _, subToken := range subTokens {
+		pipe.HDel(ctx, getMetaKey(subToken), rolesField)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
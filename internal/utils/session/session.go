@@ -0,0 +1,225 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/crypto"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Info describes one login session (one subToken) of a user
+type Info struct {
+	SessionId  string `json:"session_id" redis:"session_id"`
+	Device     string `json:"device" redis:"device"`
+	ClientIp   string `json:"client_ip" redis:"client_ip"`
+	UserAgent  string `json:"user_agent" redis:"user_agent"`
+	CreatedAt  int64  `json:"created_at" redis:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at" redis:"last_seen_at"`
+	AuthLevel  int    `json:"auth_level" redis:"auth_level"` // 1 password, 2 second factor verified
+	Admin      bool   `json:"admin" redis:"admin"`           // opened by the admin login
+	SubToken   string `json:"-" redis:"sub_token"`
+}
+
+// index of all subTokens of a user
+func getUserSessionsKey(userId uint64) string {
+	return fmt.Sprintf("sess:user:%d", userId)
+}
+
+func getMetaKey(subToken string) string {
+	return "sess:meta:" + subToken
+}
+
+// ID is the public identifier of a session, the subToken itself is never listed
+func ID(subToken string) string {
+	return crypto.GetHash(subToken)[:16]
+}
+
+// Register adds the session to the user's index, call after the subToken key is written
+func Register(ctx context.Context, userId uint64, subToken string, info Info, ttl time.Duration) error {
+	now := time.Now().Unix()
+	info.SessionId = ID(subToken)
+	info.SubToken = subToken
+	info.CreatedAt = now
+	info.LastSeenAt = now
+	if info.Device == "" {
+		info.Device = DeviceFromUserAgent(info.UserAgent)
+	}
+
+	pipe := global.Rdb.TxPipeline()
+	pipe.HSet(ctx, getMetaKey(subToken), info)
+	pipe.Expire(ctx, getMetaKey(subToken), ttl)
+	pipe.SAdd(ctx, getUserSessionsKey(userId), subToken)
+	pipe.Expire(ctx, getUserSessionsKey(userId), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Touch updates last-seen time, returns false when the session was revoked or expired
+func Touch(ctx context.Context, subToken string) (bool, error) {
+	exists, err := global.Rdb.Exists(ctx, subToken).Result()
+	if err != nil {
+		return false, err
+	}
+	if exists == 0 {
+		return false, nil
+	}
+	// meta may be missing for sessions created before the index existed,
+	// HSet on a missing key would create a hash without TTL
+	hasMeta, err := global.Rdb.Exists(ctx, getMetaKey(subToken)).Result()
+	if err != nil || hasMeta == 0 {
+		return true, err
+	}
+	return true, global.Rdb.HSet(ctx, getMetaKey(subToken), "last_seen_at", time.Now().Unix()).Err()
+}
+
+// SetAuthLevel raises the level of a live session after a step-up verification,
+// returns false for sessions without metadata
+func SetAuthLevel(ctx context.Context, subToken string, authLevel int) (bool, error) {
+	hasMeta, err := global.Rdb.Exists(ctx, getMetaKey(subToken)).Result()
+	if err != nil || hasMeta == 0 {
+		return false, err
+	}
+	return true, global.Rdb.HSet(ctx, getMetaKey(subToken), "auth_level", authLevel).Err()
+}
+
+// Get returns the metadata of a session, found is false for sessions without metadata
+func Get(ctx context.Context, subToken string) (info Info, found bool, err error) {
+	res := global.Rdb.HGetAll(ctx, getMetaKey(subToken))
+	if err = res.Err(); err != nil || len(res.Val()) == 0 {
+		return info, false, err
+	}
+	if err = res.Scan(&info); err != nil {
+		return info, false, err
+	}
+	return info, true, nil
+}
+
+// List returns the active sessions of a user, expired entries are pruned from the index
+func List(ctx context.Context, userId uint64) ([]Info, error) {
+	subTokens, err := global.Rdb.SMembers(ctx, getUserSessionsKey(userId)).Result()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Info, 0, len(subTokens))
+	for _, subToken := range subTokens {
+		var info Info
+		res := global.Rdb.HGetAll(ctx, getMetaKey(subToken))
+		if err := res.Err(); err != nil {
+			return nil, err
+		}
+		alive, err := global.Rdb.Exists(ctx, subToken).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Val()) == 0 || alive == 0 {
+			global.Rdb.SRem(ctx, getUserSessionsKey(userId), subToken)
+			continue
+		}
+		if err := res.Scan(&info); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, info)
+	}
+	return sessions, nil
+}
+
+// Revoke deletes one session: user-info, refresh token and metadata
+func Revoke(ctx context.Context, userId uint64, subToken string) error {
+	pipe := global.Rdb.TxPipeline()
+	pipe.Del(ctx, subToken, subToken+"_refresh", getMetaKey(subToken))
+	pipe.SRem(ctx, getUserSessionsKey(userId), subToken)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RevokeByID revokes the session with the given public ID, returns false if not found
+func RevokeByID(ctx context.Context, userId uint64, sessionId string) (bool, error) {
+	subTokens, err := global.Rdb.SMembers(ctx, getUserSessionsKey(userId)).Result()
+	if err != nil {
+		return false, err
+	}
+	for _, subToken := range subTokens {
+		if ID(subToken) == sessionId {
+			return true, Revoke(ctx, userId, subToken)
+		}
+	}
+	return false, nil
+}
+
+// RevokeOthers revokes every session of the user except keepSubToken, e.g. after a password change
+func RevokeOthers(ctx context.Context, userId uint64, keepSubToken string) error {
+	subTokens, err := global.Rdb.SMembers(ctx, getUserSessionsKey(userId)).Result()
+	if err != nil {
+		return err
+	}
+	for _, subToken := range subTokens {
+		if subToken == keepSubToken {
+			continue
+		}
+		if err := Revoke(ctx, userId, subToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefreshUserInfo replaces the cached user-info payload of every live session, TTLs are kept
+func RefreshUserInfo(ctx context.Context, userId uint64, payload []byte) error {
+	subTokens, err := global.Rdb.SMembers(ctx, getUserSessionsKey(userId)).Result()
+	if err != nil {
+		return err
+	}
+	for _, subToken := range subTokens {
+		// XX: a session that already expired must not come back without TTL
+		err := global.Rdb.SetArgs(ctx, subToken, payload, redis.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeAll deletes every session of the user
+func RevokeAll(ctx context.Context, userId uint64) error {
+	subTokens, err := global.Rdb.SMembers(ctx, getUserSessionsKey(userId)).Result()
+	if err != nil {
+		return err
+	}
+	for _, subToken := range subTokens {
+		if err := Revoke(ctx, userId, subToken); err != nil {
+			return err
+		}
+	}
+	return global.Rdb.Del(ctx, getUserSessionsKey(userId)).Err()
+}
+
+// DeviceFromUserAgent gives a short, human readable device label
+func DeviceFromUserAgent(ua string) string {
+	ua = strings.ToLower(ua)
+	switch {
+	case ua == "":
+		return "Unknown"
+	case strings.Contains(ua, "ipad"):
+		return "iPad"
+	case strings.Contains(ua, "iphone"):
+		return "iPhone"
+	case strings.Contains(ua, "android") && strings.Contains(ua, "mobile"):
+		return "Android phone"
+	case strings.Contains(ua, "android"):
+		return "Android tablet"
+	case strings.Contains(ua, "windows"):
+		return "Windows"
+	case strings.Contains(ua, "mac os"):
+		return "Mac"
+	case strings.Contains(ua, "linux"):
+		return "Linux"
+	case strings.Contains(ua, "okhttp"), strings.Contains(ua, "dart"):
+		return "Mobile app"
+	}
+	return "Unknown"
+}
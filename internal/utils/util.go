@@ -13,6 +13,11 @@ func GetUserKey(hashKey string) string {
 	return fmt.Sprintf("u:%s:otp", hashKey)
 }
 
+// GetUserPurposeKey is the OTP key for purposes other than registration, e.g. reset password
+func GetUserPurposeKey(hashKey string, purpose string) string {
+	return fmt.Sprintf("u:%s:otp:%s", hashKey, strings.ToLower(purpose))
+}
+
 func GenerateCliTokenUUID(userId int) string {
 	newUUID := uuid.New()
 	// convert UUID to string, remove -
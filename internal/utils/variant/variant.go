@@ -0,0 +1,304 @@
+package variant
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go_ecommerce/internal/model"
+)
+
+const (
+	MaxOptions     = 3   // e.g. size, packaging, color
+	MaxValues      = 20  // values of one option
+	MaxSkus        = 100 // SKUs of one product
+	maxNameLength  = 50  // product_options.option_name, and a value
+	maxCodeLength  = 64  // product_skus.sku_code
+	DefaultSkuCode = "DEFAULT"
+)
+
+// FieldError is the error of one field of the matrix, e.g. skus[2].options.size
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// InvalidError lists every invalid field of the matrix
+type InvalidError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *InvalidError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return "invalid variants: " + strings.Join(msgs, "; ")
+}
+
+// Defaults fill what a new SKU row leaves out
+type Defaults struct {
+	Price         float64
+	DiscountPrice float64
+	Stock         int // stock of the single SKU of a product without options
+}
+
+type builder struct {
+	errs []FieldError
+}
+
+func (b *builder) fail(field, format string, args ...interface{}) {
+	b.errs = append(b.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Build validates the options and SKU rows of a product and returns them normalized.
+// Without rows every combination of option values becomes a SKU, without options the
+// product has one DEFAULT SKU. existing are the current SKUs of the product: a row with the
+// same code keeps the SKU id, and its price and stock when the row leaves them out
+func Build(options []model.ProductOption, rows []model.SkuInput, existing []model.SkuModel, d Defaults) ([]model.ProductOption, []model.SkuModel, error) {
+	b := &builder{}
+	options = b.options(options)
+	if len(b.errs) > 0 {
+		return nil, nil, &InvalidError{Fields: b.errs}
+	}
+
+	if len(rows) == 0 {
+		combinations := 1
+		for _, o := range options {
+			combinations *= len(o.Values)
+		}
+		if combinations > MaxSkus {
+			b.fail("options", "give %d combinations, at most %d SKUs are allowed", combinations, MaxSkus)
+		} else {
+			rows = matrix(options)
+		}
+	} else if len(rows) > MaxSkus {
+		b.fail("skus", "at most %d SKUs are allowed", MaxSkus)
+	} else if len(options) == 0 && len(rows) > 1 {
+		b.fail("skus", "a product without options has one SKU")
+	}
+	if len(b.errs) > 0 {
+		return nil, nil, &InvalidError{Fields: b.errs}
+	}
+
+	byCode := make(map[string]model.SkuModel, len(existing))
+	for _, sku := range existing {
+		byCode[sku.SkuCode] = sku
+	}
+	codes := map[string]int{}
+	combinations := map[string]int{}
+	skus := make([]model.SkuModel, 0, len(rows))
+	for i, row := range rows {
+		field := fmt.Sprintf("skus[%d]", i)
+		values, key := b.skuOptions(field, options, row.Options)
+		if j, dup := combinations[key]; dup && key != "" {
+			b.fail(field+".options", "same options as skus[%d]", j)
+		}
+		combinations[key] = i
+
+		code := strings.TrimSpace(row.SkuCode)
+		if code == "" {
+			code = skuCode(options, values)
+		}
+		if utf8.RuneCountInString(code) > maxCodeLength {
+			b.fail(field+".sku_code", "must be at most %d characters", maxCodeLength)
+		}
+		if j, dup := codes[code]; dup {
+			b.fail(field+".sku_code", "same code as skus[%d]", j)
+		}
+		codes[code] = i
+
+		current, found := byCode[code]
+		sku := model.SkuModel{
+			ID:            current.ID,
+			SkuCode:       code,
+			Options:       values,
+			Price:         row.Price,
+			DiscountPrice: row.DiscountPrice,
+			Weight:        row.Weight,
+		}
+		// price and discount go together, a row without price keeps both
+		if row.Price == 0 {
+			sku.Price, sku.DiscountPrice = d.Price, d.DiscountPrice
+			if found {
+				sku.Price, sku.DiscountPrice = current.Price, current.DiscountPrice
+			}
+		}
+		if row.Weight == 0 && found {
+			sku.Weight = current.Weight
+		}
+		switch {
+		case row.Stock != nil:
+			sku.Stock = *row.Stock
+		case found:
+			sku.Stock = current.Stock
+		case len(options) == 0:
+			sku.Stock = d.Stock
+		}
+
+		if sku.Price <= 0 {
+			b.fail(field+".price", "must be greater than 0")
+		}
+		if sku.DiscountPrice < 0 || (sku.DiscountPrice > 0 && sku.DiscountPrice >= sku.Price) {
+			b.fail(field+".discounted_price", "must be lower than the price")
+		}
+		if sku.Weight < 0 {
+			b.fail(field+".weight", "must not be negative")
+		}
+		if sku.Stock < 0 {
+			b.fail(field+".stock", "must not be negative")
+		}
+		skus = append(skus, sku)
+	}
+	if len(b.errs) > 0 {
+		return nil, nil, &InvalidError{Fields: b.errs}
+	}
+	return options, skus, nil
+}
+
+// options trims names and values and checks they are unique
+func (b *builder) options(options []model.ProductOption) []model.ProductOption {
+	if len(options) > MaxOptions {
+		b.fail("options", "at most %d options are allowed", MaxOptions)
+		return nil
+	}
+	out := make([]model.ProductOption, 0, len(options))
+	names := map[string]bool{}
+	for i, o := range options {
+		field := fmt.Sprintf("options[%d]", i)
+		name := strings.TrimSpace(o.Name)
+		switch {
+		case name == "":
+			b.fail(field+".name", "is required")
+		case utf8.RuneCountInString(name) > maxNameLength:
+			b.fail(field+".name", "must be at most %d characters", maxNameLength)
+		case names[name]:
+			b.fail(field+".name", "is used by another option")
+		}
+		names[name] = true
+
+		if len(o.Values) == 0 || len(o.Values) > MaxValues {
+			b.fail(field+".values", "must have 1 to %d values", MaxValues)
+		}
+		values := make([]string, 0, len(o.Values))
+		seen := map[string]bool{}
+		for j, v := range o.Values {
+			v = strings.TrimSpace(v)
+			switch {
+			case v == "":
+				b.fail(fmt.Sprintf("%s.values[%d]", field, j), "is required")
+			case utf8.RuneCountInString(v) > maxNameLength:
+				b.fail(fmt.Sprintf("%s.values[%d]", field, j), "must be at most %d characters", maxNameLength)
+			case seen[v]:
+				b.fail(fmt.Sprintf("%s.values[%d]", field, j), "is listed twice")
+			}
+			seen[v] = true
+			values = append(values, v)
+		}
+		out = append(out, model.ProductOption{Name: name, Values: values})
+	}
+	return out
+}
+
+// skuOptions checks that the row has one valid value for each option, key identifies the combination
+func (b *builder) skuOptions(field string, options []model.ProductOption, given map[string]string) (map[string]string, string) {
+	values := make(map[string]string, len(options))
+	parts := make([]string, 0, len(options))
+	for _, o := range options {
+		v, ok := given[o.Name]
+		v = strings.TrimSpace(v)
+		switch {
+		case !ok || v == "":
+			b.fail(field+".options."+o.Name, "is required")
+		case !contains(o.Values, v):
+			b.fail(field+".options."+o.Name, "must be one of %s", strings.Join(o.Values, ", "))
+		}
+		values[o.Name] = v
+		parts = append(parts, v)
+	}
+	var unknown []string
+	for name := range given {
+		if !hasOption(options, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		b.fail(field+".options."+name, "is not an option of the product")
+	}
+	return values, strings.Join(parts, "\x00")
+}
+
+// matrix returns a row for every combination of option values, in option order
+func matrix(options []model.ProductOption) []model.SkuInput {
+	combos := []map[string]string{{}}
+	for _, o := range options {
+		next := make([]map[string]string, 0, len(combos)*len(o.Values))
+		for _, c := range combos {
+			for _, v := range o.Values {
+				m := make(map[string]string, len(c)+1)
+				for k, cv := range c {
+					m[k] = cv
+				}
+				m[o.Name] = v
+				next = append(next, m)
+			}
+		}
+		combos = next
+	}
+	rows := make([]model.SkuInput, 0, len(combos))
+	for _, c := range combos {
+		rows = append(rows, model.SkuInput{Options: c})
+	}
+	return rows
+}
+
+// skuCode derives a code from the option values, e.g. 500G-BAG
+func skuCode(options []model.ProductOption, values map[string]string) string {
+	if len(options) == 0 {
+		return DefaultSkuCode
+	}
+	parts := make([]string, 0, len(options))
+	for _, o := range options {
+		parts = append(parts, strings.Join(strings.Fields(strings.ToUpper(values[o.Name])), "_"))
+	}
+	return strings.Join(parts, "-")
+}
+
+// Summary is what the products row shows: the price and discounted price of the SKU that sells
+// the cheapest, and the total stock
+func Summary(skus []model.SkuModel) (price float64, discountPrice float64, quantity int) {
+	for i, sku := range skus {
+		if i == 0 || effective(sku) < effective(model.SkuModel{Price: price, DiscountPrice: discountPrice}) {
+			price, discountPrice = sku.Price, sku.DiscountPrice
+		}
+		quantity += sku.Stock
+	}
+	return price, discountPrice, quantity
+}
+
+func effective(sku model.SkuModel) float64 {
+	if sku.DiscountPrice > 0 {
+		return sku.DiscountPrice
+	}
+	return sku.Price
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func hasOption(options []model.ProductOption, name string) bool {
+	for _, o := range options {
+		if o.Name == name {
+			return true
+		}
+	}
+	return false
+}
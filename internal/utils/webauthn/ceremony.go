@@ -0,0 +1,109 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/crypto"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// what a challenge was issued for, a challenge can't be used for another purpose
+const (
+	PurposeRegister     = "register"
+	PurposeLogin        = "login"         // passkey as the only factor
+	PurposeSecondFactor = "second_factor" // completes a password login challenge
+	PurposeStepUp       = "step_up"       // raises the level of the current session
+)
+
+var (
+	ErrInvalidChallenge = errors.New("passkey challenge is invalid or expired")
+	ErrDisabled         = errors.New("passkeys are not configured")
+)
+
+// Ceremony is a pending registration or authentication, stored under its challenge
+type Ceremony struct {
+	Purpose        string `json:"purpose"`
+	UserId         uint64 `json:"user_id"`                   // 0 for a login where the user picks the passkey
+	LoginChallenge string `json:"login_challenge,omitempty"` // PurposeSecondFactor
+	SessionId      string `json:"session_id,omitempty"`      // PurposeRegister and PurposeStepUp
+}
+
+func getCeremonyKey(challenge string) string {
+	return "webauthn:challenge:" + crypto.GetHash(challenge)
+}
+
+// NewChallenge returns 32 random bytes in base64url
+func NewChallenge() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// StartCeremony stores the ceremony and returns its challenge
+func StartCeremony(ctx context.Context, c Ceremony, ttl time.Duration) (challenge string, err error) {
+	challenge = NewChallenge()
+	ceremonyJson, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	if err = global.Rdb.Set(ctx, getCeremonyKey(challenge), ceremonyJson, ttl).Err(); err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// TakeCeremony returns the pending ceremony of the challenge, a challenge can be used once
+// and only for one of the given purposes
+func TakeCeremony(ctx context.Context, challenge string, purposes ...string) (Ceremony, error) {
+	var c Ceremony
+	if challenge == "" {
+		return c, ErrInvalidChallenge
+	}
+	val, err := global.Rdb.GetDel(ctx, getCeremonyKey(challenge)).Result()
+	if err == redis.Nil {
+		return c, ErrInvalidChallenge
+	} else if err != nil {
+		return c, err
+	}
+	if err = json.Unmarshal([]byte(val), &c); err != nil {
+		return c, ErrInvalidChallenge
+	}
+	for _, purpose := range purposes {
+		if c.Purpose == purpose {
+			return c, nil
+		}
+	}
+	return c, ErrInvalidChallenge
+}
+
+var (
+	rpMu sync.RWMutex
+	rp   *RelyingParty
+)
+
+// SetRelyingParty enables passkeys, nil disables them
+func SetRelyingParty(p *RelyingParty) {
+	rpMu.Lock()
+	defer rpMu.Unlock()
+	rp = p
+}
+
+// GetRelyingParty returns the configured relying party
+func GetRelyingParty() (*RelyingParty, error) {
+	rpMu.RLock()
+	defer rpMu.RUnlock()
+	if rp == nil {
+		return nil, ErrDisabled
+	}
+	return rp, nil
+}
@@ -0,0 +1,417 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Registration and authentication ceremonies of WebAuthn Level 2
+// (https://www.w3.org/TR/webauthn-2/#sctn-rp-operations). Attestation is
+// not evaluated: options ask for "none" and a passkey is trusted on first use.
+
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+
+	UserVerificationRequired  = "required"
+	UserVerificationPreferred = "preferred"
+
+	// authenticator data flags
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackupState    = 0x10
+	flagAttestedData   = 0x40
+	flagExtensionData  = 0x80
+
+	maxCredentialIDLength = 1023
+)
+
+var (
+	ErrVerification = errors.New("passkey verification failed")
+	// the authenticator counter went backwards: the credential may have been cloned
+	ErrSignCount = errors.New("passkey sign count did not increase")
+)
+
+// RelyingParty is this server as seen by the authenticators
+type RelyingParty struct {
+	ID      string   // registrable domain, e.g. gnfarm.vn
+	Name    string   // shown by the authenticator
+	Origins []string // e.g. https://gnfarm.vn, android:apk-key-hash:...
+	Timeout time.Duration
+}
+
+// User is the account a credential is created for
+type User struct {
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// CredentialDescriptor identifies an existing credential of the user
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"` // base64url
+	Transports []string `json:"transports,omitempty"`
+}
+
+// CreationOptions is the publicKey argument of navigator.credentials.create
+type CreationOptions struct {
+	RP struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          string `json:"id"` // base64url
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	Challenge        string `json:"challenge"`
+	PubKeyCredParams []struct {
+		Type string `json:"type"`
+		Alg  int64  `json:"alg"`
+	} `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"` // milliseconds
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection struct {
+		ResidentKey      string `json:"residentKey"`
+		UserVerification string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+	Attestation string `json:"attestation"`
+}
+
+// RequestOptions is the publicKey argument of navigator.credentials.get
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"` // milliseconds
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// AttestationResponse is the JSON form of the PublicKeyCredential returned by create, binary fields in base64url
+type AttestationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the JSON form of the PublicKeyCredential returned by get, binary fields in base64url
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// CollectedClientData is the clientDataJSON signed by the authenticator
+type CollectedClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// AuthenticatorData is the parsed authenticatorData of a ceremony
+type AuthenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte // COSE_Key
+}
+
+func (a AuthenticatorData) UserPresent() bool    { return a.Flags&flagUserPresent != 0 }
+func (a AuthenticatorData) UserVerified() bool   { return a.Flags&flagUserVerified != 0 }
+func (a AuthenticatorData) BackupEligible() bool { return a.Flags&flagBackupEligible != 0 }
+func (a AuthenticatorData) BackupState() bool    { return a.Flags&flagBackupState != 0 }
+
+// Credential is a verified new credential, to be stored with the user
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // COSE_Key
+	Alg            int64
+	SignCount      uint32
+	AAGUID         []byte
+	Transports     []string
+	UserVerified   bool
+	BackupEligible bool
+	BackupState    bool
+}
+
+// CreationOptions builds the options of a registration ceremony
+func (rp *RelyingParty) CreationOptions(user User, challenge string, exclude []CredentialDescriptor) CreationOptions {
+	var o CreationOptions
+	o.RP.ID, o.RP.Name = rp.ID, rp.Name
+	o.User.ID = base64.RawURLEncoding.EncodeToString(user.ID)
+	o.User.Name, o.User.DisplayName = user.Name, user.DisplayName
+	o.Challenge = challenge
+	for _, alg := range []int64{AlgES256, AlgEdDSA, AlgRS256} {
+		o.PubKeyCredParams = append(o.PubKeyCredParams, struct {
+			Type string `json:"type"`
+			Alg  int64  `json:"alg"`
+		}{Type: "public-key", Alg: alg})
+	}
+	o.Timeout = rp.Timeout.Milliseconds()
+	o.ExcludeCredentials = exclude
+	if o.ExcludeCredentials == nil {
+		o.ExcludeCredentials = []CredentialDescriptor{}
+	}
+	// a discoverable credential allows login without typing the account
+	o.AuthenticatorSelection.ResidentKey = "preferred"
+	o.AuthenticatorSelection.UserVerification = UserVerificationPreferred
+	o.Attestation = "none"
+	return o
+}
+
+// RequestOptions builds the options of an authentication ceremony, an empty allow list lets the user pick a passkey
+func (rp *RelyingParty) RequestOptions(challenge string, allow []CredentialDescriptor, userVerification string) RequestOptions {
+	o := RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.ID,
+		Timeout:          rp.Timeout.Milliseconds(),
+		AllowCredentials: allow,
+		UserVerification: userVerification,
+	}
+	if o.AllowCredentials == nil {
+		o.AllowCredentials = []CredentialDescriptor{}
+	}
+	return o
+}
+
+// ParseClientData decodes clientDataJSON, the challenge in it selects the stored ceremony
+func ParseClientData(clientDataJSON string) (data CollectedClientData, raw []byte, err error) {
+	raw, err = decodeBase64URL(clientDataJSON)
+	if err != nil {
+		return data, nil, err
+	}
+	if err = json.Unmarshal(raw, &data); err != nil {
+		return data, nil, fmt.Errorf("%w: client data is not JSON", ErrVerification)
+	}
+	return data, raw, nil
+}
+
+// VerifyRegistration checks the response of navigator.credentials.create against the issued challenge
+func (rp *RelyingParty) VerifyRegistration(resp *AttestationResponse, challenge string, requireUserVerification bool) (*Credential, error) {
+	if resp.Type != "public-key" {
+		return nil, fmt.Errorf("%w: credential type %q", ErrVerification, resp.Type)
+	}
+	clientData, _, err := ParseClientData(resp.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err = rp.checkClientData(clientData, ceremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := decodeBase64URL(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	v, rest, err := decodeCBOR(rawAttestation)
+	if err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("%w: attestation object is malformed", ErrVerification)
+	}
+	attestation, _ := v.(map[interface{}]interface{})
+	rawAuthData, _ := attestation["authData"].([]byte)
+	if _, ok := attestation["fmt"].(string); !ok || rawAuthData == nil {
+		return nil, fmt.Errorf("%w: attestation object is malformed", ErrVerification)
+	}
+
+	authData, err := ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err = rp.checkAuthenticatorData(authData, requireUserVerification); err != nil {
+		return nil, err
+	}
+	if authData.CredentialID == nil {
+		return nil, fmt.Errorf("%w: no attested credential data", ErrVerification)
+	}
+	key, err := ParsePublicKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if rawID, err := decodeBase64URL(resp.RawID); err != nil || !bytes.Equal(rawID, authData.CredentialID) {
+		return nil, fmt.Errorf("%w: credential id does not match the authenticator data", ErrVerification)
+	}
+
+	return &Credential{
+		ID:             authData.CredentialID,
+		PublicKey:      authData.PublicKey,
+		Alg:            key.Alg,
+		SignCount:      authData.SignCount,
+		AAGUID:         authData.AAGUID,
+		Transports:     resp.Response.Transports,
+		UserVerified:   authData.UserVerified(),
+		BackupEligible: authData.BackupEligible(),
+		BackupState:    authData.BackupState(),
+	}, nil
+}
+
+// VerifyAssertion checks the response of navigator.credentials.get signed by the stored credential
+// and returns the new authenticator data, whose sign count must be stored
+func (rp *RelyingParty) VerifyAssertion(resp *AssertionResponse, challenge string, publicKey []byte, storedSignCount uint32, requireUserVerification bool) (AuthenticatorData, error) {
+	var authData AuthenticatorData
+	if resp.Type != "public-key" {
+		return authData, fmt.Errorf("%w: credential type %q", ErrVerification, resp.Type)
+	}
+	clientData, rawClientData, err := ParseClientData(resp.Response.ClientDataJSON)
+	if err != nil {
+		return authData, err
+	}
+	if err = rp.checkClientData(clientData, ceremonyGet, challenge); err != nil {
+		return authData, err
+	}
+	rawAuthData, err := decodeBase64URL(resp.Response.AuthenticatorData)
+	if err != nil {
+		return authData, err
+	}
+	if authData, err = ParseAuthenticatorData(rawAuthData); err != nil {
+		return authData, err
+	}
+	if err = rp.checkAuthenticatorData(authData, requireUserVerification); err != nil {
+		return authData, err
+	}
+
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return authData, err
+	}
+	sig, err := decodeBase64URL(resp.Response.Signature)
+	if err != nil {
+		return authData, err
+	}
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if !key.Verify(signed, sig) {
+		return authData, fmt.Errorf("%w: bad signature", ErrVerification)
+	}
+
+	// authenticators without a counter always send 0
+	if (authData.SignCount != 0 || storedSignCount != 0) && authData.SignCount <= storedSignCount {
+		return authData, ErrSignCount
+	}
+	return authData, nil
+}
+
+// ParseAuthenticatorData decodes authenticatorData, including the attested credential of a registration
+func ParseAuthenticatorData(raw []byte) (AuthenticatorData, error) {
+	var a AuthenticatorData
+	if len(raw) < 37 {
+		return a, fmt.Errorf("%w: authenticator data is too short", ErrVerification)
+	}
+	a.RPIDHash = raw[:32]
+	a.Flags = raw[32]
+	a.SignCount = binary.BigEndian.Uint32(raw[33:37])
+	rest := raw[37:]
+
+	if a.Flags&flagAttestedData != 0 {
+		if len(rest) < 18 {
+			return a, fmt.Errorf("%w: attested credential data is too short", ErrVerification)
+		}
+		a.AAGUID = rest[:16]
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen == 0 || idLen > maxCredentialIDLength || idLen > len(rest) {
+			return a, fmt.Errorf("%w: credential id length", ErrVerification)
+		}
+		a.CredentialID = rest[:idLen]
+		rest = rest[idLen:]
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return a, fmt.Errorf("%w: credential public key is malformed", ErrVerification)
+		}
+		a.PublicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+	if a.Flags&flagExtensionData != 0 {
+		v, after, err := decodeCBOR(rest)
+		if _, ok := v.(map[interface{}]interface{}); err != nil || !ok {
+			return a, fmt.Errorf("%w: extensions are malformed", ErrVerification)
+		}
+		rest = after
+	}
+	if len(rest) != 0 {
+		return a, fmt.Errorf("%w: trailing authenticator data", ErrVerification)
+	}
+	return a, nil
+}
+
+func (rp *RelyingParty) checkClientData(c CollectedClientData, ceremony string, challenge string) error {
+	if c.Type != ceremony {
+		return fmt.Errorf("%w: client data type %q", ErrVerification, c.Type)
+	}
+	if c.Challenge == "" || c.Challenge != challenge {
+		return fmt.Errorf("%w: challenge does not match", ErrVerification)
+	}
+	if c.CrossOrigin {
+		return fmt.Errorf("%w: cross-origin ceremony", ErrVerification)
+	}
+	for _, origin := range rp.Origins {
+		if c.Origin == origin {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: origin %q is not allowed", ErrVerification, c.Origin)
+}
+
+func (rp *RelyingParty) checkAuthenticatorData(a AuthenticatorData, requireUserVerification bool) error {
+	rpIdHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(a.RPIDHash, rpIdHash[:]) {
+		return fmt.Errorf("%w: credential belongs to another site", ErrVerification)
+	}
+	if !a.UserPresent() {
+		return fmt.Errorf("%w: user was not present", ErrVerification)
+	}
+	if requireUserVerification && !a.UserVerified() {
+		return fmt.Errorf("%w: user was not verified", ErrVerification)
+	}
+	return nil
+}
+
+// EncodeID encodes a credential id or user handle for the browser
+func EncodeID(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeBase64URL accepts padded and unpadded base64url, browsers differ
+func decodeBase64URL(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		b, err = base64.URLEncoding.DecodeString(s)
+	}
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("%w: invalid base64url", ErrVerification)
+	}
+	return b, nil
+}
+
+// DecodeID is the inverse of EncodeID
+func DecodeID(s string) ([]byte, error) {
+	return decodeBase64URL(s)
+}
+
+// UserHandle is the user.id given to authenticators: the account id, no personal data
+func UserHandle(userId uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, userId)
+	return b
+}
@@ -7,6 +7,9 @@ const (
 	ErrInvalidToken     = 30001 // Token is Invalid
 	ErrInvalidOtp       = 30002
 	ErrSendEmailOtp     = 30003
+	ErrCodeOtpLocked    = 30004 // too many wrong OTP, locked for a while
+	ErrCodeOtpTooSoon   = 30005 // OTP resend cooldown
+	ErrSendSmsOtp       = 30006
 	// Register code
 	ErrCodeUserHassExits = 50001 // User has already been assigned
 	// Login code
@@ -14,11 +17,57 @@ const (
 	ErrCodeUserOtpNotExists = 60008
 
 	// User Authentication
-	ErrCodeAuthFailed = 40005
+	ErrCodeAuthFailed      = 40005
+	ErrCodeSessionFailed   = 40006
+	ErrCodeSessionNotFound = 40007
+
+	ErrCodeRefreshTokenReused = 40008
+
+	// Account state
+	ErrCodeUserLocked             = 40010
+	ErrCodeUserNotActivated       = 40011
+	ErrCodeAdminTwoFactorRequired = 40012
+	ErrCodeUserStateInvalid       = 40013
+
+	// Social login (OpenID Connect)
+	ErrCodeOIDCProviderUnknown = 40020
+	ErrCodeOIDCStateInvalid    = 40021
+	ErrCodeOIDCLoginFailed     = 40022
+
+	// Access control
+	ErrCodePermissionDenied = 40301
+	ErrCodeRoleInvalid      = 40302
+	ErrCodeUserNotFound     = 40401
+
+	// Shop API keys
+	ErrCodeApiKeyScopeInvalid = 40303
+	ErrCodeApiKeyLimit        = 40304
+	ErrCodeApiKeyNotFound     = 40402
+
+	// Personal data export and account deletion
+	ErrCodeExportTooSoon   = 40030
+	ErrCodeDeletionPending = 40031
+	ErrCodeExportNotReady  = 40403
+
+	// Passkeys (WebAuthn)
+	ErrCodePasskeyDisabled         = 40040
+	ErrCodePasskeyChallengeInvalid = 40041
+	ErrCodePasskeyVerifyFailed     = 40042
+	ErrCodePasskeyLimit            = 40043
+	ErrCodeStepUpRequired          = 40044
+	ErrCodePasskeyNotFound         = 40404
+
+	// Product
+	ErrCodeProductTypeInvalid       = 40050
+	ErrCodeProductAttributesInvalid = 40051 // detail lists the invalid attributes
+	ErrCodeProductVariantsInvalid   = 40052 // detail lists the invalid options and SKU rows
 
 	// Two Factor Authentication
 	ErrCodeTwoFactorAuthSetupFailed  = 80001
 	ErrCodeTwoFactorAuthVerifyFailed = 80002
+	ErrCodeTwoFactorCodeInvalid      = 80003
+	ErrCodeTwoFactorRecoveryFailed   = 80004
+	ErrCodeTwoFactorChallengeInvalid = 80005
 )
 
 var msg = map[int]string{
@@ -29,13 +78,55 @@ var msg = map[int]string{
 	ErrCodeUserHassExits: "User has already been assigned",
 	ErrInvalidOtp:        "Otp is error",
 	ErrSendEmailOtp:      "Send email failed",
+	ErrCodeOtpLocked:     "Too many failed OTP attempts, try again later",
+	ErrCodeOtpTooSoon:    "OTP was sent recently, try again later",
+	ErrSendSmsOtp:        "Send SMS failed",
 
 	ErrCodeOtpNotExists:     "Otp exists but not registered",
 	ErrCodeUserOtpNotExists: "User OTP not exists",
 
-	ErrCodeAuthFailed: "Authentication failed",
+	ErrCodeAuthFailed:      "Authentication failed",
+	ErrCodeSessionFailed:   "Session operation failed",
+	ErrCodeSessionNotFound: "Session not found",
+
+	ErrCodeRefreshTokenReused: "Refresh token reuse detected, session revoked",
+
+	ErrCodeUserLocked:             "Account is locked",
+	ErrCodeUserNotActivated:       "Account is not activated",
+	ErrCodeAdminTwoFactorRequired: "Admin accounts must enable two-factor authentication",
+	ErrCodeUserStateInvalid:       "Account state does not allow this action",
+
+	ErrCodeOIDCProviderUnknown: "Login provider is not configured",
+	ErrCodeOIDCStateInvalid:    "Login state is invalid or expired",
+	ErrCodeOIDCLoginFailed:     "Login with the provider failed",
+
+	ErrCodePermissionDenied: "Permission denied",
+	ErrCodeRoleInvalid:      "Role is invalid",
+	ErrCodeUserNotFound:     "User not found",
+
+	ErrCodeApiKeyScopeInvalid: "API key scope is invalid or not granted to the account",
+	ErrCodeApiKeyLimit:        "Too many active API keys",
+	ErrCodeApiKeyNotFound:     "API key not found",
+
+	ErrCodeExportTooSoon:   "A data export was requested recently, try again later",
+	ErrCodeDeletionPending: "Account deletion is already scheduled",
+	ErrCodeExportNotReady:  "No data export is ready for download",
+
+	ErrCodePasskeyDisabled:         "Passkeys are not enabled",
+	ErrCodePasskeyChallengeInvalid: "Passkey challenge is invalid or expired",
+	ErrCodePasskeyVerifyFailed:     "Passkey verification failed",
+	ErrCodePasskeyLimit:            "Too many passkeys registered",
+	ErrCodeStepUpRequired:          "Verify this session with your second factor first",
+	ErrCodePasskeyNotFound:         "Passkey not found",
+
+	ErrCodeProductTypeInvalid:       "Product type is not supported",
+	ErrCodeProductAttributesInvalid: "Product attributes are invalid",
+	ErrCodeProductVariantsInvalid:   "Product variants are invalid",
 
 	// Two Factor Authentication
 	ErrCodeTwoFactorAuthSetupFailed:  "Two Factor Authentication setup failed",
 	ErrCodeTwoFactorAuthVerifyFailed: "Two Factor Authentication verify failed",
+	ErrCodeTwoFactorCodeInvalid:      "Two Factor Authentication code is invalid",
+	ErrCodeTwoFactorRecoveryFailed:   "Two Factor Authentication recovery codes failed",
+	ErrCodeTwoFactorChallengeInvalid: "Two Factor Authentication challenge is invalid or expired",
 }
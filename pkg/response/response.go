@@ -39,3 +39,16 @@ func ErrorResponse(c *gin.Context, code int, message string) {
 	})
 
 }
+
+// ErrorDetailResponse is ErrorResponse with data explaining the error, e.g. the invalid fields
+func ErrorDetailResponse(c *gin.Context, code int, message string, detail interface{}) {
+	if message == "" {
+		message = msg[code]
+	}
+
+	c.JSON(http.StatusOK, ResponseData{
+		Code:    code,
+		Message: message,
+		Data:    detail,
+	})
+}
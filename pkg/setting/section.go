@@ -5,6 +5,55 @@ type Config struct {
 	Logger LoggerSetting `mapstructure:"logger"`
 	Redis  RedisSetting  `mapstructure:"redis"`
 	JWT JWTSetting `mapstructure:"jwt"`
+	Password PasswordSetting `mapstructure:"password"`
+	SMS SMSSetting `mapstructure:"sms"`
+	Email EmailSetting `mapstructure:"email"`
+	RBAC RBACSetting `mapstructure:"rbac"`
+	OIDC OIDCSetting `mapstructure:"oidc"`
+	Account AccountSetting `mapstructure:"account"`
+	WebAuthn WebAuthnSetting `mapstructure:"webauthn"`
+	Search SearchSetting `mapstructure:"search"`
+}
+
+// Passkey (WebAuthn) settings, an empty rp_id disables passkeys
+type WebAuthnSetting struct {
+	RPID                string   `mapstructure:"rp_id"`   // registrable domain of the web app, e.g. gnfarm.vn
+	RPName              string   `mapstructure:"rp_name"` // shown by the authenticator
+	Origins             []string `mapstructure:"origins"` // exact origins allowed in client data, e.g. https://gnfarm.vn
+	ChallengeTTLSeconds int      `mapstructure:"challenge_ttl_seconds"`
+}
+
+// Product search settings, 0 uses the default
+type SearchSetting struct {
+	RebuildMinutes int `mapstructure:"rebuild_minutes"` // how often each instance reloads its index, for the writes of the other instances
+}
+
+// Account self-service settings, 0 uses the default
+type AccountSetting struct {
+	DeletionGraceDays     int `mapstructure:"deletion_grace_days"`     // DELETE /user/me takes effect after this, signing in cancels it
+	DeletionSweepMinutes  int `mapstructure:"deletion_sweep_minutes"`  // how often due deletions are processed
+	ExportTTLHours        int `mapstructure:"export_ttl_hours"`        // the archive can be downloaded for this long
+	ExportCooldownMinutes int `mapstructure:"export_cooldown_minutes"` // between two export requests
+}
+
+// Social login (OpenID Connect) settings
+type OIDCSetting struct {
+	StateTTLSeconds int                   `mapstructure:"state_ttl_seconds"` // time to complete the provider login
+	Providers       []OIDCProviderSetting `mapstructure:"providers"`
+}
+
+type OIDCProviderSetting struct {
+	Name         string   `mapstructure:"name"` // used in the URL: /user/oidc/{name}/start
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"` // or env OIDC_<NAME>_CLIENT_SECRET
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+// Access control settings
+type RBACSetting struct {
+	BootstrapAdmins []string `mapstructure:"bootstrap_admins"` // accounts given the admin role at startup
 }
 
 // JWT settings
@@ -12,11 +61,63 @@ type JWTSetting struct {
 	TOKEN_HOUR_LIFESPAN string `mapstructure:"TOKEN_HOUR_LIFESPAN"`
 	API_SECRET_KEY string `mapstructure:"API_SECRET_KEY"`
 	JWT_EXPIRATION string `mapstructure:"JWT_EXPRIRATION"`
+	// asymmetric signing, the active key signs and every listed key verifies
+	ACTIVE_KID          string          `mapstructure:"ACTIVE_KID"`
+	KEYS                []JWTKeySetting `mapstructure:"KEYS"`
+	ACCEPT_LEGACY_HS256 bool            `mapstructure:"ACCEPT_LEGACY_HS256"` // verify old HS256 tokens with API_SECRET_KEY during the migration
+}
+
+// JWTKeySetting is one signing key, retired keys need only the public key
+type JWTKeySetting struct {
+	Kid            string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"algorithm"` // RS256 | EdDSA
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+	RetiredAt      string `mapstructure:"retired_at"` // RFC 3339, empty = in use
+}
+
+// Password hashing settings
+type PasswordSetting struct {
+	Algorithm         string `mapstructure:"algorithm"` // argon2id (default) | bcrypt
+	Argon2Memory      uint32 `mapstructure:"argon2_memory"` // KiB
+	Argon2Iterations  uint32 `mapstructure:"argon2_iterations"`
+	Argon2Parallelism uint8  `mapstructure:"argon2_parallelism"`
+	BcryptCost        int    `mapstructure:"bcrypt_cost"`
+}
+
+// SMS provider settings
+type SMSSetting struct {
+	Provider       string `mapstructure:"provider"`  // file (default) | http
+	FilePath       string `mapstructure:"file_path"` // file provider, empty = stdout
+	GatewayURL     string `mapstructure:"gateway_url"`
+	GatewayAPIKey  string `mapstructure:"gateway_api_key"`
+	Brandname      string `mapstructure:"brandname"`
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"`
+}
+
+// Email delivery settings
+type EmailSetting struct {
+	Provider           string `mapstructure:"provider"` // sendgrid | smtp | outbox, empty = sendgrid when an API key is set
+	SenderEmail        string `mapstructure:"sender_email"`
+	SenderName         string `mapstructure:"sender_name"`
+	SendGridAPIKey     string `mapstructure:"sendgrid_api_key"`
+	SMTPHost           string `mapstructure:"smtp_host"`
+	SMTPPort           int    `mapstructure:"smtp_port"`
+	SMTPUsername       string `mapstructure:"smtp_username"`
+	SMTPPassword       string `mapstructure:"smtp_password"`
+	SMTPTimeoutSeconds int    `mapstructure:"smtp_timeout_seconds"`
+	OutboxDir          string `mapstructure:"outbox_dir"` // outbox provider, empty = memory only
+	OutboxLimit        int    `mapstructure:"outbox_limit"`
+	DevMailEndpoint    bool   `mapstructure:"dev_mail_endpoint"` // GET /dev/mails, needs dev mode and the outbox provider
 }
 
 type ServerSetting struct {
 	Port int `mapstructure:"port"`
 	Mode string `mapstructure:"mode"`
+	// proxies allowed to set X-Forwarded-For, empty = use the peer address
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// header with the ISO country of the client set by the CDN (e.g. CF-IPCountry), empty = unknown
+	CountryHeader string `mapstructure:"country_header"`
 }
 type MySQLSetting struct {
 	Host string `mapstructure:"host"`
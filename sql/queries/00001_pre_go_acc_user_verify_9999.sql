@@ -1,29 +1,53 @@
 -- name: GetValidOTP :one
 SELECT verify_otp, verify_key_hash, verify_key, verify_id
 FROM `pre_go_acc_user_verify_9999`
-WHERE verify_key_hash = ? AND is_verified = 0;
+WHERE verify_key_hash = ? AND verify_purpose = ? AND is_verified = 0;
 
 -- update lai
 -- name: UpdateUserVerificationStatus :exec
 UPDATE `pre_go_acc_user_verify_9999`
 SET is_verified = 1,
     verify_updated_at = now()
-WHERE verify_key_hash = ?;
+WHERE verify_key_hash = ? AND verify_purpose = ?;
 
+-- a pending OTP is used once: is_verified 0 -> 1, no row when it was used already
+-- name: UsePendingVerification :execrows
+UPDATE `pre_go_acc_user_verify_9999`
+SET is_verified = 1,
+    verify_updated_at = now()
+WHERE verify_key_hash = ? AND verify_purpose = ? AND is_verified = 0;
+
+-- a verified REGISTER record creates exactly one account: is_verified 1 -> 2
+-- name: ConsumeUserVerification :execrows
+UPDATE `pre_go_acc_user_verify_9999`
+SET is_verified = 2,
+    verify_updated_at = now()
+WHERE verify_key_hash = ? AND verify_purpose = ? AND is_verified = 1;
+
+-- name: DeleteUserVerification :exec
+DELETE FROM `pre_go_acc_user_verify_9999`
+WHERE verify_key_hash = ? AND verify_purpose = ?;
+
+-- a new OTP for the same key and purpose replaces the previous one
 -- name: InsertOTPVerify :execresult
 INSERT INTO `pre_go_acc_user_verify_9999` (
     verify_otp,
     verify_key,
     verify_key_hash, 
     verify_type, 
+    verify_purpose,
     is_verified, 
     is_deleted, 
     verify_created_at, 
     verify_updated_at
 )
-VALUES (?, ?, ?, ?, 0, 0, NOW(), NOW());
+VALUES (?, ?, ?, ?, ?, 0, 0, NOW(), NOW())
+ON DUPLICATE KEY UPDATE
+    verify_otp = VALUES(verify_otp),
+    is_verified = 0,
+    verify_updated_at = NOW();
 
 -- name: GetInfoOTP :one
-SELECT verify_id, verify_otp, verify_key, verify_key_hash, verify_type, is_verified, is_deleted, verify_created_at, verify_updated_at
+SELECT verify_id, verify_otp, verify_key, verify_key_hash, verify_type, is_verified, is_deleted, verify_created_at, verify_updated_at, verify_purpose
 FROM `pre_go_acc_user_verify_9999`
-WHERE verify_key_hash = ?;
+WHERE verify_key_hash = ? AND verify_purpose = ?;
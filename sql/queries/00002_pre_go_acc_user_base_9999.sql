@@ -31,3 +31,15 @@ UPDATE pre_go_acc_user_base_9999
 SET user_logout_time = NOW()
 WHERE user_account = ?;
 
+-- name: UpdateUserPassword :exec
+UPDATE pre_go_acc_user_base_9999
+SET user_password = ?, user_salt = ?, user_updated_at = NOW()
+WHERE user_id = ?;
+
+-- name: UpdateUserAccount :exec
+UPDATE pre_go_acc_user_base_9999
+SET user_account = ?, user_updated_at = NOW()
+WHERE user_id = ?;
+
+-- name: RemoveUserBase :exec
+DELETE FROM pre_go_acc_user_base_9999 WHERE user_id = ?;
@@ -32,10 +32,11 @@ FROM pre_go_acc_user_info_9999
 WHERE user_id IN (?);
 
 -- name: FindUsers :many
-SELECT * FROM pre_go_acc_user_info_9999 WHERE user_account LIKE ? OR user_nickname LIKE ?;
+SELECT * FROM pre_go_acc_user_info_9999 WHERE user_account LIKE ? OR user_nickname LIKE ?
+ORDER BY user_id LIMIT ? OFFSET ?;
 
 -- name: ListUsers :many
-SELECT * FROM pre_go_acc_user_info_9999 LIMIT ? OFFSET ?;
+SELECT * FROM pre_go_acc_user_info_9999 ORDER BY user_id LIMIT ? OFFSET ?;
 
 
 -- name: RemoveUser :exec
@@ -64,5 +65,22 @@ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 -- name: EditUserByUserId :execresult
 UPDATE `pre_go_acc_user_info_9999`
 SET user_nickname = ?, user_avatar = ?, user_mobile = ?, 
-user_gender = ?, user_birthday = ?, user_email = ?, updated_at = NOW()
+user_gender = ?, user_birthday = ?, updated_at = NOW()
 WHERE user_id = ? AND user_is_authentication = 1;
+
+-- personal data is cleared, the row stays so order records keep their user_id
+-- name: AnonymizeUser :execrows
+UPDATE `pre_go_acc_user_info_9999`
+SET user_account = ?, user_nickname = NULL, user_avatar = NULL, user_mobile = NULL,
+user_gender = NULL, user_birthday = NULL, user_email = NULL, user_state = 0, updated_at = NOW()
+WHERE user_id = ?;
+
+-- name: UpdateUserInfoEmail :exec
+UPDATE `pre_go_acc_user_info_9999`
+SET user_account = ?, user_email = ?, updated_at = NOW()
+WHERE user_id = ?;
+
+-- name: UpdateUserState :execrows
+UPDATE `pre_go_acc_user_info_9999`
+SET user_state = ?, updated_at = NOW()
+WHERE user_id = ? AND user_state = ?;
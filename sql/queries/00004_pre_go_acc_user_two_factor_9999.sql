@@ -5,6 +5,16 @@
 INSERT INTO pre_go_acc_user_two_factor_9999 (user_id, two_factor_auth_type, two_factor_email, two_factor_auth_secret, two_factor_is_active, two_factor_created_at, two_factor_updated_at)
 VALUES (?, ?, ?, "OTP", FALSE, NOW(), NOW());
 
+-- EnableTwoFactorTypeApp
+-- name: EnableTwoFactorTypeApp :exec
+INSERT INTO pre_go_acc_user_two_factor_9999 (user_id, two_factor_auth_type, two_factor_auth_secret, two_factor_is_active, two_factor_created_at, two_factor_updated_at)
+VALUES (?, ?, ?, FALSE, NOW(), NOW());
+
+-- EnableTwoFactorTypeSms
+-- name: EnableTwoFactorTypeSms :exec
+INSERT INTO pre_go_acc_user_two_factor_9999 (user_id, two_factor_auth_type, two_factor_phone, two_factor_auth_secret, two_factor_is_active, two_factor_created_at, two_factor_updated_at)
+VALUES (?, ?, ?, "OTP", FALSE, NOW(), NOW());
+
 -- DisableTwoFactor
 -- name: DisableTwoFactor :exec
 UPDATE pre_go_acc_user_two_factor_9999
@@ -13,7 +23,7 @@ SET two_factor_is_active = FALSE,
 WHERE user_id = ? AND two_factor_auth_type = ?;
 
 -- UpdateTwoFactorStatusVerification
--- name: UpdateTwoFactorStatus :exec
+-- name: UpdateTwoFactorStatus :execrows
 UPDATE pre_go_acc_user_two_factor_9999
 SET two_factor_is_active = TRUE, two_factor_updated_at = NOW()
 WHERE user_id = ? AND two_factor_auth_type = ? AND two_factor_is_active = FALSE;
@@ -72,6 +82,11 @@ WHERE user_id = ? AND two_factor_auth_type = ?;
 DELETE FROM pre_go_acc_user_two_factor_9999
 WHERE user_id = ? AND two_factor_auth_type = ?;
 
+-- RemoveAllTwoFactor
+-- name: RemoveAllTwoFactor :exec
+DELETE FROM pre_go_acc_user_two_factor_9999
+WHERE user_id = ?;
+
 -- CountActiveTwoFactorMethods
 -- name: CountActiveTwoFactorMethods :one
 SELECT COUNT(*)
@@ -93,3 +108,12 @@ SELECT two_factor_id, user_id, two_factor_auth_type, two_factor_auth_secret,
        two_factor_is_active, two_factor_created_at, two_factor_updated_at
 FROM pre_go_acc_user_two_factor_9999
 WHERE user_id = ? AND two_factor_auth_type = ?;
+
+-- GetActiveTwoFactorMethod
+-- name: GetActiveTwoFactorMethod :one
+SELECT two_factor_id, user_id, two_factor_auth_type, two_factor_auth_secret, 
+       two_factor_phone, two_factor_email, 
+       two_factor_is_active, two_factor_created_at, two_factor_updated_at
+FROM pre_go_acc_user_two_factor_9999
+WHERE user_id = ? AND two_factor_is_active = TRUE
+LIMIT 1;
@@ -53,6 +53,19 @@ WHERE id = ? LIMIT 1;
 SELECT * FROM bonsais
 WHERE id = ? LIMIT 1;
 
+-- attributes of a page of products, one query per product type
+-- name: ListMushroomsByIDs :many
+SELECT * FROM mushrooms
+WHERE id IN (sqlc.slice('ids'));
+
+-- name: ListVegetablesByIDs :many
+SELECT * FROM vegetables
+WHERE id IN (sqlc.slice('ids'));
+
+-- name: ListBonsaisByIDs :many
+SELECT * FROM bonsais
+WHERE id IN (sqlc.slice('ids'));
+
 -- name: UpdateProduct :execresult
 UPDATE products
 SET 
@@ -106,6 +119,21 @@ UPDATE products
 SET is_draft = true, is_published = false
 WHERE id = ? AND product_shop = ?;
 
+-- the products UnpublishShopProducts takes out of the search index
+-- name: ListPublishedShopProductIDs :many
+SELECT id FROM products
+WHERE product_shop = ? AND is_published = true;
+
+-- name: UnpublishShopProducts :execrows
+UPDATE products
+SET is_draft = true, is_published = false
+WHERE product_shop = ? AND is_published = true;
+
+-- type rows and inventory are removed by ON DELETE CASCADE
+-- name: DeleteProduct :execrows
+DELETE FROM products
+WHERE id = ?;
+
 -- name: ListDraftProducts :many
 SELECT * FROM products
 WHERE product_shop = ? AND is_draft = true
@@ -138,16 +166,6 @@ LIMIT ? OFFSET ?;
 SELECT COUNT(*) FROM products
 WHERE product_type = ? AND is_published = true;
 
--- name: SearchProductsByName :many
-SELECT * FROM products
-WHERE product_name LIKE ? AND is_published = true
-ORDER BY created_at DESC
-LIMIT ? OFFSET ?;
-
--- name: CountSearchProductsByName :one
-SELECT COUNT(*) FROM products
-WHERE product_name LIKE ? AND is_published = true;
-
 -- name: ListProductsByDiscount :many
 SELECT * FROM products
 WHERE is_published = true
@@ -0,0 +1,17 @@
+-- name: InsertRecoveryCode :exec
+INSERT INTO `pre_go_acc_user_two_factor_recovery_9999` (user_id, recovery_code_hash, recovery_created_at)
+VALUES (?, ?, NOW());
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM `pre_go_acc_user_two_factor_recovery_9999`
+WHERE user_id = ?;
+
+-- name: UseRecoveryCode :execresult
+UPDATE `pre_go_acc_user_two_factor_recovery_9999`
+SET recovery_used_at = NOW()
+WHERE user_id = ? AND recovery_code_hash = ? AND recovery_used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*)
+FROM `pre_go_acc_user_two_factor_recovery_9999`
+WHERE user_id = ? AND recovery_used_at IS NULL;
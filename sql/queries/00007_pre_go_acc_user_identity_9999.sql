@@ -0,0 +1,23 @@
+-- name: GetUserIdentity :one
+SELECT identity_id, user_id, identity_provider, identity_subject, identity_email, identity_created_at, identity_last_login_at
+FROM `pre_go_acc_user_identity_9999`
+WHERE identity_provider = ? AND identity_subject = ?;
+
+-- name: AddUserIdentity :exec
+INSERT INTO `pre_go_acc_user_identity_9999` (user_id, identity_provider, identity_subject, identity_email, identity_created_at)
+VALUES (?, ?, ?, ?, NOW());
+
+-- name: ListUserIdentities :many
+SELECT identity_id, user_id, identity_provider, identity_subject, identity_email, identity_created_at, identity_last_login_at
+FROM `pre_go_acc_user_identity_9999`
+WHERE user_id = ?
+ORDER BY identity_id;
+
+-- name: TouchUserIdentity :exec
+UPDATE `pre_go_acc_user_identity_9999`
+SET identity_last_login_at = NOW()
+WHERE identity_id = ?;
+
+-- name: RemoveUserIdentities :exec
+DELETE FROM `pre_go_acc_user_identity_9999`
+WHERE user_id = ?;
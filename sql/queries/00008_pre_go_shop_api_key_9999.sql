@@ -0,0 +1,41 @@
+-- name: AddApiKey :execresult
+INSERT INTO `pre_go_shop_api_key_9999` (shop_id, key_name, key_prefix, key_hash, key_scopes, key_allowed_ips, key_expires_at, key_created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, NOW());
+
+-- name: GetApiKeyByPrefix :one
+SELECT k.key_id, k.shop_id, k.key_name, k.key_prefix, k.key_hash, k.key_scopes, k.key_allowed_ips,
+       k.key_last_used_at, k.key_last_used_ip, k.key_expires_at, k.key_revoked_at, k.key_created_at,
+       i.user_account, i.user_state
+FROM `pre_go_shop_api_key_9999` k
+JOIN `pre_go_acc_user_info_9999` i ON i.user_id = k.shop_id
+WHERE k.key_prefix = ?;
+
+-- name: GetApiKey :one
+SELECT key_id, shop_id, key_name, key_prefix, key_hash, key_scopes, key_allowed_ips, key_last_used_at, key_last_used_ip, key_expires_at, key_revoked_at, key_created_at
+FROM `pre_go_shop_api_key_9999`
+WHERE key_id = ?;
+
+-- name: ListShopApiKeys :many
+SELECT key_id, shop_id, key_name, key_prefix, key_hash, key_scopes, key_allowed_ips, key_last_used_at, key_last_used_ip, key_expires_at, key_revoked_at, key_created_at
+FROM `pre_go_shop_api_key_9999`
+WHERE shop_id = ?
+ORDER BY key_id DESC;
+
+-- name: CountActiveShopApiKeys :one
+SELECT COUNT(*)
+FROM `pre_go_shop_api_key_9999`
+WHERE shop_id = ? AND key_revoked_at IS NULL AND (key_expires_at IS NULL OR key_expires_at > NOW());
+
+-- name: TouchApiKey :exec
+UPDATE `pre_go_shop_api_key_9999`
+SET key_last_used_at = NOW(), key_last_used_ip = ?
+WHERE key_id = ?;
+
+-- name: RevokeApiKey :execrows
+UPDATE `pre_go_shop_api_key_9999`
+SET key_revoked_at = NOW()
+WHERE key_id = ? AND shop_id = ? AND key_revoked_at IS NULL;
+
+-- name: RemoveShopApiKeys :exec
+DELETE FROM `pre_go_shop_api_key_9999`
+WHERE shop_id = ?;
@@ -0,0 +1,30 @@
+-- name: AddLoginHistory :exec
+INSERT INTO `pre_go_acc_user_login_history_9999` (
+    user_id, login_account, login_method, login_outcome, login_ip, login_user_agent, login_device_fingerprint, login_country, login_created_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW());
+
+-- name: ListUserLoginHistory :many
+SELECT login_id, user_id, login_account, login_method, login_outcome, login_ip, login_user_agent, login_device_fingerprint, login_country, login_created_at
+FROM `pre_go_acc_user_login_history_9999`
+WHERE user_id = ?
+ORDER BY login_id DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountUserLogins :one
+SELECT COUNT(*)
+FROM `pre_go_acc_user_login_history_9999`
+WHERE user_id = ? AND login_outcome = 'success';
+
+-- name: CountUserLoginsFromDevice :one
+SELECT COUNT(*)
+FROM `pre_go_acc_user_login_history_9999`
+WHERE user_id = ? AND login_outcome = 'success' AND login_device_fingerprint = ?;
+
+-- name: CountUserLoginsFromCountry :one
+SELECT COUNT(*)
+FROM `pre_go_acc_user_login_history_9999`
+WHERE user_id = ? AND login_outcome = 'success' AND login_country = ?;
+
+-- name: RemoveUserLoginHistory :exec
+DELETE FROM `pre_go_acc_user_login_history_9999`
+WHERE user_id = ?;
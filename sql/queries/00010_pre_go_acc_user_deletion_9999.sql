@@ -0,0 +1,19 @@
+-- name: AddUserDeletion :exec
+INSERT INTO `pre_go_acc_user_deletion_9999` (user_id, deletion_requested_at, deletion_scheduled_at, deletion_requested_ip)
+VALUES (?, NOW(), ?, ?);
+
+-- name: GetUserDeletion :one
+SELECT user_id, deletion_requested_at, deletion_scheduled_at, deletion_requested_ip
+FROM `pre_go_acc_user_deletion_9999`
+WHERE user_id = ?;
+
+-- name: ListDueUserDeletions :many
+SELECT user_id, deletion_requested_at, deletion_scheduled_at, deletion_requested_ip
+FROM `pre_go_acc_user_deletion_9999`
+WHERE deletion_scheduled_at <= ?
+ORDER BY deletion_scheduled_at
+LIMIT ?;
+
+-- name: RemoveUserDeletion :execrows
+DELETE FROM `pre_go_acc_user_deletion_9999`
+WHERE user_id = ?;
@@ -0,0 +1,35 @@
+-- name: AddUserPasskey :execresult
+INSERT INTO `pre_go_acc_user_passkey_9999` (
+    user_id, passkey_credential_id, passkey_public_key, passkey_algorithm, passkey_sign_count,
+    passkey_aaguid, passkey_transports, passkey_name, passkey_backup_eligible, passkey_created_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NOW());
+
+-- name: CountUserPasskeys :one
+SELECT COUNT(*)
+FROM `pre_go_acc_user_passkey_9999`
+WHERE user_id = ?;
+
+-- name: GetPasskeyByCredentialId :one
+SELECT passkey_id, user_id, passkey_credential_id, passkey_public_key, passkey_algorithm, passkey_sign_count, passkey_aaguid, passkey_transports, passkey_name, passkey_backup_eligible, passkey_created_at, passkey_last_used_at
+FROM `pre_go_acc_user_passkey_9999`
+WHERE passkey_credential_id = ?;
+
+-- name: ListUserPasskeys :many
+SELECT passkey_id, user_id, passkey_credential_id, passkey_public_key, passkey_algorithm, passkey_sign_count, passkey_aaguid, passkey_transports, passkey_name, passkey_backup_eligible, passkey_created_at, passkey_last_used_at
+FROM `pre_go_acc_user_passkey_9999`
+WHERE user_id = ?
+ORDER BY passkey_id;
+
+-- name: RemoveUserPasskey :execrows
+DELETE FROM `pre_go_acc_user_passkey_9999`
+WHERE passkey_id = ? AND user_id = ?;
+
+-- name: RemoveUserPasskeys :exec
+DELETE FROM `pre_go_acc_user_passkey_9999`
+WHERE user_id = ?;
+
+-- the counter only moves forward, a concurrent older assertion updates nothing
+-- name: UpdatePasskeySignCount :execrows
+UPDATE `pre_go_acc_user_passkey_9999`
+SET passkey_sign_count = ?, passkey_last_used_at = NOW()
+WHERE passkey_id = ? AND (passkey_sign_count < ? OR passkey_sign_count = 0);
@@ -0,0 +1,57 @@
+-- name: CreateProductOption :exec
+INSERT INTO product_options (product_id, option_name, option_values, position)
+VALUES (?, ?, ?, ?);
+
+-- name: DeleteProductOptions :exec
+DELETE FROM product_options
+WHERE product_id = ?;
+
+-- name: ListProductOptions :many
+SELECT * FROM product_options
+WHERE product_id = ?
+ORDER BY position;
+
+-- name: CreateProductSku :exec
+INSERT INTO product_skus (id, product_id, sku_code, sku_options, sku_price, sku_discounted_price, sku_weight, position)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: UpdateProductSku :exec
+UPDATE product_skus
+SET sku_options = ?, sku_price = ?, sku_discounted_price = ?, sku_weight = ?, position = ?
+WHERE id = ?;
+
+-- the inventory row goes with it (ON DELETE CASCADE)
+-- name: DeleteProductSku :exec
+DELETE FROM product_skus
+WHERE id = ?;
+
+-- SKUs of a product with the stock of their inventory row
+-- name: ListProductSkus :many
+SELECT s.id, s.product_id, s.sku_code, s.sku_options, s.sku_price, s.sku_discounted_price, s.sku_weight, s.position,
+       COALESCE(i.stock, 0) AS stock
+FROM product_skus s
+LEFT JOIN inventory i ON i.sku_id = s.id
+WHERE s.product_id = ?
+ORDER BY s.position;
+
+-- price range of a page of products
+-- name: ListSkuPriceRanges :many
+SELECT product_id, MIN(sku_price) AS min_price, MAX(sku_price) AS max_price
+FROM product_skus
+WHERE product_id IN (sqlc.slice('ids'))
+GROUP BY product_id;
+
+-- name: CreateSkuInventory :exec
+INSERT INTO inventory (product_id, sku_id, shop_id, stock)
+VALUES (?, ?, ?, ?);
+
+-- name: UpdateSkuStock :exec
+UPDATE inventory
+SET stock = ?
+WHERE sku_id = ? AND shop_id = ?;
+
+-- the cheapest SKU and the total stock, shown where a product has one price and quantity
+-- name: UpdateProductSummary :exec
+UPDATE products
+SET product_price = ?, product_discounted_price = ?, product_quantity = ?
+WHERE id = ?;
@@ -0,0 +1,17 @@
+-- the text of every published product, to build the search index. The searchable
+-- attributes are loaded by product type, see producttype.Field.Searchable
+-- name: ListSearchDocuments :many
+SELECT id, product_name, product_type, sub_product_type, product_description
+FROM products
+WHERE is_published = true;
+
+-- no row when the product is not published, it is removed from the index
+-- name: GetSearchDocument :one
+SELECT id, product_name, product_type, sub_product_type, product_description
+FROM products
+WHERE id = ? AND is_published = true;
+
+-- a page of search hits, a product unpublished since it was indexed is left out
+-- name: ListPublishedProductsByIDs :many
+SELECT * FROM products
+WHERE id IN (sqlc.slice('ids')) AND is_published = true;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `pre_go_acc_user_two_factor_recovery_9999` (
+    `recovery_id` INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,                      -- Primary key
+    `user_id` INT UNSIGNED NOT NULL,                                            -- User owning the recovery code
+    `recovery_code_hash` VARCHAR(255) NOT NULL,                                 -- SHA-256 hash of the recovery code (plain code is shown only once)
+    `recovery_used_at` TIMESTAMP NULL DEFAULT NULL,                             -- Time the code was consumed (NULL = still usable)
+    `recovery_created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,                  -- Record creation time
+
+    INDEX `idx_user_id` (`user_id`),
+    UNIQUE KEY `unique_user_code` (`user_id`, `recovery_code_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='pre_go_acc_user_two_factor_recovery_9999';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `pre_go_acc_user_two_factor_recovery_9999`;
+-- +goose StatementEnd
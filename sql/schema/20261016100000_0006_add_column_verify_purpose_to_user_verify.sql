@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE pre_go_acc_user_verify_9999
+ADD COLUMN verify_purpose VARCHAR(32) NOT NULL DEFAULT 'REGISTER' COMMENT 'REGISTER, RESET_PASSWORD ... an OTP is only valid for its purpose',
+DROP INDEX unique_verify_key,
+ADD UNIQUE KEY unique_verify_key_purpose (verify_key, verify_purpose);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM pre_go_acc_user_verify_9999 WHERE verify_purpose <> 'REGISTER';
+ALTER TABLE pre_go_acc_user_verify_9999
+DROP INDEX unique_verify_key_purpose,
+ADD UNIQUE KEY unique_verify_key (verify_key),
+DROP COLUMN verify_purpose;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `pre_go_acc_user_identity_9999` (
+    `identity_id` INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,                      -- Primary key
+    `user_id` INT UNSIGNED NOT NULL,                                            -- Linked account (pre_go_acc_user_base_9999.user_id)
+    `identity_provider` VARCHAR(64) NOT NULL,                                   -- OIDC provider name from the config (google, facebook, ...)
+    `identity_subject` VARCHAR(255) NOT NULL,                                   -- "sub" claim of the ID token, stable per provider
+    `identity_email` VARCHAR(255) NULL,                                         -- Email reported by the provider when linked
+    `identity_created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,                  -- Record creation time
+    `identity_last_login_at` TIMESTAMP NULL DEFAULT NULL,                       -- Last login through this identity
+
+    INDEX `idx_user_id` (`user_id`),
+    UNIQUE KEY `unique_provider_subject` (`identity_provider`, `identity_subject`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='pre_go_acc_user_identity_9999';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `pre_go_acc_user_identity_9999`;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `pre_go_shop_api_key_9999` (
+    `key_id` INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,                           -- Primary key
+    `shop_id` BIGINT UNSIGNED NOT NULL,                                         -- Owner (pre_go_acc_user_info_9999.user_id), a user owns one shop
+    `key_name` VARCHAR(100) NOT NULL,                                           -- Label chosen by the owner (POS stall 1, wholesale partner, ...)
+    `key_prefix` CHAR(12) NOT NULL,                                             -- Public part of the key, used for lookup and shown in lists
+    `key_hash` CHAR(64) NOT NULL,                                               -- SHA-256 of the secret part, the secret itself is never stored
+    `key_scopes` VARCHAR(255) NOT NULL,                                         -- Comma separated scopes (product:read, inventory:write, ...)
+    `key_allowed_ips` VARCHAR(1024) NOT NULL DEFAULT '',                        -- Comma separated IPs / CIDRs, empty allows any address
+    `key_last_used_at` TIMESTAMP NULL DEFAULT NULL,                             -- Last authenticated request
+    `key_last_used_ip` VARCHAR(45) NULL DEFAULT NULL,                           -- Client IP of the last request
+    `key_expires_at` TIMESTAMP NULL DEFAULT NULL,                               -- NULL never expires
+    `key_revoked_at` TIMESTAMP NULL DEFAULT NULL,                               -- Set on revocation, the row is kept for the audit
+    `key_created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,                       -- Record creation time
+
+    UNIQUE KEY `unique_key_prefix` (`key_prefix`),
+    INDEX `idx_shop_id` (`shop_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='pre_go_shop_api_key_9999';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `pre_go_shop_api_key_9999`;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `pre_go_acc_user_login_history_9999` (
+    `login_id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,                      -- Primary key
+    `user_id` INT UNSIGNED NULL DEFAULT NULL,                                   -- Account of the attempt, NULL when the account does not exist
+    `login_account` VARCHAR(255) NOT NULL,                                      -- Account as typed (normalized), kept for failed attempts
+    `login_method` VARCHAR(64) NOT NULL,                                        -- password, two_factor, admin, oidc:<provider>
+    `login_outcome` VARCHAR(16) NOT NULL,                                       -- success, challenge, failed, locked, not_activated
+    `login_ip` VARCHAR(45) NOT NULL DEFAULT '',                                 -- Client IP (X-Forwarded-For from trusted proxies only)
+    `login_user_agent` VARCHAR(512) NOT NULL DEFAULT '',                        -- User agent, truncated
+    `login_device_fingerprint` CHAR(64) NOT NULL DEFAULT '',                    -- SHA-256 of device id or user agent + language
+    `login_country` CHAR(2) NOT NULL DEFAULT '',                                -- ISO country from the CDN header, empty when unknown
+    `login_created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,                     -- Attempt time
+
+    INDEX `idx_user_created` (`user_id`, `login_created_at`),
+    INDEX `idx_user_device` (`user_id`, `login_device_fingerprint`),
+    INDEX `idx_ip_created` (`login_ip`, `login_created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='pre_go_acc_user_login_history_9999';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `pre_go_acc_user_login_history_9999`;
+-- +goose StatementEnd
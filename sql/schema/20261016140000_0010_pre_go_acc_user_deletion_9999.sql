@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `pre_go_acc_user_deletion_9999` (
+    `user_id` BIGINT UNSIGNED PRIMARY KEY,                                      -- Account to delete, one pending request per account
+    `deletion_requested_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,       -- Request time
+    `deletion_scheduled_at` TIMESTAMP NOT NULL,                                 -- End of the grace period, logging in before it cancels the request
+    `deletion_requested_ip` VARCHAR(45) NOT NULL DEFAULT '',                    -- Client IP of the request
+
+    INDEX `idx_scheduled` (`deletion_scheduled_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='pre_go_acc_user_deletion_9999';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `pre_go_acc_user_deletion_9999`;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `pre_go_acc_user_passkey_9999` (
+    `passkey_id` INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,                       -- Primary key
+    `user_id` INT UNSIGNED NOT NULL,                                            -- Owner of the passkey
+    `passkey_credential_id` VARBINARY(1023) NOT NULL,                           -- WebAuthn credential id
+    `passkey_public_key` BLOB NOT NULL,                                         -- COSE_Key of the credential
+    `passkey_algorithm` INT NOT NULL,                                           -- COSE algorithm: -7 ES256, -8 EdDSA, -257 RS256
+    `passkey_sign_count` INT UNSIGNED NOT NULL DEFAULT 0,                       -- Last signature counter, must increase unless always 0
+    `passkey_aaguid` BINARY(16) NULL DEFAULT NULL,                              -- Authenticator model, zero for most platform passkeys
+    `passkey_transports` VARCHAR(255) NOT NULL DEFAULT '',                      -- Comma separated: internal, hybrid, usb, nfc, ble
+    `passkey_name` VARCHAR(64) NOT NULL,                                        -- Label chosen by the user
+    `passkey_backup_eligible` TINYINT(1) NOT NULL DEFAULT 0,                    -- Synced passkey (e.g. iCloud Keychain, Google Password Manager)
+    `passkey_created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,                   -- Registration time
+    `passkey_last_used_at` TIMESTAMP NULL DEFAULT NULL,                         -- Last successful authentication
+
+    UNIQUE KEY `unique_credential_id` (`passkey_credential_id`(255)),
+    INDEX `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='pre_go_acc_user_passkey_9999';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `pre_go_acc_user_passkey_9999`;
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Variant dimensions of a product, e.g. size: 250g, 500g, 1kg
+CREATE TABLE IF NOT EXISTS product_options (
+    id INT AUTO_INCREMENT PRIMARY KEY,             -- Option ID
+    product_id VARCHAR(36) NOT NULL,               -- Product ID (UUID)
+    option_name VARCHAR(50) NOT NULL,              -- e.g. size, packaging
+    option_values JSON NOT NULL,                   -- Array of values in display order
+    position INT NOT NULL DEFAULT 0,               -- Display order of the option
+    UNIQUE KEY unique_product_option (product_id, option_name),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Product options table';
+
+-- Sellable variants, one per combination of option values
+CREATE TABLE IF NOT EXISTS product_skus (
+    id VARCHAR(36) PRIMARY KEY,                    -- SKU ID (UUID)
+    product_id VARCHAR(36) NOT NULL,               -- Product ID (UUID)
+    sku_code VARCHAR(64) NOT NULL,                 -- Seller code, unique within the product
+    sku_options JSON NOT NULL,                     -- Value per option, e.g. {"size": "500g"}
+    sku_price DECIMAL(10, 2) NOT NULL,             -- Price of the variant
+    sku_discounted_price DECIMAL(10, 2) NULL,      -- Discounted price
+    sku_weight DECIMAL(10, 2) NULL,                -- Shipping weight in kg
+    position INT NOT NULL DEFAULT 0,               -- Display order
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Creation time
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, -- Update time
+    UNIQUE KEY unique_product_sku_code (product_id, sku_code),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Product SKUs table';
+
+-- Stock is kept per SKU, products.product_quantity is the sum
+ALTER TABLE inventory
+    ADD COLUMN sku_id VARCHAR(36) NULL AFTER product_id,
+    ADD UNIQUE KEY unique_inventory_sku (sku_id),
+    ADD CONSTRAINT fk_inventory_sku FOREIGN KEY (sku_id) REFERENCES product_skus(id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- every existing product gets a default SKU that takes over its inventory row
+INSERT INTO product_skus (id, product_id, sku_code, sku_options, sku_price, sku_discounted_price)
+SELECT UUID(), id, 'DEFAULT', JSON_OBJECT(), product_price, product_discounted_price FROM products;
+
+-- a product may have several inventory rows but a SKU has one: the first row takes the SKU
+-- and the stock of every row, the other rows are merged into it (the down migration keeps the merge)
+UPDATE inventory i
+JOIN (SELECT product_id, MIN(id) AS first_id, SUM(stock) AS stock FROM inventory GROUP BY product_id) f ON f.first_id = i.id
+JOIN product_skus s ON s.product_id = i.product_id
+SET i.sku_id = s.id, i.stock = f.stock
+WHERE i.sku_id IS NULL;
+
+DELETE i FROM inventory i
+JOIN (SELECT product_id, MIN(id) AS first_id FROM inventory GROUP BY product_id) f ON f.product_id = i.product_id
+WHERE i.id <> f.first_id AND i.sku_id IS NULL;
+
+-- products created without an inventory row get one with their quantity
+INSERT INTO inventory (product_id, sku_id, shop_id, stock)
+SELECT s.product_id, s.id, p.product_shop, p.product_quantity
+FROM product_skus s
+JOIN products p ON p.id = s.product_id
+WHERE NOT EXISTS (SELECT 1 FROM inventory i WHERE i.sku_id = s.id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE inventory DROP FOREIGN KEY fk_inventory_sku;
+ALTER TABLE inventory DROP INDEX unique_inventory_sku, DROP COLUMN sku_id;
+DROP TABLE IF EXISTS `product_skus`;
+DROP TABLE IF EXISTS `product_options`;
+-- +goose StatementEnd
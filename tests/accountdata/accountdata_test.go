@@ -0,0 +1,149 @@
+package accountdata
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/accountdata"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func setup(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	global.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return mr
+}
+
+func readZip(t *testing.T, archive []byte) map[string][]byte {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	assert.Nil(t, err)
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		assert.Nil(t, err)
+		body, err := io.ReadAll(rc)
+		assert.Nil(t, err)
+		rc.Close()
+		files[f.Name] = body
+	}
+	return files
+}
+
+func TestBuildWritesSectionsAndManifest(t *testing.T) {
+	at := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	archive, err := accountdata.Build(7, []accountdata.Section{
+		{Name: "profile", Data: map[string]string{"user_account": "farmer@example.com"}},
+		{Name: "login_history", Data: []string{}},
+	}, at)
+	assert.Nil(t, err)
+
+	files := readZip(t, archive)
+	assert.Len(t, files, 3)
+	assert.Contains(t, string(files["profile.json"]), `"user_account": "farmer@example.com"`)
+	assert.Equal(t, "[]\n", string(files["login_history.json"]))
+
+	var manifest struct {
+		UserId      uint64    `json:"user_id"`
+		GeneratedAt time.Time `json:"generated_at"`
+		Files       []string  `json:"files"`
+	}
+	assert.Nil(t, json.Unmarshal(files["manifest.json"], &manifest))
+	assert.Equal(t, uint64(7), manifest.UserId)
+	assert.True(t, manifest.GeneratedAt.Equal(at))
+	assert.Equal(t, []string{"profile.json", "login_history.json"}, manifest.Files)
+}
+
+func TestExportJobLifecycle(t *testing.T) {
+	mr := setup(t)
+	ctx := context.Background()
+
+	_, found, err := accountdata.Get(ctx, 1)
+	assert.Nil(t, err)
+	assert.False(t, found)
+	_, err = accountdata.Archive(ctx, 1)
+	assert.ErrorIs(t, err, accountdata.ErrNotReady)
+
+	job, err := accountdata.Start(ctx, 1, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, accountdata.StatusPending, job.Status)
+	_, err = accountdata.Start(ctx, 1, time.Hour)
+	assert.ErrorIs(t, err, accountdata.ErrTooSoon)
+	// other users are not affected
+	_, err = accountdata.Start(ctx, 2, time.Hour)
+	assert.Nil(t, err)
+
+	job, err = accountdata.Finish(ctx, 1, job, []byte("zip"), 24*time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, accountdata.StatusReady, job.Status)
+	assert.Equal(t, 3, job.Size)
+
+	got, found, err := accountdata.Get(ctx, 1)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, accountdata.StatusReady, got.Status)
+	assert.NotNil(t, got.ExpiresAt)
+	archive, err := accountdata.Archive(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("zip"), archive)
+
+	// the download expires with the job
+	mr.FastForward(25 * time.Hour)
+	_, err = accountdata.Archive(ctx, 1)
+	assert.ErrorIs(t, err, accountdata.ErrNotReady)
+	_, found, _ = accountdata.Get(ctx, 1)
+	assert.False(t, found)
+}
+
+func TestFailedExportCanBeRetried(t *testing.T) {
+	setup(t)
+	ctx := context.Background()
+
+	job, err := accountdata.Start(ctx, 1, time.Hour)
+	assert.Nil(t, err)
+	assert.Nil(t, accountdata.Fail(ctx, 1, job))
+	got, found, err := accountdata.Get(ctx, 1)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, accountdata.StatusFailed, got.Status)
+
+	_, err = accountdata.Start(ctx, 1, time.Hour)
+	assert.Nil(t, err)
+}
+
+func TestRemoveDropsExport(t *testing.T) {
+	setup(t)
+	ctx := context.Background()
+
+	job, err := accountdata.Start(ctx, 1, time.Hour)
+	assert.Nil(t, err)
+	_, err = accountdata.Finish(ctx, 1, job, []byte("zip"), time.Hour)
+	assert.Nil(t, err)
+	assert.Nil(t, accountdata.Remove(ctx, 1))
+
+	_, err = accountdata.Archive(ctx, 1)
+	assert.ErrorIs(t, err, accountdata.ErrNotReady)
+	_, err = accountdata.Start(ctx, 1, time.Hour)
+	assert.Nil(t, err)
+}
+
+func TestMails(t *testing.T) {
+	at := time.Date(2026, 10, 30, 8, 0, 0, 0, time.UTC)
+
+	ready := accountdata.ExportReadyMail("farmer@example.com", at)
+	assert.Equal(t, []string{"farmer@example.com"}, ready.To)
+	assert.Contains(t, ready.Body, "2026-10-30 08:00 UTC")
+	assert.Empty(t, ready.Attachments)
+
+	deletion := accountdata.DeletionScheduledMail("farmer@example.com", at)
+	assert.Contains(t, deletion.Body, "2026-10-30 08:00 UTC")
+	assert.Contains(t, deletion.Body, "sign in again")
+}
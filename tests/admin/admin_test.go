@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/middlewares"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/service/impl"
+	"go_ecommerce/internal/utils/auth"
+	usercontext "go_ecommerce/internal/utils/context"
+	"go_ecommerce/internal/utils/rbac"
+	"go_ecommerce/internal/utils/session"
+	"go_ecommerce/pkg/response"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRoles struct{ service.IUserRole }
+
+func (fakeRoles) GetRoles(ctx context.Context, userId uint64) ([]string, error) {
+	return []string{rbac.RoleCustomer, rbac.RoleAdmin}, nil
+}
+
+func setup(t *testing.T) {
+	mr := miniredis.RunT(t)
+	global.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	ks := auth.NewKeySet("test", time.Hour)
+	assert.Nil(t, ks.Add(&auth.SigningKey{Kid: "test", Alg: auth.AlgEdDSA, Private: private}))
+	auth.SetKeySet(ks)
+	gin.SetMode(gin.TestMode)
+	service.InitUserRole(fakeRoles{})
+}
+
+func login(t *testing.T, subToken string, info session.Info) string {
+	ctx := context.Background()
+	assert.Nil(t, global.Rdb.Set(ctx, subToken, `{"UserId":1,"UserAccount":"admin@example.com"}`, time.Hour).Err())
+	assert.Nil(t, session.Register(ctx, 1, subToken, info, time.Hour))
+	token, err := auth.CreateToken(subToken, "30m")
+	assert.Nil(t, err)
+	return token
+}
+
+func findUsers(t *testing.T, token string) int {
+	r := gin.New()
+	r.GET("/admin/user", middlewares.AuthenMiddleware(), middlewares.RequireAdminSession(), middlewares.RequirePermission(rbac.PermUserRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/admin/user", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAdminSessionRequired(t *testing.T) {
+	setup(t)
+	// an admin using its normal user session, even with 2FA, is not an admin session
+	userSession := login(t, "sub-user", session.Info{AuthLevel: usercontext.AuthLevelTwoFactor})
+	assert.Equal(t, http.StatusForbidden, findUsers(t, userSession))
+
+	adminSession := login(t, "sub-admin", session.Info{AuthLevel: usercontext.AuthLevelTwoFactor, Admin: true})
+	assert.Equal(t, http.StatusOK, findUsers(t, adminSession))
+}
+
+func TestAdminSessionWithoutSecondFactorRejected(t *testing.T) {
+	setup(t)
+	token := login(t, "sub-admin-pw", session.Info{AuthLevel: usercontext.AuthLevelPassword, Admin: true})
+	assert.Equal(t, http.StatusForbidden, findUsers(t, token))
+}
+
+func TestModeratorHasAdminAccess(t *testing.T) {
+	assert.True(t, rbac.Can([]string{rbac.RoleModerator}, rbac.PermAdminAccess))
+	assert.False(t, rbac.Can([]string{rbac.RoleCustomer, rbac.RoleShopOwner}, rbac.PermAdminAccess))
+}
+
+// userRoles serves the roles of each user, an admin (1), a moderator (2) and another moderator (3)
+type userRoles struct{ service.IUserRole }
+
+func (userRoles) GetRoles(ctx context.Context, userId uint64) ([]string, error) {
+	switch userId {
+	case 1:
+		return []string{rbac.RoleCustomer, rbac.RoleAdmin}, nil
+	case 2, 3:
+		return []string{rbac.RoleCustomer, rbac.RoleModerator}, nil
+	}
+	return []string{rbac.RoleCustomer}, nil
+}
+
+func TestModeratorCantManageAdmin(t *testing.T) {
+	ctx := context.Background()
+	// refused before the database is used
+	admin := impl.NewUserAdminImpl(nil, userRoles{})
+	for _, target := range []uint64{1, 3} {
+		in := &model.AdminUserInput{UserId: target, AdminId: 2}
+		code, _, err := admin.LockUser(ctx, in)
+		assert.Error(t, err)
+		assert.Equal(t, response.ErrCodePermissionDenied, code)
+		code, _, err = admin.UnlockUser(ctx, in)
+		assert.Error(t, err)
+		assert.Equal(t, response.ErrCodePermissionDenied, code)
+		code, _, err = admin.ActivateUser(ctx, in)
+		assert.Error(t, err)
+		assert.Equal(t, response.ErrCodePermissionDenied, code)
+		code, err = admin.RemoveUser(ctx, in)
+		assert.Error(t, err)
+		assert.Equal(t, response.ErrCodePermissionDenied, code)
+	}
+}
@@ -0,0 +1,167 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_ecommerce/internal/middlewares"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/apikey"
+	usercontext "go_ecommerce/internal/utils/context"
+	"go_ecommerce/internal/utils/rbac"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeKeys accepts one key, as the service does after the database lookup
+type fakeKeys struct {
+	service.IShopApiKey
+	key       string
+	allowedIp string
+	roles     []string
+	scopes    []string
+}
+
+func (f *fakeKeys) Authenticate(ctx context.Context, key string, clientIp string) (*usercontext.Principal, error) {
+	prefix, secret, ok := apikey.Parse(key)
+	wantPrefix, wantSecret, _ := apikey.Parse(f.key)
+	if !ok || prefix != wantPrefix || !apikey.MatchSecret(apikey.HashSecret(wantSecret), secret) {
+		return nil, apikey.ErrInvalidKey
+	}
+	if !apikey.AllowsIP(f.allowedIp, clientIp) {
+		return nil, apikey.ErrIPNotAllowed
+	}
+	return &usercontext.Principal{UserId: 7, ShopId: "7", Roles: f.roles, ApiKeyId: 1, Scopes: f.scopes}, nil
+}
+
+func setup(t *testing.T, scopes ...string) *fakeKeys {
+	gin.SetMode(gin.TestMode)
+	_, _, key, err := apikey.Generate()
+	assert.Nil(t, err)
+	fake := &fakeKeys{
+		key:    key,
+		roles:  []string{rbac.RoleCustomer, rbac.RoleShopOwner},
+		scopes: scopes,
+	}
+	service.InitShopApiKey(fake)
+	return fake
+}
+
+func call(t *testing.T, header string, value string, permission string) int {
+	r := gin.New()
+	r.PUT("/product/stock/:id", middlewares.AuthenOrApiKeyMiddleware(), middlewares.RequirePermission(permission), func(c *gin.Context) {
+		principal, err := usercontext.GetPrincipal(c)
+		assert.Nil(t, err)
+		assert.Equal(t, "7", principal.ShopId)
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodPut, "/product/stock/p1", nil)
+	req.RemoteAddr = "203.0.113.10:5000"
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestGenerateAndParse(t *testing.T) {
+	prefix, secret, key, err := apikey.Generate()
+	assert.Nil(t, err)
+	assert.Len(t, prefix, apikey.PrefixLength)
+	assert.True(t, strings.HasPrefix(key, prefix+"."))
+
+	gotPrefix, gotSecret, ok := apikey.Parse(key)
+	assert.True(t, ok)
+	assert.Equal(t, prefix, gotPrefix)
+	assert.Equal(t, secret, gotSecret)
+	assert.True(t, apikey.MatchSecret(apikey.HashSecret(secret), gotSecret))
+	assert.False(t, apikey.MatchSecret(apikey.HashSecret(secret), gotSecret+"x"))
+
+	for _, bad := range []string{"", "gnk_", prefix, prefix + ".", "abc_12345678.secret", strings.Replace(key, ".", "-", 1)} {
+		_, _, ok := apikey.Parse(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestAllowedIPs(t *testing.T) {
+	allowed, err := apikey.NormalizeAllowedIPs([]string{" 203.0.113.10 ", "10.1.0.0/16", ""})
+	assert.Nil(t, err)
+	assert.Equal(t, "203.0.113.10,10.1.0.0/16", allowed)
+	assert.True(t, apikey.AllowsIP(allowed, "203.0.113.10"))
+	assert.True(t, apikey.AllowsIP(allowed, "10.1.200.3"))
+	assert.False(t, apikey.AllowsIP(allowed, "10.2.0.1"))
+	assert.False(t, apikey.AllowsIP(allowed, "not-an-ip"))
+	assert.True(t, apikey.AllowsIP("", "198.51.100.1"))
+
+	_, err = apikey.NormalizeAllowedIPs([]string{"10.0.0.300"})
+	assert.NotNil(t, err)
+	_, err = apikey.NormalizeAllowedIPs([]string{"10.0.0.0/40"})
+	assert.NotNil(t, err)
+}
+
+func TestScopes(t *testing.T) {
+	assert.True(t, apikey.IsScope(rbac.PermInventoryWrite))
+	assert.True(t, apikey.IsScope(rbac.PermProductRead))
+	// keys never administer anything
+	assert.False(t, apikey.IsScope(rbac.PermUserLock))
+	assert.False(t, apikey.IsScope(rbac.PermAdminAccess))
+}
+
+func TestPrincipalCanIsLimitedByScopes(t *testing.T) {
+	owner := []string{rbac.RoleCustomer, rbac.RoleShopOwner}
+	key := &usercontext.Principal{Roles: owner, ApiKeyId: 1, Scopes: []string{rbac.PermInventoryWrite}}
+	assert.True(t, key.Can(rbac.PermInventoryWrite))
+	assert.False(t, key.Can(rbac.PermProductPublish)) // granted to the owner, not to the key
+
+	// the owner lost the shop role: the key loses the scope too
+	key.Roles = []string{rbac.RoleCustomer}
+	assert.False(t, key.Can(rbac.PermInventoryWrite))
+
+	session := &usercontext.Principal{Roles: owner}
+	assert.True(t, session.Can(rbac.PermProductPublish))
+}
+
+func TestMiddlewareAcceptsApiKey(t *testing.T) {
+	fake := setup(t, rbac.PermInventoryWrite)
+	assert.Equal(t, http.StatusOK, call(t, "X-API-Key", fake.key, rbac.PermInventoryWrite))
+	assert.Equal(t, http.StatusOK, call(t, "Authorization", "ApiKey "+fake.key, rbac.PermInventoryWrite))
+}
+
+func TestMiddlewareRejectsOutOfScope(t *testing.T) {
+	fake := setup(t, rbac.PermInventoryWrite)
+	assert.Equal(t, http.StatusForbidden, call(t, "X-API-Key", fake.key, rbac.PermProductCreate))
+}
+
+func TestMiddlewareRejectsInvalidKey(t *testing.T) {
+	fake := setup(t, rbac.PermInventoryWrite)
+	prefix, _, _ := apikey.Parse(fake.key)
+	assert.Equal(t, http.StatusUnauthorized, call(t, "X-API-Key", prefix+".wrong-secret", rbac.PermInventoryWrite))
+	assert.Equal(t, http.StatusUnauthorized, call(t, "X-API-Key", "garbage", rbac.PermInventoryWrite))
+	assert.Equal(t, http.StatusUnauthorized, call(t, "", "", rbac.PermInventoryWrite))
+}
+
+func TestMiddlewareChecksAllowedIPs(t *testing.T) {
+	fake := setup(t, rbac.PermInventoryWrite)
+	fake.allowedIp = "198.51.100.0/24"
+	assert.Equal(t, http.StatusForbidden, call(t, "X-API-Key", fake.key, rbac.PermInventoryWrite))
+	fake.allowedIp = "203.0.113.0/24"
+	assert.Equal(t, http.StatusOK, call(t, "X-API-Key", fake.key, rbac.PermInventoryWrite))
+}
+
+func TestSessionOnlyRoutesIgnoreApiKeys(t *testing.T) {
+	fake := setup(t, rbac.PermInventoryWrite)
+	r := gin.New()
+	r.PUT("/user/password", middlewares.AuthenMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodPut, "/user/password", nil)
+	req.Header.Set("X-API-Key", fake.key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
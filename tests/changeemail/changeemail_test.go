@@ -0,0 +1,185 @@
+package changeemail
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/consts"
+	"go_ecommerce/internal/database"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/service/impl"
+	"go_ecommerce/internal/utils"
+	"go_ecommerce/internal/utils/crypto"
+	"go_ecommerce/pkg/logger"
+	"go_ecommerce/pkg/response"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	userId   = 7
+	newEmail = "new@example.com"
+	otp      = "123456"
+)
+
+// accounts is a database/sql driver that serves one user and logs the statements with their arguments
+type accounts struct {
+	mu      sync.Mutex
+	log     []string
+	account string
+}
+
+var db = &accounts{}
+
+func init() {
+	sql.Register("changeemail", db)
+}
+
+func (a *accounts) Open(string) (driver.Conn, error) { return &conn{a: a}, nil }
+
+func (a *accounts) add(query string, args []driver.NamedValue) {
+	values := make([]string, 0, len(args))
+	for _, arg := range args {
+		values = append(values, fmt.Sprint(arg.Value))
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.log = append(a.log, strings.Join(strings.Fields(query), " ")+" "+strings.Join(values, ","))
+}
+
+// statements are the logged statements that contain part
+func (a *accounts) statements(part string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var out []string
+	for _, entry := range a.log {
+		if strings.Contains(entry, part) {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+type conn struct{ a *accounts }
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *conn) Close() error              { return nil }
+func (c *conn) Begin() (driver.Tx, error) { return tx{}, nil }
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.a.add(query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.a.add(query, args)
+	switch {
+	case strings.Contains(query, "pre_go_acc_user_verify_9999"):
+		return &rows{columns: []string{"verify_otp", "verify_key_hash", "verify_key", "verify_id"}, values: [][]driver.Value{
+			{otp, crypto.GetHash(newEmail), newEmail, int64(1)},
+		}}, nil
+	case strings.Contains(query, "pre_go_acc_user_info_9999"):
+		return &rows{columns: []string{"user_id", "user_account", "user_nickname", "user_avatar", "user_state",
+			"user_mobile", "user_gender", "user_birthday", "user_email", "user_is_authentication", "created_at", "updated_at"},
+			values: [][]driver.Value{
+				{int64(userId), c.a.account, nil, nil, int64(1), nil, nil, nil, nil, int64(0), nil, nil},
+			}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query %s", query)
+}
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
+
+// confirm changes the email of the account to newEmail
+func confirm(t *testing.T, account string) model.ChangeEmailOutput {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	global.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	global.Logger = &logger.LoggerZap{Logger: zap.NewNop()}
+	sqldb, err := sql.Open("changeemail", "")
+	require.NoError(t, err)
+	global.Mdbc = sqldb
+	db.mu.Lock()
+	db.log, db.account = nil, account
+	db.mu.Unlock()
+
+	ctx := context.Background()
+	keyPending := utils.GetUserPurposeKey(crypto.GetHash(strconv.Itoa(userId)), consts.VERIFY_PURPOSE_CHANGE_EMAIL)
+	require.NoError(t, global.Rdb.Set(ctx, keyPending, `{"new_email":"`+newEmail+`","otp":"`+otp+`"}`, time.Minute).Err())
+
+	code, out, err := impl.NewUserLoginImpl(database.New(sqldb)).ConfirmChangeEmail(ctx, &model.ConfirmChangeEmailInput{
+		UserId:     userId,
+		VerifyCode: otp,
+		ClientIp:   "127.0.0.1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, response.CodeSuccess, code)
+	return out
+}
+
+func TestEmailAccountChangesItsLogin(t *testing.T) {
+	out := confirm(t, "old@example.com")
+	assert.Equal(t, newEmail, out.UserAccount)
+	logins := db.statements("pre_go_acc_user_base_9999 SET user_account")
+	require.Len(t, logins, 1)
+	assert.True(t, strings.HasSuffix(logins[0], " "+newEmail+",7"), logins[0])
+	updates := db.statements("SET user_account = ?, user_email = ?")
+	require.Len(t, updates, 1)
+	assert.True(t, strings.HasSuffix(updates[0], " "+newEmail+","+newEmail+",7"), updates[0])
+	// the old address can register again
+	assert.Len(t, db.statements("DELETE FROM"), 1)
+}
+
+func TestPhoneAccountKeepsItsLogin(t *testing.T) {
+	out := confirm(t, "+84901234567")
+	assert.Equal(t, "+84901234567", out.UserAccount)
+	assert.Empty(t, db.statements("pre_go_acc_user_base_9999 SET user_account"))
+	updates := db.statements("SET user_account = ?, user_email = ?")
+	require.Len(t, updates, 1)
+	assert.True(t, strings.HasSuffix(updates[0], " +84901234567,"+newEmail+",7"), updates[0])
+	// the REGISTER record of the phone number still stands
+	assert.Empty(t, db.statements("DELETE FROM"))
+}
+
+func TestProviderAccountKeepsItsLogin(t *testing.T) {
+	out := confirm(t, "keycloak:user@example.com")
+	assert.Equal(t, "keycloak:user@example.com", out.UserAccount)
+	assert.Empty(t, db.statements("pre_go_acc_user_base_9999 SET user_account"))
+	assert.Empty(t, db.statements("DELETE FROM"))
+}
@@ -0,0 +1,142 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/auth"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRSAKey(t *testing.T, kid string) *auth.SigningKey {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	return &auth.SigningKey{Kid: kid, Alg: auth.AlgRS256, Private: private}
+}
+
+func newEdKey(t *testing.T, kid string) *auth.SigningKey {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	return &auth.SigningKey{Kid: kid, Alg: auth.AlgEdDSA, Private: private}
+}
+
+func kidOf(t *testing.T, token string) string {
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, &jwt.StandardClaims{})
+	assert.Nil(t, err)
+	return parsed.Header["kid"].(string)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	for _, key := range []*auth.SigningKey{newRSAKey(t, "rsa-1"), newEdKey(t, "ed-1")} {
+		ks := auth.NewKeySet(key.Kid, time.Hour)
+		assert.Nil(t, ks.Add(key))
+		auth.SetKeySet(ks)
+
+		token, err := auth.CreateToken("sub-token", "30m")
+		assert.Nil(t, err)
+		assert.Equal(t, key.Kid, kidOf(t, token))
+		claims, err := auth.VerifyTokenSubject(token)
+		assert.Nil(t, err)
+		assert.Equal(t, "sub-token", claims.Subject)
+	}
+}
+
+func TestRotationKeepsOldTokensValid(t *testing.T) {
+	oldKey, newKey := newRSAKey(t, "2026-04"), newEdKey(t, "2026-10")
+	ks := auth.NewKeySet("2026-04", time.Hour)
+	assert.Nil(t, ks.Add(oldKey))
+	auth.SetKeySet(ks)
+	oldToken, err := auth.CreateToken("sub-old", "30m")
+	assert.Nil(t, err)
+
+	// rotate: the old key is retired, its tokens still verify
+	rotated := auth.NewKeySet("2026-10", time.Hour)
+	retired := *oldKey
+	retired.Private = nil
+	retired.RetiredAt = time.Now().Add(time.Second)
+	assert.Nil(t, rotated.Add(&retired))
+	assert.Nil(t, rotated.Add(newKey))
+	auth.SetKeySet(rotated)
+
+	_, err = auth.VerifyTokenSubject(oldToken)
+	assert.Nil(t, err)
+	newToken, err := auth.CreateToken("sub-new", "30m")
+	assert.Nil(t, err)
+	assert.Equal(t, "2026-10", kidOf(t, newToken))
+
+	// both keys are published, active first
+	jwks := auth.JWKS()
+	assert.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "2026-10", jwks.Keys[0].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+}
+
+func TestRetiredKeyRejectsNewTokens(t *testing.T) {
+	key := newRSAKey(t, "leaked")
+	ks := auth.NewKeySet("other", time.Hour)
+	retired := *key
+	retired.RetiredAt = time.Now().Add(-time.Minute)
+	assert.Nil(t, ks.Add(&retired))
+	assert.Nil(t, ks.Add(newEdKey(t, "other")))
+	auth.SetKeySet(ks)
+
+	// signed with the retired private key after its retirement
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, &jwt.StandardClaims{Subject: "x", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()})
+	token.Header["kid"] = "leaked"
+	signed, err := token.SignedString(key.Private)
+	assert.Nil(t, err)
+	_, err = auth.VerifyTokenSubject(signed)
+	assert.NotNil(t, err)
+
+	// past retired_at + max token lifetime the key is gone from the JWKS
+	expired := auth.NewKeySet("other", time.Minute)
+	gone := *key
+	gone.RetiredAt = time.Now().Add(-2 * time.Minute)
+	assert.Nil(t, expired.Add(&gone))
+	assert.Nil(t, expired.Add(newEdKey(t, "other")))
+	assert.Len(t, expired.JWKS(time.Now()).Keys, 1)
+}
+
+func TestVerifyWithPublishedKeyOnly(t *testing.T) {
+	key := newRSAKey(t, "rsa-1")
+	ks := auth.NewKeySet("rsa-1", time.Hour)
+	assert.Nil(t, ks.Add(key))
+	auth.SetKeySet(ks)
+	token, err := auth.CreateToken("sub-token", "30m")
+	assert.Nil(t, err)
+
+	// what farm-dashboard does: fetch the JWKS and verify, no secret involved
+	public, err := auth.JWKS().Keys[0].PublicKey()
+	assert.Nil(t, err)
+	parsed, err := jwt.ParseWithClaims(token, &jwt.StandardClaims{}, func(*jwt.Token) (interface{}, error) {
+		return public, nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, parsed.Valid)
+}
+
+func TestHS256RejectedUnlessLegacyEnabled(t *testing.T) {
+	ks := auth.NewKeySet("ed-1", time.Hour)
+	assert.Nil(t, ks.Add(newEdKey(t, "ed-1")))
+	auth.SetKeySet(ks)
+	global.Config.JWT.API_SECRET_KEY = "secret"
+	defer func() { global.Config.JWT.API_SECRET_KEY, global.Config.JWT.ACCEPT_LEGACY_HS256 = "", false }()
+
+	now := time.Now()
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.StandardClaims{Subject: "x", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}).SignedString([]byte("secret"))
+	assert.Nil(t, err)
+	_, err = auth.VerifyTokenSubject(legacy)
+	assert.NotNil(t, err)
+
+	global.Config.JWT.ACCEPT_LEGACY_HS256 = true
+	_, err = auth.VerifyTokenSubject(legacy)
+	assert.Nil(t, err)
+}
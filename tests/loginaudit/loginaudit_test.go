@@ -0,0 +1,103 @@
+package loginaudit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/utils/loginaudit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const chromeWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/126.0 Safari/537.36"
+
+// clientOf runs FillClient behind a proxy in 10.0.0.0/8
+func clientOf(t *testing.T, remoteAddr string, headers map[string]string) model.LoginClient {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	assert.Nil(t, r.SetTrustedProxies([]string{"10.0.0.0/8"}))
+	var client model.LoginClient
+	r.POST("/user/login", func(c *gin.Context) {
+		loginaudit.FillClient(c, &client)
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/user/login", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return client
+}
+
+func TestFingerprint(t *testing.T) {
+	a := loginaudit.Fingerprint(chromeWindows, "vi-VN,vi;q=0.9,en;q=0.8", "")
+	assert.Len(t, a, 64)
+	// only the primary language counts, q-values change between browser versions
+	assert.Equal(t, a, loginaudit.Fingerprint(chromeWindows, "vi-VN,en;q=0.5", ""))
+	assert.NotEqual(t, a, loginaudit.Fingerprint(chromeWindows, "en-US", ""))
+	assert.NotEqual(t, a, loginaudit.Fingerprint(strings.Replace(chromeWindows, "Windows NT 10.0", "Macintosh", 1), "vi-VN", ""))
+
+	// the app's device id wins over the user agent
+	app := loginaudit.Fingerprint("okhttp/4.12", "", "device-123")
+	assert.Equal(t, app, loginaudit.Fingerprint("okhttp/4.13", "en", "device-123"))
+	assert.NotEqual(t, app, loginaudit.Fingerprint("okhttp/4.12", "", "device-456"))
+}
+
+func TestCountry(t *testing.T) {
+	assert.Equal(t, "VN", loginaudit.Country("vn"))
+	assert.Equal(t, "", loginaudit.Country("XX"))
+	assert.Equal(t, "", loginaudit.Country("T1"))
+	assert.Equal(t, "", loginaudit.Country("VNM"))
+	assert.Equal(t, "", loginaudit.Country("1A"))
+	assert.Equal(t, "", loginaudit.Country(""))
+}
+
+func TestFillClientUsesTrustedProxyOnly(t *testing.T) {
+	global.Config.Server.CountryHeader = ""
+	behindProxy := clientOf(t, "10.0.0.5:4000", map[string]string{
+		"X-Forwarded-For": "203.0.113.7",
+		"User-Agent":      chromeWindows,
+	})
+	assert.Equal(t, "203.0.113.7", behindProxy.ClientIp)
+	assert.Equal(t, chromeWindows, behindProxy.UserAgent)
+	assert.NotEmpty(t, behindProxy.Fingerprint)
+	assert.Equal(t, "", behindProxy.Country)
+
+	// a client can't choose its address by sending the header itself
+	direct := clientOf(t, "198.51.100.9:4000", map[string]string{"X-Forwarded-For": "203.0.113.7"})
+	assert.Equal(t, "198.51.100.9", direct.ClientIp)
+}
+
+func TestFillClientReadsCountryHeader(t *testing.T) {
+	global.Config.Server.CountryHeader = "CF-IPCountry"
+	defer func() { global.Config.Server.CountryHeader = "" }()
+	client := clientOf(t, "10.0.0.5:4000", map[string]string{"CF-IPCountry": "sg"})
+	assert.Equal(t, "SG", client.Country)
+}
+
+func TestTruncateUserAgent(t *testing.T) {
+	long := strings.Repeat("a", 600)
+	assert.Len(t, loginaudit.TruncateUserAgent(long), 512)
+	assert.Equal(t, chromeWindows, loginaudit.TruncateUserAgent(chromeWindows))
+}
+
+func TestNewDeviceAlert(t *testing.T) {
+	mail := loginaudit.NewDeviceAlert("farmer@example.com", model.LoginClient{
+		ClientIp:  "203.0.113.7",
+		UserAgent: chromeWindows,
+		Country:   "SG",
+	}, time.Date(2026, 10, 16, 8, 30, 0, 0, time.UTC))
+	assert.Equal(t, []string{"farmer@example.com"}, mail.To)
+	assert.Contains(t, mail.Body, "203.0.113.7")
+	assert.Contains(t, mail.Body, "Windows")
+	assert.Contains(t, mail.Body, "SG")
+	assert.Contains(t, mail.Body, "2026-10-16 08:30 UTC")
+	assert.Equal(t, loginaudit.MethodOIDC("google"), "oidc:google")
+}
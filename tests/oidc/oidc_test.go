@@ -0,0 +1,281 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/auth"
+	"go_ecommerce/internal/utils/oidc"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+const clientID = "gnfarm-web"
+
+// fakeProvider is a minimal OIDC provider: discovery, JWKS and a PKCE checking token endpoint
+type fakeProvider struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu        sync.Mutex
+	keys      *auth.KeySet
+	signKey   *auth.SigningKey
+	codes     map[string]authorization
+	jwksHits  int
+	audience  string
+	expiresIn time.Duration
+}
+
+// authorization is what the provider remembers between /authorize and /token
+type authorization struct {
+	Nonce         string
+	CodeChallenge string
+	Subject       string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	f := &fakeProvider{t: t, codes: map[string]authorization{}, audience: clientID, expiresIn: 5 * time.Minute}
+	f.rotate("key-1")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.server.URL,
+			"authorization_endpoint": f.server.URL + "/authorize",
+			"token_endpoint":         f.server.URL + "/token",
+			"jwks_uri":               f.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.jwksHits++
+		json.NewEncoder(w).Encode(f.keys.JWKS(time.Now()))
+	})
+	mux.HandleFunc("/token", f.token)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// rotate replaces the signing key, the old one is no longer published
+func (f *fakeProvider) rotate(kid string) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(f.t, err)
+	key := &auth.SigningKey{Kid: kid, Alg: auth.AlgRS256, Private: private}
+	keys := auth.NewKeySet(kid, time.Hour)
+	assert.Nil(f.t, keys.Add(key))
+	f.mu.Lock()
+	f.keys, f.signKey = keys, key
+	f.mu.Unlock()
+}
+
+// authorize plays the user signing in: the provider redirects back with a code
+func (f *fakeProvider) authorize(authURL string, subject string) (code string, state string) {
+	u, err := url.Parse(authURL)
+	assert.Nil(f.t, err)
+	q := u.Query()
+	assert.Equal(f.t, "code", q.Get("response_type"))
+	assert.Equal(f.t, "S256", q.Get("code_challenge_method"))
+	assert.Equal(f.t, clientID, q.Get("client_id"))
+	code = "code-" + subject
+	f.mu.Lock()
+	f.codes[code] = authorization{Nonce: q.Get("nonce"), CodeChallenge: q.Get("code_challenge"), Subject: subject}
+	f.mu.Unlock()
+	return code, q.Get("state")
+}
+
+func (f *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	assert.Nil(f.t, r.ParseForm())
+	f.mu.Lock()
+	authz, ok := f.codes[r.PostForm.Get("code")]
+	delete(f.codes, r.PostForm.Get("code"))
+	f.mu.Unlock()
+	if !ok || oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != authz.CodeChallenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": f.idToken(authz)})
+}
+
+func (f *fakeProvider) idToken(authz authorization) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            f.server.URL,
+		"sub":            authz.Subject,
+		"aud":            []string{f.audience},
+		"iat":            now.Unix(),
+		"exp":            now.Add(f.expiresIn).Unix(),
+		"nonce":          authz.Nonce,
+		"email":          authz.Subject + "@example.com",
+		"email_verified": true,
+	})
+	token.Header["kid"] = f.signKey.Kid
+	signed, err := token.SignedString(f.signKey.Private)
+	assert.Nil(f.t, err)
+	return signed
+}
+
+func newRedis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	global.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return mr
+}
+
+func discover(t *testing.T, f *fakeProvider) *oidc.Provider {
+	p, err := oidc.Discover(context.Background(), oidc.Config{
+		Name:        "fake",
+		Issuer:      f.server.URL,
+		ClientID:    clientID,
+		RedirectURL: "https://gnfarm.vn/auth/callback/fake",
+	}, f.server.Client())
+	assert.Nil(t, err)
+	return p
+}
+
+// login runs the flow like OIDCLoginStart / OIDCLoginCallback do
+func login(t *testing.T, f *fakeProvider, p *oidc.Provider, subject string) (*oidc.Claims, error) {
+	ctx := context.Background()
+	authURL, state, err := oidc.StartFlow(ctx, p, time.Minute)
+	assert.Nil(t, err)
+	code, returnedState := f.authorize(authURL, subject)
+	assert.Equal(t, state, returnedState)
+
+	flow, err := oidc.TakeFlow(ctx, returnedState)
+	assert.Nil(t, err)
+	rawIDToken, err := p.Exchange(ctx, code, flow.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	return p.VerifyIDToken(ctx, rawIDToken, flow.Nonce)
+}
+
+func TestLoginFlow(t *testing.T) {
+	newRedis(t)
+	f := newFakeProvider(t)
+	p := discover(t, f)
+
+	claims, err := login(t, f, p, "user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, "user-1@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+}
+
+func TestStateIsSingleUse(t *testing.T) {
+	mr := newRedis(t)
+	f := newFakeProvider(t)
+	p := discover(t, f)
+	ctx := context.Background()
+
+	_, state, err := oidc.StartFlow(ctx, p, time.Minute)
+	assert.Nil(t, err)
+	flow, err := oidc.TakeFlow(ctx, state)
+	assert.Nil(t, err)
+	assert.Equal(t, "fake", flow.Provider)
+	_, err = oidc.TakeFlow(ctx, state)
+	assert.ErrorIs(t, err, oidc.ErrInvalidState)
+
+	// expired
+	_, state, err = oidc.StartFlow(ctx, p, time.Minute)
+	assert.Nil(t, err)
+	mr.FastForward(2 * time.Minute)
+	_, err = oidc.TakeFlow(ctx, state)
+	assert.ErrorIs(t, err, oidc.ErrInvalidState)
+}
+
+func TestWrongVerifierIsRejected(t *testing.T) {
+	newRedis(t)
+	f := newFakeProvider(t)
+	p := discover(t, f)
+	ctx := context.Background()
+
+	authURL, _, err := oidc.StartFlow(ctx, p, time.Minute)
+	assert.Nil(t, err)
+	code, _ := f.authorize(authURL, "user-1")
+	_, err = p.Exchange(ctx, code, oidc.NewCodeVerifier())
+	assert.NotNil(t, err)
+}
+
+func TestWrongNonceIsRejected(t *testing.T) {
+	newRedis(t)
+	f := newFakeProvider(t)
+	p := discover(t, f)
+
+	rawIDToken := f.idToken(authorization{Nonce: "nonce-of-another-login", Subject: "user-1"})
+	_, err := p.VerifyIDToken(context.Background(), rawIDToken, "nonce-of-this-login")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestWrongAudienceIsRejected(t *testing.T) {
+	newRedis(t)
+	f := newFakeProvider(t)
+	p := discover(t, f)
+	f.audience = "another-client"
+
+	_, err := login(t, f, p, "user-1")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestExpiredTokenIsRejected(t *testing.T) {
+	newRedis(t)
+	f := newFakeProvider(t)
+	p := discover(t, f)
+	f.expiresIn = -5 * time.Minute // beyond the clock skew leeway
+
+	_, err := login(t, f, p, "user-1")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestKeyRotationRefetchesJWKS(t *testing.T) {
+	newRedis(t)
+	f := newFakeProvider(t)
+	p := discover(t, f)
+
+	_, err := login(t, f, p, "user-1")
+	assert.Nil(t, err)
+	_, err = login(t, f, p, "user-2")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, f.jwksHits) // cached
+
+	f.rotate("key-2")
+	_, err = login(t, f, p, "user-3")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, f.jwksHits)
+}
+
+func TestTokenSignedByUnknownKeyIsRejected(t *testing.T) {
+	newRedis(t)
+	f := newFakeProvider(t)
+	p := discover(t, f)
+
+	attacker := newFakeProvider(t)
+	attacker.server.URL = f.server.URL // same claims, different key
+	rawIDToken := attacker.idToken(authorization{Nonce: "n", Subject: "user-1"})
+	_, err := p.VerifyIDToken(context.Background(), rawIDToken, "n")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestDiscoveryOfAnotherIssuerFails(t *testing.T) {
+	f := newFakeProvider(t)
+	_, err := oidc.Discover(context.Background(), oidc.Config{
+		Name:     "fake",
+		Issuer:   f.server.URL + "/other",
+		ClientID: clientID,
+	}, f.server.Client())
+	assert.NotNil(t, err)
+}
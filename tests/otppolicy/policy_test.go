@@ -0,0 +1,81 @@
+package otppolicy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/otppolicy"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPolicy(t *testing.T) (*otppolicy.Policy, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	global.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return &otppolicy.Policy{
+		Scope: "test", MaxAttempts: 3, MaxAttemptsPerIp: 5, AttemptWindow: time.Minute,
+		ResendCooldown: time.Minute, BaseLockout: 5 * time.Minute, MaxLockout: 30 * time.Minute,
+	}, mr
+}
+
+func TestResendCooldown(t *testing.T) {
+	p, mr := newPolicy(t)
+	ctx := context.Background()
+
+	assert.Nil(t, p.CheckSend(ctx, "user-1"))
+	assert.True(t, errors.Is(p.CheckSend(ctx, "user-1"), otppolicy.ErrTooSoon))
+	assert.Nil(t, p.CheckSend(ctx, "user-2"))
+
+	mr.FastForward(time.Minute)
+	assert.Nil(t, p.CheckSend(ctx, "user-1"))
+}
+
+func TestLockoutBackoff(t *testing.T) {
+	p, mr := newPolicy(t)
+	ctx := context.Background()
+
+	assert.Nil(t, p.Fail(ctx, "user-1", ""))
+	assert.Nil(t, p.Fail(ctx, "user-1", ""))
+	assert.True(t, errors.Is(p.Fail(ctx, "user-1", ""), otppolicy.ErrLocked))
+	assert.True(t, errors.Is(p.CheckVerify(ctx, "user-1", ""), otppolicy.ErrLocked))
+	assert.True(t, errors.Is(p.CheckSend(ctx, "user-1"), otppolicy.ErrLocked))
+
+	// second lock doubles the lock time
+	mr.FastForward(5 * time.Minute)
+	assert.Nil(t, p.CheckVerify(ctx, "user-1", ""))
+	for i := 0; i < 3; i++ {
+		p.Fail(ctx, "user-1", "")
+	}
+	mr.FastForward(5 * time.Minute)
+	assert.True(t, errors.Is(p.CheckVerify(ctx, "user-1", ""), otppolicy.ErrLocked))
+	mr.FastForward(5 * time.Minute)
+	assert.Nil(t, p.CheckVerify(ctx, "user-1", ""))
+}
+
+func TestLockPerIp(t *testing.T) {
+	p, _ := newPolicy(t)
+	ctx := context.Background()
+
+	// different keys, same IP
+	for i, key := range []string{"a", "b", "c", "d"} {
+		assert.Nil(t, p.Fail(ctx, key, "10.0.0.1"), i)
+	}
+	assert.True(t, errors.Is(p.Fail(ctx, "e", "10.0.0.1"), otppolicy.ErrLocked))
+	assert.True(t, errors.Is(p.CheckVerify(ctx, "f", "10.0.0.1"), otppolicy.ErrLocked))
+	assert.Nil(t, p.CheckVerify(ctx, "f", "10.0.0.2"))
+}
+
+func TestResetAfterSuccess(t *testing.T) {
+	p, _ := newPolicy(t)
+	ctx := context.Background()
+
+	p.Fail(ctx, "user-1", "")
+	p.Fail(ctx, "user-1", "")
+	assert.Nil(t, p.Reset(ctx, "user-1"))
+	assert.Nil(t, p.Fail(ctx, "user-1", ""))
+}
@@ -0,0 +1,71 @@
+package password
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"go_ecommerce/internal/utils/crypto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgon2idIsDefault(t *testing.T) {
+	hash, err := crypto.HashPassword("nam-huong-123")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=2$"))
+
+	match, needsRehash := crypto.MatchingPassword(hash, "nam-huong-123", "")
+	assert.True(t, match)
+	assert.False(t, needsRehash)
+
+	match, _ = crypto.MatchingPassword(hash, "wrong", "")
+	assert.False(t, match)
+}
+
+func TestLegacySha256NeedsRehash(t *testing.T) {
+	sum := sha256.Sum256([]byte("secret" + "abcd"))
+	legacy := hex.EncodeToString(sum[:])
+
+	match, needsRehash := crypto.MatchingPassword(legacy, "secret", "abcd")
+	assert.True(t, match)
+	assert.True(t, needsRehash)
+
+	match, needsRehash = crypto.MatchingPassword(legacy, "secret", "other-salt")
+	assert.False(t, match)
+	assert.False(t, needsRehash)
+}
+
+func TestBcryptHasher(t *testing.T) {
+	crypto.SetPasswordHasher(crypto.NewBcryptHasher(4))
+	defer crypto.SetPasswordHasher(crypto.NewArgon2idHasher(0, 0, 0))
+
+	hash, err := crypto.HashPassword("bonsai")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$2a$04$"))
+
+	match, needsRehash := crypto.MatchingPassword(hash, "bonsai", "")
+	assert.True(t, match)
+	assert.False(t, needsRehash)
+
+	// switching back to argon2id upgrades bcrypt hashes on next login
+	crypto.SetPasswordHasher(crypto.NewArgon2idHasher(0, 0, 0))
+	match, needsRehash = crypto.MatchingPassword(hash, "bonsai", "")
+	assert.True(t, match)
+	assert.True(t, needsRehash)
+}
+
+func TestArgon2idCorruptHashDoesNotPanic(t *testing.T) {
+	for _, corrupt := range []string{
+		"$argon2id$v=19$m=65536,t=0,p=2$c2FsdHNhbHQ$a2V5a2V5",
+		"$argon2id$v=19$m=65536,t=3,p=0$c2FsdHNhbHQ$a2V5a2V5",
+		"$argon2id$v=19$m=65536,t=3,p=2$$a2V5a2V5",
+		"$argon2id$v=19$m=65536,t=3,p=2$c2FsdHNhbHQ$",
+	} {
+		assert.NotPanics(t, func() {
+			match, _ := crypto.MatchingPassword(corrupt, "secret", "")
+			assert.False(t, match)
+		}, corrupt)
+	}
+}
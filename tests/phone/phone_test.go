@@ -0,0 +1,60 @@
+package phone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go_ecommerce/internal/utils/phone"
+	"go_ecommerce/internal/utils/sendto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"0912345678":        "+84912345678",
+		"0912 345 678":      "+84912345678",
+		"0912.345.678":      "+84912345678",
+		"+84 912 345 678":   "+84912345678",
+		"84912345678":       "+84912345678",
+		"0084912345678":     "+84912345678",
+		"(+84) 38-123-4567": "+84381234567",
+		"01671234567":       "+84371234567", // old 11-digit Viettel number
+		"01201234567":       "+84701234567", // old MobiFone number
+	}
+	for in, want := range cases {
+		got, err := phone.Normalize(in)
+		assert.Nil(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+}
+
+func TestNormalizeRejects(t *testing.T) {
+	for _, in := range []string{"", "12345", "0212345678", "+1 415 555 0100", "09123456789", "0912abc678"} {
+		_, err := phone.Normalize(in)
+		assert.ErrorIs(t, err, phone.ErrInvalidPhone, in)
+	}
+}
+
+func TestIsPhone(t *testing.T) {
+	assert.True(t, phone.IsPhone("0912 345 678"))
+	assert.True(t, phone.IsPhone("+84912345678"))
+	assert.False(t, phone.IsPhone("farm@gn.vn"))
+	assert.False(t, phone.IsPhone("nongdan01"))
+}
+
+func TestFileSMSSender(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sms.log")
+	sender := sendto.NewFileSMSSender(path)
+	assert.Nil(t, sender.SendSMS(context.Background(), "+84912345678", "ma OTP 123456"))
+	assert.Nil(t, sender.SendSMS(context.Background(), "+84381234567", "ma OTP 654321"))
+
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "+84912345678\tma OTP 123456")
+}
@@ -0,0 +1,109 @@
+package principal
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/middlewares"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/auth"
+	usercontext "go_ecommerce/internal/utils/context"
+	"go_ecommerce/internal/utils/rbac"
+	"go_ecommerce/internal/utils/session"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func setup(t *testing.T) {
+	mr := miniredis.RunT(t)
+	global.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	ks := auth.NewKeySet("test", time.Hour)
+	assert.Nil(t, ks.Add(&auth.SigningKey{Kid: "test", Alg: auth.AlgEdDSA, Private: private}))
+	auth.SetKeySet(ks)
+	gin.SetMode(gin.TestMode)
+	service.InitUserRole(fakeRoles{})
+}
+
+// fakeRoles stands in for the MySQL backed role service
+type fakeRoles struct{ service.IUserRole }
+
+func (fakeRoles) GetRoles(ctx context.Context, userId uint64) ([]string, error) {
+	return []string{rbac.RoleCustomer, rbac.RoleShopOwner}, nil
+}
+
+// login writes the session like issueLoginTokens and returns an access token
+func login(t *testing.T, subToken string, authLevel int) string {
+	ctx := context.Background()
+	assert.Nil(t, global.Rdb.Set(ctx, subToken, `{"UserId":7,"UserAccount":"farmer@example.com"}`, time.Hour).Err())
+	assert.Nil(t, session.Register(ctx, 7, subToken, session.Info{AuthLevel: authLevel}, time.Hour))
+	token, err := auth.CreateToken(subToken, "30m")
+	assert.Nil(t, err)
+	return token
+}
+
+// serve runs the middleware and records the principal seen from the gin and request contexts
+func serve(t *testing.T, token string) (int, *usercontext.Principal, *usercontext.Principal) {
+	var fromGin, fromRequest *usercontext.Principal
+	r := gin.New()
+	r.GET("/me", middlewares.AuthenMiddleware(), func(c *gin.Context) {
+		fromGin, _ = usercontext.GetPrincipal(c)
+		fromRequest, _ = usercontext.GetPrincipal(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code, fromGin, fromRequest
+}
+
+func TestMiddlewareResolvesPrincipal(t *testing.T) {
+	setup(t)
+	code, fromGin, fromRequest := serve(t, login(t, "sub-2fa", usercontext.AuthLevelTwoFactor))
+	assert.Equal(t, http.StatusOK, code)
+	if assert.NotNil(t, fromGin) {
+		assert.Equal(t, uint64(7), fromGin.UserId)
+		assert.Equal(t, "farmer@example.com", fromGin.UserAccount)
+		assert.Equal(t, "7", fromGin.ShopId)
+		assert.Equal(t, session.ID("sub-2fa"), fromGin.SessionId)
+		assert.Equal(t, "sub-2fa", fromGin.SubToken)
+		assert.Equal(t, usercontext.AuthLevelTwoFactor, fromGin.AuthLevel)
+		assert.Equal(t, []string{rbac.RoleCustomer, rbac.RoleShopOwner}, fromGin.Roles)
+	}
+	assert.Same(t, fromGin, fromRequest)
+}
+
+func TestPasswordSessionHasPasswordLevel(t *testing.T) {
+	setup(t)
+	_, p, _ := serve(t, login(t, "sub-pw", 0))
+	if assert.NotNil(t, p) {
+		assert.Equal(t, usercontext.AuthLevelPassword, p.AuthLevel)
+	}
+}
+
+func TestRevokedSessionHasNoPrincipal(t *testing.T) {
+	setup(t)
+	token := login(t, "sub-revoked", usercontext.AuthLevelPassword)
+	assert.Nil(t, session.RevokeAll(context.Background(), 7))
+	code, p, _ := serve(t, token)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Nil(t, p)
+}
+
+func TestNoPrincipalOutsideAuthenticatedRequests(t *testing.T) {
+	_, err := usercontext.GetPrincipal(context.Background())
+	assert.ErrorIs(t, err, usercontext.ErrNoPrincipal)
+}
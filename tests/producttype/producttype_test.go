@@ -0,0 +1,131 @@
+package producttype
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go_ecommerce/internal/repo"
+	"go_ecommerce/internal/utils/producttype"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decode gives the attributes as gin binds them, numbers are float64
+func decode(t *testing.T, body string) map[string]interface{} {
+	t.Helper()
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(body), &raw))
+	return raw
+}
+
+func TestBonsaiIntegersFromJSON(t *testing.T) {
+	bonsai, err := producttype.Get(producttype.Bonsai)
+	require.NoError(t, err)
+
+	attrs, err := bonsai.Validate(decode(t, `{"age": 12, "height": 35, "species": "Juniper", "style": "cascade"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 12, attrs.Int("age"))
+	assert.Equal(t, 35, attrs.Int("height"))
+	assert.Equal(t, "Juniper", attrs.String("species"))
+	assert.Equal(t, "cascade", attrs.String("style"))
+}
+
+func TestFieldErrors(t *testing.T) {
+	bonsai, err := producttype.Get(producttype.Bonsai)
+	require.NoError(t, err)
+
+	_, err = bonsai.Validate(decode(t, `{"age": 1.5, "height": "tall", "style": "round", "color": "green"}`))
+	var invalid *producttype.ValidationError
+	require.True(t, errors.As(err, &invalid), err)
+	assert.Equal(t, []producttype.FieldError{
+		{Field: "age", Message: "must be a whole number"},
+		{Field: "height", Message: "must be a number"},
+		{Field: "style", Message: "must be one of formal_upright, informal_upright, slanting, cascade, semi_cascade, windswept, literati, broom, forest"},
+		{Field: "species", Message: "is required"},
+		{Field: "color", Message: "is not an attribute of Bonsai"},
+	}, invalid.Fields)
+}
+
+func TestBoundsUseUnit(t *testing.T) {
+	mushroom, err := producttype.Get(producttype.Mushroom)
+	require.NoError(t, err)
+
+	_, err = mushroom.Validate(decode(t, `{"weight": 0}`))
+	var invalid *producttype.ValidationError
+	require.True(t, errors.As(err, &invalid), err)
+	assert.Equal(t, "must be at least 0.01 kg", invalid.Fields[0].Message)
+
+	attrs, err := mushroom.Validate(decode(t, `{"weight": 0.25, "freshness": "dried"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, attrs.Float("weight"))
+}
+
+func TestValidatePartial(t *testing.T) {
+	vegetable, err := producttype.Get(producttype.Vegetable)
+	require.NoError(t, err)
+
+	// the weight is required on create, not on update
+	attrs, err := vegetable.ValidatePartial(decode(t, `{"origin": "Da Lat"}`))
+	require.NoError(t, err)
+	assert.Equal(t, producttype.Attributes{"origin": "Da Lat"}, attrs)
+
+	_, err = vegetable.ValidatePartial(decode(t, `{"weight": -1}`))
+	assert.Error(t, err)
+}
+
+func TestRegisterNewType(t *testing.T) {
+	var created producttype.Attributes
+	producttype.Register(&producttype.Type{
+		Name: "Fertilizer",
+		Schema: producttype.Schema{
+			{Name: "organic", Kind: producttype.KindBool, Required: true},
+			{Name: "volume", Kind: producttype.KindNumber, Unit: "l", Min: producttype.Bound(0.1)},
+		},
+		Create: func(ctx context.Context, r repo.IProductRepository, productID string, shopID string, attrs producttype.Attributes) error {
+			created = attrs
+			return nil
+		},
+		Update: func(ctx context.Context, r repo.IProductRepository, productID string, shopID string, attrs producttype.Attributes) error {
+			return nil
+		},
+		Load: func(ctx context.Context, r repo.IProductRepository, productIDs []string) (map[string]producttype.Attributes, error) {
+			return nil, nil
+		},
+	})
+
+	fertilizer, err := producttype.Get("Fertilizer")
+	require.NoError(t, err)
+	attrs, err := fertilizer.Validate(decode(t, `{"organic": true, "volume": 5}`))
+	require.NoError(t, err)
+	require.NoError(t, fertilizer.Create(context.Background(), nil, "p-1", "shop-1", attrs))
+	assert.True(t, created.Bool("organic"))
+	assert.Equal(t, 5.0, created.Float("volume"))
+
+	var names []string
+	for _, pt := range producttype.List() {
+		names = append(names, pt.Name)
+	}
+	assert.Equal(t, []string{"Bonsai", "Fertilizer", "Mushroom", "Vegetable"}, names)
+
+	assert.Panics(t, func() {
+		producttype.Register(&producttype.Type{Name: "Fertilizer", Create: fertilizer.Create, Update: fertilizer.Update, Load: fertilizer.Load})
+	})
+	_, err = producttype.Get("Fruit")
+	assert.ErrorIs(t, err, producttype.ErrUnknownType)
+}
+
+func TestSearchText(t *testing.T) {
+	bonsai, err := producttype.Get(producttype.Bonsai)
+	require.NoError(t, err)
+	assert.Equal(t, "Juniperus chinensis", bonsai.SearchText(producttype.Attributes{
+		"species": "Juniperus chinensis", "style": "cascade", "age": 12,
+	}))
+
+	mushroom, err := producttype.Get(producttype.Mushroom)
+	require.NoError(t, err)
+	assert.Equal(t, "Đà Lạt", mushroom.SearchText(producttype.Attributes{"weight": 0.5, "origin": "Đà Lạt"}))
+	assert.Equal(t, "", mushroom.SearchText(producttype.Attributes{"weight": 0.5}))
+}
@@ -0,0 +1,123 @@
+package rbac
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/middlewares"
+	"go_ecommerce/internal/service"
+	"go_ecommerce/internal/utils/auth"
+	"go_ecommerce/internal/utils/rbac"
+	"go_ecommerce/internal/utils/session"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRoles serves the roles of user 7 and counts the lookups
+type fakeRoles struct {
+	service.IUserRole
+	roles   []string
+	lookups int
+}
+
+func (f *fakeRoles) GetRoles(ctx context.Context, userId uint64) ([]string, error) {
+	f.lookups++
+	return f.roles, nil
+}
+
+func setup(t *testing.T, roles ...string) *fakeRoles {
+	mr := miniredis.RunT(t)
+	global.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	ks := auth.NewKeySet("test", time.Hour)
+	assert.Nil(t, ks.Add(&auth.SigningKey{Kid: "test", Alg: auth.AlgEdDSA, Private: private}))
+	auth.SetKeySet(ks)
+	gin.SetMode(gin.TestMode)
+	fake := &fakeRoles{roles: append([]string{rbac.RoleCustomer}, roles...)}
+	service.InitUserRole(fake)
+	return fake
+}
+
+func login(t *testing.T, subToken string) string {
+	ctx := context.Background()
+	assert.Nil(t, global.Rdb.Set(ctx, subToken, `{"UserId":7,"UserAccount":"farmer@example.com"}`, time.Hour).Err())
+	assert.Nil(t, session.Register(ctx, 7, subToken, session.Info{}, time.Hour))
+	token, err := auth.CreateToken(subToken, "30m")
+	assert.Nil(t, err)
+	return token
+}
+
+func publish(t *testing.T, token string) int {
+	r := gin.New()
+	r.PUT("/product/publish/:id", middlewares.AuthenMiddleware(), middlewares.RequirePermission(rbac.PermProductPublish), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodPut, "/product/publish/p1", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRolePermissions(t *testing.T) {
+	assert.False(t, rbac.Can([]string{rbac.RoleCustomer}, rbac.PermProductCreate))
+	assert.True(t, rbac.Can([]string{rbac.RoleCustomer, rbac.RoleShopStaff}, rbac.PermProductCreate))
+	assert.False(t, rbac.Can([]string{rbac.RoleShopStaff}, rbac.PermProductPublish))
+	assert.True(t, rbac.Can([]string{rbac.RoleModerator}, rbac.PermUserLock))
+	assert.False(t, rbac.Can([]string{rbac.RoleModerator}, rbac.PermRoleAssign))
+	assert.False(t, rbac.Can([]string{"root"}, rbac.PermRoleAssign))
+	for _, role := range rbac.Roles() {
+		assert.True(t, rbac.IsRole(role))
+		for _, p := range rbac.Permissions([]string{role}) {
+			assert.True(t, rbac.Can([]string{rbac.RoleAdmin}, p), "admin lacks %s", p)
+		}
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	setup(t)
+	assert.Equal(t, http.StatusForbidden, publish(t, login(t, "sub-customer")))
+
+	setup(t, rbac.RoleShopOwner)
+	assert.Equal(t, http.StatusOK, publish(t, login(t, "sub-owner")))
+}
+
+func TestRolesAreCachedPerSession(t *testing.T) {
+	fake := setup(t)
+	token := login(t, "sub-1")
+	assert.Equal(t, http.StatusForbidden, publish(t, token))
+	assert.Equal(t, http.StatusForbidden, publish(t, token))
+	assert.Equal(t, 1, fake.lookups)
+
+	// role granted: the service clears the cache of every session of the user
+	fake.roles = append(fake.roles, rbac.RoleShopOwner)
+	assert.Nil(t, session.ClearRoles(context.Background(), 7))
+	assert.Equal(t, http.StatusOK, publish(t, token))
+	assert.Equal(t, 2, fake.lookups)
+}
+
+func TestCanManage(t *testing.T) {
+	moderator := []string{rbac.RoleCustomer, rbac.RoleModerator}
+	admin := []string{rbac.RoleCustomer, rbac.RoleAdmin}
+	seller := []string{rbac.RoleCustomer, rbac.RoleShopOwner}
+
+	assert.True(t, rbac.CanManage(moderator, seller))
+	assert.True(t, rbac.CanManage(moderator, []string{rbac.RoleCustomer}))
+	assert.False(t, rbac.CanManage(moderator, admin))
+	assert.False(t, rbac.CanManage(moderator, moderator))
+	assert.False(t, rbac.CanManage(moderator, []string{rbac.RoleShopOwner, rbac.RoleModerator}))
+
+	assert.True(t, rbac.CanManage(admin, moderator))
+	assert.True(t, rbac.CanManage(admin, admin))
+	assert.True(t, rbac.CanManage(admin, seller))
+}
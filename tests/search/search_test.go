@@ -0,0 +1,122 @@
+package search
+
+import (
+	"testing"
+
+	"go_ecommerce/internal/utils/search"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFold(t *testing.T) {
+	assert.Equal(t, "nam huong da lat", search.Fold("Nấm Hương Đà Lạt"))
+	assert.Equal(t, "cay tung la kim", search.Fold("Cây tùng lá kim"))
+	assert.Equal(t, []string{"nam", "dui", "ga", "500g"}, search.Tokenize("Nấm đùi gà, 500g!"))
+}
+
+func newIndex() *search.Index {
+	ix := search.NewIndex(
+		search.Field{Name: "name", Boost: 3},
+		search.Field{Name: "type", Boost: 2},
+		search.Field{Name: "description", Boost: 1},
+	)
+	ix.Put(search.Document{ID: "p-1", Tag: "Mushroom", Fields: map[string]string{
+		"name": "Nấm hương Đà Lạt", "type": "Mushroom", "description": "Nấm hương sấy khô",
+	}})
+	ix.Put(search.Document{ID: "p-2", Tag: "Mushroom", Fields: map[string]string{
+		"name": "Nấm đùi gà", "type": "Mushroom", "description": "Ngon hơn nấm hương",
+	}})
+	ix.Put(search.Document{ID: "p-3", Tag: "Bonsai", Fields: map[string]string{
+		"name": "Tùng la hán", "type": "Bonsai", "description": "Dáng trực, chậu gốm",
+	}})
+	return ix
+}
+
+func ids(hits []search.Hit) []string {
+	var out []string
+	for _, h := range hits {
+		out = append(out, h.ID)
+	}
+	return out
+}
+
+func TestAccentsAreOptional(t *testing.T) {
+	ix := newIndex()
+	for _, query := range []string{"nam huong", "Nấm Hương", "NAM HUONG"} {
+		hits, total := ix.Search(query, "", 0, 10)
+		assert.Equal(t, 2, total, query)
+		// the name ranks above the description
+		assert.Equal(t, []string{"p-1", "p-2"}, ids(hits), query)
+	}
+}
+
+func TestEveryWordMustMatch(t *testing.T) {
+	hits, _ := newIndex().Search("nam dui ga", "", 0, 10)
+	assert.Equal(t, []string{"p-2"}, ids(hits))
+
+	hits, total := newIndex().Search("nam bonsai", "", 0, 10)
+	assert.Empty(t, hits)
+	assert.Equal(t, 0, total)
+}
+
+func TestTypos(t *testing.T) {
+	ix := newIndex()
+	// a swap and a missing letter
+	hits, _ := ix.Search("nam huogn", "", 0, 10)
+	assert.Equal(t, []string{"p-1", "p-2"}, ids(hits))
+	hits, _ = ix.Search("bonsi", "", 0, 10)
+	assert.Equal(t, []string{"p-3"}, ids(hits))
+	// short words must be exact, "nap" is not "nam"
+	hits, _ = ix.Search("nap", "", 0, 10)
+	assert.Empty(t, hits)
+}
+
+func TestLastWordPrefix(t *testing.T) {
+	hits, _ := newIndex().Search("nam hu", "", 0, 10)
+	assert.Equal(t, []string{"p-1", "p-2"}, ids(hits))
+
+	// only the last word is a prefix
+	hits, _ = newIndex().Search("hu nam", "", 0, 10)
+	assert.Empty(t, hits)
+}
+
+func TestBoostRanksNameAboveType(t *testing.T) {
+	ix := search.NewIndex(search.Field{Name: "name", Boost: 3}, search.Field{Name: "type", Boost: 2})
+	ix.Put(search.Document{ID: "a", Fields: map[string]string{"name": "Rau muống", "type": "Vegetable"}})
+	ix.Put(search.Document{ID: "b", Fields: map[string]string{"name": "Vegetable mix", "type": "Mushroom"}})
+	hits, _ := ix.Search("vegetable", "", 0, 10)
+	assert.Equal(t, []string{"b", "a"}, ids(hits))
+}
+
+func TestTagAndPages(t *testing.T) {
+	ix := newIndex()
+	all, _ := ix.Search("nam", "Mushroom", 0, 10)
+	hits, total := ix.Search("nam", "Mushroom", 1, 1)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, ids(all[1:]), ids(hits))
+
+	hits, total = ix.Search("nam", "Bonsai", 0, 10)
+	assert.Empty(t, hits)
+	assert.Equal(t, 0, total)
+
+	hits, total = ix.Search("nam", "", 5, 10)
+	assert.Empty(t, hits)
+	assert.Equal(t, 2, total)
+}
+
+func TestPutRemoveReset(t *testing.T) {
+	ix := newIndex()
+	ix.Put(search.Document{ID: "p-1", Fields: map[string]string{"name": "Rau cải"}})
+	hits, _ := ix.Search("nam huong", "", 0, 10)
+	assert.Equal(t, []string{"p-2"}, ids(hits))
+
+	ix.Remove("p-2")
+	hits, _ = ix.Search("nam", "", 0, 10)
+	assert.Empty(t, hits)
+	assert.Equal(t, 2, ix.Len())
+
+	ix.Reset([]search.Document{{ID: "p-9", Fields: map[string]string{"name": "Nấm linh chi"}}})
+	assert.Equal(t, 1, ix.Len())
+	hits, _ = ix.Search("linh chi", "", 0, 10)
+	assert.Equal(t, []string{"p-9"}, ids(hits))
+}
@@ -0,0 +1,113 @@
+package sendto
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go_ecommerce/internal/utils/sendto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func invoice() *sendto.Mail {
+	return &sendto.Mail{
+		From:     sendto.EmailAddress{Address: "shop@gnfarm.vn", Name: "GN Farm"},
+		To:       []string{"a@example.com", "b@example.com"},
+		Cc:       []string{"c@example.com"},
+		Bcc:      []string{"audit@gnfarm.vn"},
+		Subject:  "Hoá đơn #1001",
+		Body:     "Cảm ơn bạn đã mua nấm linh chi.",
+		HTMLBody: "<p>Cảm ơn bạn đã mua nấm linh chi.</p>",
+		Attachments: []sendto.Attachment{
+			{Filename: "invoice-1001.pdf", ContentType: "application/pdf", Content: []byte("%PDF-1.4 fake")},
+		},
+	}
+}
+
+func TestOutboxCapturesAllRecipients(t *testing.T) {
+	dir := t.TempDir()
+	outbox := sendto.NewOutboxSender(dir, 2)
+	sendto.SetEmailSender(outbox)
+
+	assert.Nil(t, sendto.SendEmail(context.Background(), invoice()))
+	assert.Nil(t, sendto.SendTextEmailOtp([]string{"farmer@example.com"}, "otp@gnfarm.vn", "123456"))
+
+	mails := outbox.List("")
+	assert.Len(t, mails, 2)
+	assert.Equal(t, "OTP Verification", mails[0].Subject) // newest first
+	assert.Len(t, outbox.List("audit@gnfarm.vn"), 1)
+	assert.Equal(t, 13, outbox.List("c@example.com")[0].Attachments[0].Size)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.Len(t, files, 2)
+
+	// limit keeps the newest mails only
+	assert.Nil(t, sendto.SendTextEmailOtp([]string{"x@example.com"}, "otp@gnfarm.vn", "654321"))
+	assert.Len(t, outbox.List(""), 2)
+	assert.Len(t, outbox.List("a@example.com"), 0)
+}
+
+func TestSendEmailRequiresRecipient(t *testing.T) {
+	sendto.SetEmailSender(sendto.NewOutboxSender("", 10))
+	assert.NotNil(t, sendto.SendEmail(context.Background(), &sendto.Mail{Subject: "empty"}))
+}
+
+func TestBuildMIME(t *testing.T) {
+	raw, err := sendto.BuildMIME(invoice())
+	assert.Nil(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	assert.Equal(t, "a@example.com, b@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "c@example.com", msg.Header.Get("Cc"))
+	assert.Empty(t, msg.Header.Get("Bcc"))
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.Equal(t, "Hoá đơn #1001", subject)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.Nil(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+
+	body, err := reader.NextPart()
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(body.Header.Get("Content-Type"), "multipart/alternative"))
+
+	attachment, err := reader.NextPart()
+	assert.Nil(t, err)
+	assert.Equal(t, "invoice-1001.pdf", attachment.FileName())
+	content, _ := io.ReadAll(attachment)
+	assert.Contains(t, string(content), "JVBERi0xLjQgZmFrZQ==")
+}
+
+func TestSMTPStalledServerTimesOut(t *testing.T) {
+	// accepts the connection but never sends the 220 greeting
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	sender := sendto.NewSMTPSender(host, portNum, "", "", 200*time.Millisecond)
+
+	start := time.Now()
+	err = sender.SendEmail(context.Background(), invoice())
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
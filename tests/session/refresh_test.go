@@ -0,0 +1,72 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/session"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const payload = `{"UserId":1,"UserAccount":"a@gnfarm.vn"}`
+
+// loginFamily creates the session (token family) with its first refresh token id
+func loginFamily(t *testing.T, subToken string, jti string) {
+	ctx := context.Background()
+	assert.Nil(t, global.Rdb.Set(ctx, subToken, payload, time.Hour).Err())
+	assert.Nil(t, session.Register(ctx, 1, subToken, session.Info{}, time.Hour))
+	assert.Nil(t, session.SetRefreshToken(ctx, subToken, jti, time.Hour))
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	mr := newRedis(t)
+	ctx := context.Background()
+	loginFamily(t, "sub-a", "jti-1")
+
+	mr.FastForward(30 * time.Minute)
+	assert.Nil(t, session.RotateRefreshToken(ctx, 1, "sub-a", "jti-1", "jti-2", time.Hour))
+	assert.Nil(t, session.RotateRefreshToken(ctx, 1, "sub-a", "jti-2", "jti-3", time.Hour))
+
+	current, err := global.Rdb.Get(ctx, "sub-a_refresh").Result()
+	assert.Nil(t, err)
+	assert.Equal(t, "jti-3", current)
+
+	// the user-info payload survives the refresh and its TTL is extended
+	got, err := global.Rdb.Get(ctx, "sub-a").Result()
+	assert.Nil(t, err)
+	assert.Equal(t, payload, got)
+	assert.Equal(t, time.Hour, mr.TTL("sub-a"))
+}
+
+func TestReplayRevokesFamily(t *testing.T) {
+	newRedis(t)
+	ctx := context.Background()
+	loginFamily(t, "sub-a", "jti-1")
+	assert.Nil(t, session.RotateRefreshToken(ctx, 1, "sub-a", "jti-1", "jti-2", time.Hour))
+
+	// a stolen jti-1 is presented again
+	err := session.RotateRefreshToken(ctx, 1, "sub-a", "jti-1", "jti-x", time.Hour)
+	assert.True(t, errors.Is(err, session.ErrRefreshTokenReused))
+
+	// the service revokes the family on reuse, the legitimate jti-2 is dead as well
+	assert.Nil(t, session.Revoke(ctx, 1, "sub-a"))
+	err = session.RotateRefreshToken(ctx, 1, "sub-a", "jti-2", "jti-3", time.Hour)
+	assert.True(t, errors.Is(err, session.ErrRefreshTokenInvalid))
+	alive, err := session.Touch(ctx, "sub-a")
+	assert.Nil(t, err)
+	assert.False(t, alive)
+}
+
+func TestStaleTokenOfFamilyIsReuse(t *testing.T) {
+	newRedis(t)
+	ctx := context.Background()
+	loginFamily(t, "sub-a", "jti-2")
+
+	// signed for this family but never the current one
+	err := session.RotateRefreshToken(ctx, 1, "sub-a", "jti-1", "jti-3", time.Hour)
+	assert.True(t, errors.Is(err, session.ErrRefreshTokenReused))
+}
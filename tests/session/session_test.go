@@ -0,0 +1,130 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/session"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRedis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	global.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return mr
+}
+
+// login writes the subToken payload like issueLoginTokens does, then indexes the session
+func login(t *testing.T, userId uint64, subToken string, ua string, ttl time.Duration) {
+	ctx := context.Background()
+	assert.Nil(t, global.Rdb.Set(ctx, subToken, `{"UserId":1}`, ttl).Err())
+	assert.Nil(t, session.Register(ctx, userId, subToken, session.Info{ClientIp: "10.0.0.1", UserAgent: ua}, ttl))
+}
+
+func TestListSessions(t *testing.T) {
+	newRedis(t)
+	ctx := context.Background()
+	login(t, 1, "sub-phone", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)", time.Hour)
+	login(t, 1, "sub-laptop", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)", time.Hour)
+	login(t, 2, "sub-other-user", "", time.Hour)
+
+	sessions, err := session.List(ctx, 1)
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 2)
+	devices := map[string]string{}
+	for _, s := range sessions {
+		devices[s.SessionId] = s.Device
+		assert.Equal(t, "10.0.0.1", s.ClientIp)
+		assert.NotZero(t, s.CreatedAt)
+	}
+	assert.Equal(t, "iPhone", devices[session.ID("sub-phone")])
+	assert.Equal(t, "Windows", devices[session.ID("sub-laptop")])
+}
+
+func TestListPrunesExpiredSessions(t *testing.T) {
+	mr := newRedis(t)
+	ctx := context.Background()
+	login(t, 1, "sub-short", "", time.Minute)
+	login(t, 1, "sub-long", "", time.Hour)
+
+	// the payload expires first, its index entry must go away on the next listing
+	mr.FastForward(2 * time.Minute)
+	sessions, err := session.List(ctx, 1)
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, session.ID("sub-long"), sessions[0].SessionId)
+
+	members, err := global.Rdb.SMembers(ctx, "sess:user:1").Result()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"sub-long"}, members)
+}
+
+func TestRevokeByID(t *testing.T) {
+	newRedis(t)
+	ctx := context.Background()
+	login(t, 1, "sub-a", "", time.Hour)
+	login(t, 1, "sub-b", "", time.Hour)
+
+	found, err := session.RevokeByID(ctx, 1, session.ID("sub-a"))
+	assert.Nil(t, err)
+	assert.True(t, found)
+	alive, err := session.Touch(ctx, "sub-a")
+	assert.Nil(t, err)
+	assert.False(t, alive)
+
+	// another user's session id is not found
+	found, err = session.RevokeByID(ctx, 2, session.ID("sub-b"))
+	assert.Nil(t, err)
+	assert.False(t, found)
+	alive, _ = session.Touch(ctx, "sub-b")
+	assert.True(t, alive)
+}
+
+func TestLogoutAll(t *testing.T) {
+	newRedis(t)
+	ctx := context.Background()
+	login(t, 1, "sub-a", "", time.Hour)
+	login(t, 1, "sub-b", "", time.Hour)
+	login(t, 2, "sub-c", "", time.Hour)
+
+	assert.Nil(t, session.RevokeAll(ctx, 1))
+	sessions, err := session.List(ctx, 1)
+	assert.Nil(t, err)
+	assert.Empty(t, sessions)
+	for _, subToken := range []string{"sub-a", "sub-b"} {
+		alive, err := session.Touch(ctx, subToken)
+		assert.Nil(t, err)
+		assert.False(t, alive)
+	}
+
+	// other users keep their sessions
+	alive, _ := session.Touch(ctx, "sub-c")
+	assert.True(t, alive)
+}
+
+func TestSetAuthLevelAfterStepUp(t *testing.T) {
+	mr := newRedis(t)
+	ctx := context.Background()
+	login(t, 1, "sub-a", "", time.Hour)
+
+	found, err := session.SetAuthLevel(ctx, "sub-a", 2)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	info, _, err := session.Get(ctx, "sub-a")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, info.AuthLevel)
+	// the TTL of the session is kept
+	assert.True(t, mr.TTL("sess:meta:sub-a") > 0)
+
+	// a revoked session does not come back
+	assert.Nil(t, session.Revoke(ctx, 1, "sub-a"))
+	found, err = session.SetAuthLevel(ctx, "sub-a", 2)
+	assert.Nil(t, err)
+	assert.False(t, found)
+	assert.False(t, mr.Exists("sess:meta:sub-a"))
+}
@@ -0,0 +1,42 @@
+package twofactor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go_ecommerce/internal/utils/random"
+	"go_ecommerce/internal/utils/totp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTotpValidateWithClockSkew(t *testing.T) {
+	key, err := totp.GenerateKey("GN Farm", "farmer@example.com")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(key.URL, "otpauth://totp/"))
+	assert.True(t, strings.HasPrefix(key.QRCode, "data:image/png;base64,"))
+
+	now := time.Now()
+	code, err := totp.GenerateCode(key.Secret, now)
+	assert.Nil(t, err)
+
+	assert.True(t, totp.Validate(code, key.Secret, now), "current step")
+	assert.True(t, totp.Validate(code, key.Secret, now.Add(30*time.Second)), "one step of drift")
+	assert.False(t, totp.Validate(code, key.Secret, now.Add(2*time.Minute)), "too far away")
+	assert.False(t, totp.Validate("000000x", key.Secret, now), "malformed code")
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := random.GenerateRecoveryCodes(10)
+	assert.Nil(t, err)
+	assert.Len(t, codes, 10)
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		assert.Len(t, code, 11)
+		assert.Equal(t, "-", code[5:6])
+		assert.False(t, seen[code])
+		seen[code] = true
+	}
+}
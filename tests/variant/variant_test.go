@@ -0,0 +1,112 @@
+package variant
+
+import (
+	"errors"
+	"testing"
+
+	"go_ecommerce/internal/model"
+	"go_ecommerce/internal/utils/variant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestWithoutOptionsOneDefaultSku(t *testing.T) {
+	options, skus, err := variant.Build(nil, nil, nil, variant.Defaults{Price: 10, DiscountPrice: 8, Stock: 5})
+	require.NoError(t, err)
+	assert.Empty(t, options)
+	assert.Equal(t, []model.SkuModel{{
+		SkuCode: variant.DefaultSkuCode, Options: map[string]string{}, Price: 10, DiscountPrice: 8, Stock: 5,
+	}}, skus)
+}
+
+func TestMatrixFromOptions(t *testing.T) {
+	options, skus, err := variant.Build([]model.ProductOption{
+		{Name: " size ", Values: []string{"250g", "1 kg"}},
+		{Name: "packaging", Values: []string{"bag", "box", "tray"}},
+	}, nil, nil, variant.Defaults{Price: 10, Stock: 5})
+	require.NoError(t, err)
+	assert.Equal(t, "size", options[0].Name)
+
+	var codes []string
+	for _, sku := range skus {
+		codes = append(codes, sku.SkuCode)
+		assert.Equal(t, 10.0, sku.Price)
+		// the stock of a product is not split over the combinations
+		assert.Equal(t, 0, sku.Stock)
+	}
+	assert.Equal(t, []string{
+		"250G-BAG", "250G-BOX", "250G-TRAY", "1_KG-BAG", "1_KG-BOX", "1_KG-TRAY",
+	}, codes)
+}
+
+func TestTooManyCombinations(t *testing.T) {
+	values := make([]string, 0, variant.MaxValues)
+	for i := 0; i < variant.MaxValues; i++ {
+		values = append(values, string(rune('a'+i)))
+	}
+	_, _, err := variant.Build([]model.ProductOption{
+		{Name: "size", Values: values},
+		{Name: "color", Values: values},
+	}, nil, nil, variant.Defaults{Price: 10})
+	var invalid *variant.InvalidError
+	require.True(t, errors.As(err, &invalid), err)
+	assert.Equal(t, "options", invalid.Fields[0].Field)
+}
+
+func TestFieldErrors(t *testing.T) {
+	_, _, err := variant.Build([]model.ProductOption{
+		{Name: "size", Values: []string{"250g", "500g"}},
+	}, []model.SkuInput{
+		{Options: map[string]string{"size": "250g"}, Price: 10, DiscountPrice: 12},
+		{Options: map[string]string{"size": "250g", "color": "red"}, Price: 10},
+		{Options: map[string]string{"size": "2kg"}, Stock: intPtr(-1)},
+	}, nil, variant.Defaults{})
+	var invalid *variant.InvalidError
+	require.True(t, errors.As(err, &invalid), err)
+	assert.Equal(t, []variant.FieldError{
+		{Field: "skus[0].discounted_price", Message: "must be lower than the price"},
+		{Field: "skus[1].options.color", Message: "is not an option of the product"},
+		{Field: "skus[1].options", Message: "same options as skus[0]"},
+		{Field: "skus[1].sku_code", Message: "same code as skus[0]"},
+		{Field: "skus[2].options.size", Message: "must be one of 250g, 500g"},
+		{Field: "skus[2].price", Message: "must be greater than 0"},
+		{Field: "skus[2].stock", Message: "must not be negative"},
+	}, invalid.Fields)
+}
+
+func TestExistingSkusKeepPriceAndStock(t *testing.T) {
+	existing := []model.SkuModel{
+		{ID: "sku-250", SkuCode: "250G", Options: map[string]string{"size": "250g"}, Price: 10, DiscountPrice: 9, Weight: 0.3, Stock: 4},
+	}
+	_, skus, err := variant.Build([]model.ProductOption{
+		{Name: "size", Values: []string{"250g", "500g"}},
+	}, nil, existing, variant.Defaults{Price: 12})
+	require.NoError(t, err)
+	require.Len(t, skus, 2)
+	assert.Equal(t, existing[0], skus[0])
+	assert.Equal(t, model.SkuModel{SkuCode: "500G", Options: map[string]string{"size": "500g"}, Price: 12}, skus[1])
+
+	// a row with a price replaces price and discount, the stock is kept
+	_, skus, err = variant.Build([]model.ProductOption{
+		{Name: "size", Values: []string{"250g"}},
+	}, []model.SkuInput{{Options: map[string]string{"size": "250g"}, Price: 11}}, existing, variant.Defaults{})
+	require.NoError(t, err)
+	assert.Equal(t, 11.0, skus[0].Price)
+	assert.Equal(t, 0.0, skus[0].DiscountPrice)
+	assert.Equal(t, 4, skus[0].Stock)
+}
+
+func TestSummary(t *testing.T) {
+	price, discountPrice, quantity := variant.Summary([]model.SkuModel{
+		{Price: 18, DiscountPrice: 16, Stock: 3},
+		{Price: 12, Stock: 2},
+		{Price: 14, DiscountPrice: 9},
+	})
+	// price and discount come from the same SKU
+	assert.Equal(t, 14.0, price)
+	assert.Equal(t, 9.0, discountPrice)
+	assert.Equal(t, 5, quantity)
+}
@@ -0,0 +1,426 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"go_ecommerce/global"
+	"go_ecommerce/internal/utils/webauthn"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	rpID   = "gnfarm.vn"
+	origin = "https://gnfarm.vn"
+)
+
+func newRP() *webauthn.RelyingParty {
+	return &webauthn.RelyingParty{ID: rpID, Name: "GN Farm", Origins: []string{origin}, Timeout: time.Minute}
+}
+
+// softAuthenticator is a passkey in memory: it answers create and get like a browser would
+type softAuthenticator struct {
+	t          *testing.T
+	credID     []byte
+	signer     crypto.Signer
+	coseKey    []byte
+	signCount  uint32
+	rpID       string
+	origin     string
+	uv         bool
+	userHandle []byte
+}
+
+func newES256Authenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	cose := encodeCBOR(map[interface{}]interface{}{
+		int64(1): int64(2), int64(3): webauthn.AlgES256, int64(-1): int64(1), int64(-2): x, int64(-3): y,
+	})
+	return newSoftAuthenticator(t, key, cose)
+}
+
+func newEd25519Authenticator(t *testing.T) *softAuthenticator {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	cose := encodeCBOR(map[interface{}]interface{}{
+		int64(1): int64(1), int64(3): webauthn.AlgEdDSA, int64(-1): int64(6), int64(-2): []byte(pub),
+	})
+	return newSoftAuthenticator(t, priv, cose)
+}
+
+func newSoftAuthenticator(t *testing.T, signer crypto.Signer, cose []byte) *softAuthenticator {
+	credID := make([]byte, 32)
+	rand.Read(credID)
+	return &softAuthenticator{t: t, credID: credID, signer: signer, coseKey: cose, rpID: rpID, origin: origin, uv: true, userHandle: webauthn.UserHandle(42)}
+}
+
+func (a *softAuthenticator) clientData(typ string, challenge string) []byte {
+	b, err := json.Marshal(webauthn.CollectedClientData{Type: typ, Challenge: challenge, Origin: a.origin})
+	assert.NoError(a.t, err)
+	return b
+}
+
+func (a *softAuthenticator) authData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	flags := byte(0x01) // UP
+	if a.uv {
+		flags |= 0x04
+	}
+	var buf bytes.Buffer
+	buf.Write(rpIDHash[:])
+	if attested {
+		flags |= 0x40
+	}
+	buf.WriteByte(flags)
+	binary.Write(&buf, binary.BigEndian, a.signCount)
+	if attested {
+		buf.Write(make([]byte, 16)) // AAGUID
+		binary.Write(&buf, binary.BigEndian, uint16(len(a.credID)))
+		buf.Write(a.credID)
+		buf.Write(a.coseKey)
+	}
+	return buf.Bytes()
+}
+
+func (a *softAuthenticator) create(challenge string) *webauthn.AttestationResponse {
+	attestation := encodeCBOR(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": a.authData(true),
+	})
+	resp := &webauthn.AttestationResponse{ID: b64(a.credID), RawID: b64(a.credID), Type: "public-key"}
+	resp.Response.ClientDataJSON = b64(a.clientData("webauthn.create", challenge))
+	resp.Response.AttestationObject = b64(attestation)
+	resp.Response.Transports = []string{"internal", "hybrid"}
+	return resp
+}
+
+func (a *softAuthenticator) get(challenge string) *webauthn.AssertionResponse {
+	a.signCount++
+	authData := a.authData(false)
+	clientData := a.clientData("webauthn.get", challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	signed := append(append([]byte{}, authData...), clientDataHash[:]...)
+
+	var sig []byte
+	var err error
+	if _, ok := a.signer.(ed25519.PrivateKey); ok {
+		sig, err = a.signer.Sign(rand.Reader, signed, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(signed)
+		sig, err = a.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	assert.NoError(a.t, err)
+
+	resp := &webauthn.AssertionResponse{ID: b64(a.credID), RawID: b64(a.credID), Type: "public-key"}
+	resp.Response.ClientDataJSON = b64(clientData)
+	resp.Response.AuthenticatorData = b64(authData)
+	resp.Response.Signature = b64(sig)
+	resp.Response.UserHandle = b64(a.userHandle)
+	return resp
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// encodeCBOR writes the canonical CBOR of the few types authenticators use
+func encodeCBOR(v interface{}) []byte {
+	var buf bytes.Buffer
+	writeCBOR(&buf, v)
+	return buf.Bytes()
+}
+
+func writeCBOR(buf *bytes.Buffer, v interface{}) {
+	head := func(major byte, n uint64) {
+		switch {
+		case n < 24:
+			buf.WriteByte(major<<5 | byte(n))
+		case n <= 0xff:
+			buf.Write([]byte{major<<5 | 24, byte(n)})
+		case n <= 0xffff:
+			buf.WriteByte(major<<5 | 25)
+			binary.Write(buf, binary.BigEndian, uint16(n))
+		default:
+			buf.WriteByte(major<<5 | 26)
+			binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+	}
+	switch x := v.(type) {
+	case int64:
+		if x >= 0 {
+			head(0, uint64(x))
+		} else {
+			head(1, uint64(-1-x))
+		}
+	case []byte:
+		head(2, uint64(len(x)))
+		buf.Write(x)
+	case string:
+		head(3, uint64(len(x)))
+		buf.WriteString(x)
+	case map[interface{}]interface{}:
+		head(5, uint64(len(x)))
+		keys := make([][]byte, 0, len(x))
+		values := map[string]interface{}{}
+		for k, val := range x {
+			kb := encodeCBOR(k)
+			keys = append(keys, kb)
+			values[string(kb)] = val
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+		for _, kb := range keys {
+			buf.Write(kb)
+			writeCBOR(buf, values[string(kb)])
+		}
+	default:
+		panic("unsupported CBOR value")
+	}
+}
+
+// register runs a create ceremony and returns the stored credential
+func register(t *testing.T, rp *webauthn.RelyingParty, a *softAuthenticator) *webauthn.Credential {
+	challenge := webauthn.NewChallenge()
+	cred, err := rp.VerifyRegistration(a.create(challenge), challenge, true)
+	assert.NoError(t, err)
+	return cred
+}
+
+func TestRegistrationAndLoginES256(t *testing.T) {
+	rp := newRP()
+	a := newES256Authenticator(t)
+	cred := register(t, rp, a)
+	assert.Equal(t, a.credID, cred.ID)
+	assert.Equal(t, webauthn.AlgES256, cred.Alg)
+	assert.Equal(t, []string{"internal", "hybrid"}, cred.Transports)
+	assert.True(t, cred.UserVerified)
+
+	challenge := webauthn.NewChallenge()
+	authData, err := rp.VerifyAssertion(a.get(challenge), challenge, cred.PublicKey, cred.SignCount, true)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), authData.SignCount)
+	assert.True(t, authData.UserVerified())
+}
+
+func TestRegistrationAndLoginEd25519(t *testing.T) {
+	rp := newRP()
+	a := newEd25519Authenticator(t)
+	cred := register(t, rp, a)
+	assert.Equal(t, webauthn.AlgEdDSA, cred.Alg)
+
+	challenge := webauthn.NewChallenge()
+	_, err := rp.VerifyAssertion(a.get(challenge), challenge, cred.PublicKey, cred.SignCount, true)
+	assert.NoError(t, err)
+}
+
+func TestWrongChallengeIsRejected(t *testing.T) {
+	rp := newRP()
+	a := newES256Authenticator(t)
+	_, err := rp.VerifyRegistration(a.create(webauthn.NewChallenge()), webauthn.NewChallenge(), false)
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+}
+
+func TestWrongOriginIsRejected(t *testing.T) {
+	rp := newRP()
+	a := newES256Authenticator(t)
+	cred := register(t, rp, a)
+
+	// a phishing site relaying the ceremony
+	a.origin = "https://gnfarm-login.vn"
+	challenge := webauthn.NewChallenge()
+	_, err := rp.VerifyAssertion(a.get(challenge), challenge, cred.PublicKey, cred.SignCount, false)
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+}
+
+func TestCredentialOfAnotherSiteIsRejected(t *testing.T) {
+	rp := newRP()
+	a := newES256Authenticator(t)
+	a.rpID = "example.com"
+	challenge := webauthn.NewChallenge()
+	_, err := rp.VerifyRegistration(a.create(challenge), challenge, false)
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+}
+
+func TestCeremonyTypeIsChecked(t *testing.T) {
+	rp := newRP()
+	a := newES256Authenticator(t)
+	cred := register(t, rp, a)
+
+	// a get response signed over clientData of type webauthn.create
+	challenge := webauthn.NewChallenge()
+	resp := a.get(challenge)
+	clientData := a.clientData("webauthn.create", challenge)
+	resp.Response.ClientDataJSON = b64(clientData)
+	_, err := rp.VerifyAssertion(resp, challenge, cred.PublicKey, cred.SignCount, false)
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+}
+
+func TestUserVerificationIsRequiredForLogin(t *testing.T) {
+	rp := newRP()
+	a := newES256Authenticator(t)
+	cred := register(t, rp, a)
+
+	a.uv = false
+	challenge := webauthn.NewChallenge()
+	_, err := rp.VerifyAssertion(a.get(challenge), challenge, cred.PublicKey, cred.SignCount, true)
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+
+	// presence is enough for a second factor
+	challenge = webauthn.NewChallenge()
+	_, err = rp.VerifyAssertion(a.get(challenge), challenge, cred.PublicKey, cred.SignCount, false)
+	assert.NoError(t, err)
+}
+
+func TestSignCountMustIncrease(t *testing.T) {
+	rp := newRP()
+	a := newES256Authenticator(t)
+	cred := register(t, rp, a)
+
+	challenge := webauthn.NewChallenge()
+	authData, err := rp.VerifyAssertion(a.get(challenge), challenge, cred.PublicKey, cred.SignCount, true)
+	assert.NoError(t, err)
+	stored := authData.SignCount
+
+	// a cloned authenticator is behind the stored counter
+	a.signCount = stored - 1
+	challenge = webauthn.NewChallenge()
+	_, err = rp.VerifyAssertion(a.get(challenge), challenge, cred.PublicKey, stored, true)
+	assert.ErrorIs(t, err, webauthn.ErrSignCount)
+}
+
+func TestZeroSignCountIsAccepted(t *testing.T) {
+	rp := newRP()
+	a := newES256Authenticator(t)
+	cred := register(t, rp, a)
+
+	// authenticators without a counter always send 0
+	for i := 0; i < 2; i++ {
+		a.signCount = ^uint32(0) // get increments to 0
+		challenge := webauthn.NewChallenge()
+		authData, err := rp.VerifyAssertion(a.get(challenge), challenge, cred.PublicKey, 0, true)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(0), authData.SignCount)
+	}
+}
+
+func TestBadSignatureIsRejected(t *testing.T) {
+	rp := newRP()
+	a := newES256Authenticator(t)
+	cred := register(t, rp, a)
+
+	// signed by another key
+	other := newES256Authenticator(t)
+	other.credID = a.credID
+	challenge := webauthn.NewChallenge()
+	_, err := rp.VerifyAssertion(other.get(challenge), challenge, cred.PublicKey, cred.SignCount, true)
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+}
+
+func TestRawIdMustMatchAuthenticatorData(t *testing.T) {
+	rp := newRP()
+	a := newES256Authenticator(t)
+	challenge := webauthn.NewChallenge()
+	resp := a.create(challenge)
+	resp.RawID = b64([]byte("another credential"))
+	_, err := rp.VerifyRegistration(resp, challenge, false)
+	assert.ErrorIs(t, err, webauthn.ErrVerification)
+}
+
+func TestMalformedAttestationIsRejected(t *testing.T) {
+	rp := newRP()
+	a := newES256Authenticator(t)
+	challenge := webauthn.NewChallenge()
+	resp := a.create(challenge)
+	raw, _ := base64.RawURLEncoding.DecodeString(resp.Response.AttestationObject)
+
+	for _, bad := range [][]byte{
+		raw[:len(raw)-10],              // truncated
+		append(raw, 0x00),              // trailing bytes
+		{0xbf, 0x63, 'f', 'm', 't'},    // indefinite length map
+		{0xa2, 0x01, 0x01, 0x01, 0x02}, // duplicate key
+	} {
+		resp.Response.AttestationObject = b64(bad)
+		_, err := rp.VerifyRegistration(resp, challenge, false)
+		assert.Error(t, err)
+	}
+}
+
+func TestUnsupportedKeyIsRejected(t *testing.T) {
+	// P-256 point that is not on the curve
+	cose := encodeCBOR(map[interface{}]interface{}{
+		int64(1): int64(2), int64(3): webauthn.AlgES256, int64(-1): int64(1),
+		int64(-2): bytes.Repeat([]byte{1}, 32), int64(-3): bytes.Repeat([]byte{2}, 32),
+	})
+	_, err := webauthn.ParsePublicKey(cose)
+	assert.True(t, errors.Is(err, webauthn.ErrUnsupportedKey))
+}
+
+func TestCeremonyIsSingleUseAndBoundToPurpose(t *testing.T) {
+	mr := miniredis.RunT(t)
+	global.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	challenge, err := webauthn.StartCeremony(ctx, webauthn.Ceremony{Purpose: webauthn.PurposeRegister, UserId: 42}, time.Minute)
+	assert.NoError(t, err)
+	c, err := webauthn.TakeCeremony(ctx, challenge, webauthn.PurposeRegister)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), c.UserId)
+	_, err = webauthn.TakeCeremony(ctx, challenge, webauthn.PurposeRegister)
+	assert.ErrorIs(t, err, webauthn.ErrInvalidChallenge)
+
+	// a registration challenge can't log in
+	challenge, err = webauthn.StartCeremony(ctx, webauthn.Ceremony{Purpose: webauthn.PurposeRegister, UserId: 42}, time.Minute)
+	assert.NoError(t, err)
+	_, err = webauthn.TakeCeremony(ctx, challenge, webauthn.PurposeLogin, webauthn.PurposeSecondFactor)
+	assert.ErrorIs(t, err, webauthn.ErrInvalidChallenge)
+
+	challenge, err = webauthn.StartCeremony(ctx, webauthn.Ceremony{Purpose: webauthn.PurposeSecondFactor, UserId: 42, LoginChallenge: "token"}, time.Minute)
+	assert.NoError(t, err)
+	c, err = webauthn.TakeCeremony(ctx, challenge, webauthn.PurposeLogin, webauthn.PurposeSecondFactor)
+	assert.NoError(t, err)
+	assert.Equal(t, "token", c.LoginChallenge)
+
+	// expired
+	challenge, err = webauthn.StartCeremony(ctx, webauthn.Ceremony{Purpose: webauthn.PurposeStepUp}, time.Minute)
+	assert.NoError(t, err)
+	mr.FastForward(2 * time.Minute)
+	_, err = webauthn.TakeCeremony(ctx, challenge, webauthn.PurposeStepUp)
+	assert.ErrorIs(t, err, webauthn.ErrInvalidChallenge)
+}
+
+func TestOptionsForTheBrowser(t *testing.T) {
+	rp := newRP()
+	exclude := []webauthn.CredentialDescriptor{{Type: "public-key", ID: b64([]byte{1, 2, 3})}}
+	creation := rp.CreationOptions(webauthn.User{ID: webauthn.UserHandle(42), Name: "a@gnfarm.vn", DisplayName: "An"}, "challenge", exclude)
+	assert.Equal(t, rpID, creation.RP.ID)
+	assert.Equal(t, b64(webauthn.UserHandle(42)), creation.User.ID)
+	assert.Equal(t, "none", creation.Attestation)
+	assert.Equal(t, exclude, creation.ExcludeCredentials)
+	assert.Equal(t, int64(60000), creation.Timeout)
+
+	request := rp.RequestOptions("challenge", nil, webauthn.UserVerificationRequired)
+	b, err := json.Marshal(request)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"allowCredentials":[]`)
+	assert.Contains(t, string(b), `"userVerification":"required"`)
+}